@@ -224,7 +224,21 @@ fi
 echo Done!
 `
 
+// offlineBuildEnv is the build environment used when chaincode.golang.offline
+// is set. Module dependencies must be vendored with the chaincode; the build
+// script already selects -mod=vendor when a vendor directory is present. The
+// build container is also started without network access.
+var offlineBuildEnv = []string{"GOPROXY=off", "GOSUMDB=off", "GOFLAGS=-mod=vendor"}
+
 func (p *Platform) DockerBuildOptions(path string) (util.DockerBuildOptions, error) {
+	if viper.GetBool("chaincode.golang.offline") {
+		return util.DockerBuildOptions{
+			Cmd:             fmt.Sprintf(buildScript, getLDFlagsOpts(), path),
+			Env:             offlineBuildEnv,
+			NetworkDisabled: true,
+		}, nil
+	}
+
 	env := []string{}
 	for _, key := range []string{"GOPROXY", "GOSUMDB"} {
 		if val, ok := os.LookupEnv(key); ok {
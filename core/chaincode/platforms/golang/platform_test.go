@@ -19,6 +19,7 @@ import (
 	"strings"
 	"testing"
 
+	docker "github.com/fsouza/go-dockerclient"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/core/chaincode/platforms/util"
 	"github.com/hyperledger/fabric/core/config/configtest"
@@ -282,6 +283,27 @@ func TestModuleDeploymentPayload(t *testing.T) {
 		})
 	})
 
+	t.Run("Vendored", func(t *testing.T) {
+		for key, val := range map[string]string{"GOPROXY": "off", "GOFLAGS": "-mod=vendor"} {
+			if old, set := os.LookupEnv(key); set {
+				defer os.Setenv(key, old)
+			} else {
+				defer os.Unsetenv(key)
+			}
+			os.Setenv(key, val)
+		}
+
+		dp, err := platform.GetDeploymentPayload("testdata/ccmodule-vendored")
+		require.NoError(t, err)
+		contents := tarContents(t, dp)
+		require.ElementsMatch(t, contents, []string{
+			"src/chaincode.go",
+			"src/go.mod",
+			"src/vendor/example.com/dep/dep.go",
+			"src/vendor/modules.txt",
+		})
+	})
+
 	t.Run("NestedPackage", func(t *testing.T) {
 		dp, err := platform.GetDeploymentPayload("testdata/ccmodule/nested")
 		require.NoError(t, err)
@@ -429,6 +451,65 @@ echo Done!
 	})
 }
 
+func TestDockerBuildOptionsOffline(t *testing.T) {
+	viper.Set("chaincode.golang.offline", true)
+	defer viper.Set("chaincode.golang.offline", false)
+
+	oldGoproxy, set := os.LookupEnv("GOPROXY")
+	if set {
+		defer os.Setenv("GOPROXY", oldGoproxy)
+	}
+	os.Setenv("GOPROXY", "the-goproxy")
+
+	platform := &Platform{}
+	opts, err := platform.DockerBuildOptions("the-path")
+	require.NoError(t, err, "unexpected error from DockerBuildOptions")
+	require.Equal(t, []string{"GOPROXY=off", "GOSUMDB=off", "GOFLAGS=-mod=vendor"}, opts.Env)
+	require.True(t, opts.NetworkDisabled)
+	require.Contains(t, opts.Cmd, `GO111MODULE=on go build -v -mod=vendor -ldflags "-linkmode external -extldflags '-static'" -o /chaincode/output/chaincode the-path`)
+}
+
+func TestDockerBuildOffline(t *testing.T) {
+	viper.Set("chaincode.golang.offline", true)
+	defer viper.Set("chaincode.golang.offline", false)
+
+	for key, val := range map[string]string{"GOPROXY": "off", "GOFLAGS": "-mod=vendor"} {
+		if old, set := os.LookupEnv(key); set {
+			defer os.Setenv(key, old)
+		} else {
+			defer os.Unsetenv(key)
+		}
+		os.Setenv(key, val)
+	}
+
+	client, err := docker.NewClientFromEnv()
+	require.NoError(t, err, "failed to get docker client")
+
+	platform := &Platform{}
+	dp, err := platform.GetDeploymentPayload("testdata/ccmodule-vendored")
+	require.NoError(t, err)
+	opts, err := platform.DockerBuildOptions("ccmodulevendored")
+	require.NoError(t, err)
+
+	output := &bytes.Buffer{}
+	opts.InputStream = bytes.NewReader(dp)
+	opts.OutputStream = output
+	err = util.DockerBuild(opts, client)
+	require.NoError(t, err, "offline build of vendored module failed")
+
+	tr := tar.NewReader(output)
+	var files []string
+	for {
+		header, err := tr.Next()
+		if err == io.EOF {
+			break
+		}
+		require.NoError(t, err)
+		files = append(files, header.Name)
+	}
+	require.Contains(t, files, "chaincode")
+}
+
 func TestDescribeCode(t *testing.T) {
 	abs, err := filepath.Abs(filepath.FromSlash("testdata/ccmodule"))
 	require.NoError(t, err)
@@ -0,0 +1,13 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import "example.com/dep"
+
+func main() {
+	dep.Run()
+}
@@ -0,0 +1,5 @@
+module ccmodulevendored
+
+go 1.14
+
+require example.com/dep v0.0.0
@@ -22,11 +22,12 @@ import (
 var logger = flogging.MustGetLogger("chaincode.platform.util")
 
 type DockerBuildOptions struct {
-	Image        string
-	Cmd          string
-	Env          []string
-	InputStream  io.Reader
-	OutputStream io.Writer
+	Image           string
+	Cmd             string
+	Env             []string
+	NetworkDisabled bool
+	InputStream     io.Reader
+	OutputStream    io.Writer
 }
 
 //-------------------------------------------------------------------------------------------
@@ -48,6 +49,7 @@ type DockerBuildOptions struct {
 // The input parameters are fairly simple:
 //      - Image:        (optional) The builder image to use or "chaincode.builder"
 //      - Cmd:          The command to execute inside the container.
+//      - NetworkDisabled: Run the build container without network access.
 //      - InputStream:  A tarball of files that will be expanded into /chaincode/input.
 //      - OutputStream: A tarball of files that will be gathered from /chaincode/output
 //                      after successful execution of Cmd.
@@ -82,9 +84,10 @@ func DockerBuild(opts DockerBuildOptions, client *docker.Client) error {
 		Config: &docker.Config{
 			Image:        opts.Image,
 			Cmd:          []string{"/bin/sh", "-c", opts.Cmd},
-			Env:          opts.Env,
-			AttachStdout: true,
-			AttachStderr: true,
+			Env:             opts.Env,
+			NetworkDisabled: opts.NetworkDisabled,
+			AttachStdout:    true,
+			AttachStderr:    true,
 		},
 	})
 	if err != nil {
@@ -16,11 +16,13 @@ import (
 	"encoding/hex"
 	"fmt"
 	"io"
+	"os"
 	"regexp"
 	"strconv"
 	"strings"
 	"time"
 
+	"code.cloudfoundry.org/clock"
 	docker "github.com/fsouza/go-dockerclient"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging"
@@ -46,8 +48,9 @@ type dockerClient interface {
 	// UploadToContainer uploads a tar archive to be extracted to a path in the
 	// filesystem of the container.
 	UploadToContainer(id string, opts docker.UploadToContainerOptions) error
-	// StartContainer starts a docker container, returns an error in case of failure
-	StartContainer(id string, cfg *docker.HostConfig) error
+	// StartContainerWithContext starts a docker container, returns an error in
+	// case of failure. The context object can be used to cancel the request.
+	StartContainerWithContext(id string, cfg *docker.HostConfig, ctx context.Context) error
 	// AttachToContainer attaches to a docker container, returns an error in case of
 	// failure
 	AttachToContainer(opts docker.AttachToContainerOptions) error
@@ -70,6 +73,9 @@ type dockerClient interface {
 	WaitContainer(containerID string) (int, error)
 	// InspectImage returns an image by its name or ID.
 	InspectImage(imageName string) (*docker.Image, error)
+	// Logs gets stdout and stderr logs from the specified container. The
+	// context in the options can be used to cancel the request.
+	Logs(opts docker.LogsOptions) error
 }
 
 type PlatformBuilder interface {
@@ -111,6 +117,37 @@ type DockerVM struct {
 	PlatformBuilder PlatformBuilder
 	LoggingEnv      []string
 	MSPID           string
+	// AuthConfigurations are the registry credentials used to pull the
+	// base images referenced by chaincode Dockerfiles. When empty, images
+	// are pulled without authentication.
+	AuthConfigurations docker.AuthConfigurations
+	// StopTimeout is how long a chaincode container is given to exit after
+	// receiving SIGTERM before it is killed. When zero, the container is
+	// killed immediately.
+	StopTimeout time.Duration
+	// Mounts are host directories or volumes mounted into every chaincode
+	// container in addition to those in HostConfig. Anything mounted is
+	// readable, and unless marked read only writable, by all chaincode run
+	// by the peer, so only mount content every chaincode is trusted with.
+	Mounts []docker.HostMount
+	// StartTimeout bounds the docker requests made to create, populate, and
+	// start a chaincode container so that Start returns promptly when the
+	// daemon does not respond. When zero, the requests are not bounded.
+	StartTimeout time.Duration
+	// Clock is used to time image builds and to enforce the attach and stop
+	// timeouts. When nil, the real clock is used. Tests can inject a fake
+	// clock to control the passage of time.
+	Clock clock.Clock
+}
+
+// StopResult describes how a chaincode container was stopped.
+type StopResult struct {
+	// Graceful is true when the container exited on SIGTERM within the
+	// stop timeout.
+	Graceful bool
+	// Killed is true when the container did not drain within the stop
+	// timeout and had to be killed.
+	Killed bool
 }
 
 // HealthCheck checks if the DockerVM is able to communicate with the Docker
@@ -122,10 +159,14 @@ func (vm *DockerVM) HealthCheck(ctx context.Context) error {
 	return nil
 }
 
-func (vm *DockerVM) createContainer(imageID, containerID string, args, env []string) error {
+func (vm *DockerVM) createContainer(ctx context.Context, imageID, containerID string, args, env []string) error {
 	logger := dockerLogger.With("imageID", imageID, "containerID", containerID)
 	logger.Debugw("create container")
-	_, err := vm.Client.CreateContainer(docker.CreateContainerOptions{
+	hostConfig, err := vm.hostConfig()
+	if err != nil {
+		return err
+	}
+	_, err = vm.Client.CreateContainer(docker.CreateContainerOptions{
 		Name: containerID,
 		Config: &docker.Config{
 			Cmd:          args,
@@ -134,7 +175,8 @@ func (vm *DockerVM) createContainer(imageID, containerID string, args, env []str
 			AttachStdout: vm.AttachStdOut,
 			AttachStderr: vm.AttachStdOut,
 		},
-		HostConfig: vm.HostConfig,
+		HostConfig: hostConfig,
+		Context:    ctx,
 	})
 	if err != nil {
 		return err
@@ -143,6 +185,31 @@ func (vm *DockerVM) createContainer(imageID, containerID string, args, env []str
 	return nil
 }
 
+// hostConfig returns the host configuration for chaincode containers with
+// the configured mounts appended to those already present in HostConfig.
+// The source of each bind mount must exist on the host.
+func (vm *DockerVM) hostConfig() (*docker.HostConfig, error) {
+	if len(vm.Mounts) == 0 {
+		return vm.HostConfig, nil
+	}
+
+	for _, m := range vm.Mounts {
+		if m.Type != "" && m.Type != "bind" {
+			continue
+		}
+		if _, err := os.Stat(m.Source); err != nil {
+			return nil, errors.Wrapf(err, "invalid source for mount %s", m.Target)
+		}
+	}
+
+	hostConfig := &docker.HostConfig{}
+	if vm.HostConfig != nil {
+		*hostConfig = *vm.HostConfig
+	}
+	hostConfig.Mounts = append(append([]docker.HostMount{}, hostConfig.Mounts...), vm.Mounts...)
+	return hostConfig, nil
+}
+
 func (vm *DockerVM) buildImage(ccid string, reader io.Reader) error {
 	id, err := vm.GetVMNameForDocker(ccid)
 	if err != nil {
@@ -156,15 +223,16 @@ func (vm *DockerVM) buildImage(ccid string, reader io.Reader) error {
 		NetworkMode:  vm.NetworkMode,
 		InputStream:  reader,
 		OutputStream: outputbuf,
+		AuthConfigs:  vm.AuthConfigurations,
 	}
 
-	startTime := time.Now()
+	startTime := vm.clock().Now()
 	err = vm.Client.BuildImage(opts)
 
 	vm.BuildMetrics.ChaincodeImageBuildDuration.With(
 		"chaincode", ccid,
 		"success", strconv.FormatBool(err == nil),
-	).Observe(time.Since(startTime).Seconds())
+	).Observe(vm.clock().Since(startTime).Seconds())
 
 	if err != nil {
 		dockerLogger.Errorf("Error building image: %s", err)
@@ -287,6 +355,13 @@ func (vm *DockerVM) Start(ccid string, ccType string, peerConnection *ccintf.Pee
 
 	vm.stopInternal(containerName)
 
+	ctx := context.Background()
+	if vm.StartTimeout > 0 {
+		var cancel context.CancelFunc
+		ctx, cancel = context.WithTimeout(ctx, vm.StartTimeout)
+		defer cancel()
+	}
+
 	args, err := vm.GetArgs(ccType, peerConnection.Address)
 	if err != nil {
 		return errors.WithMessage(err, "could not get args")
@@ -296,16 +371,16 @@ func (vm *DockerVM) Start(ccid string, ccType string, peerConnection *ccintf.Pee
 	env := vm.GetEnv(ccid, peerConnection.TLSConfig)
 	dockerLogger.Debugf("start container with env:\n\t%s", strings.Join(env, "\n\t"))
 
-	err = vm.createContainer(imageName, containerName, args, env)
+	err = vm.createContainer(ctx, imageName, containerName, args, env)
 	if err != nil {
 		logger.Errorf("create container failed: %s", err)
-		return err
+		return startError(ctx, containerName, err)
 	}
 
 	// stream stdout and stderr to chaincode logger
 	if vm.AttachStdOut {
 		containerLogger := flogging.MustGetLogger("peer.chaincode." + containerName)
-		streamOutput(dockerLogger, vm.Client, containerName, containerLogger)
+		streamOutput(dockerLogger, vm.Client, vm.clock(), containerName, containerLogger)
 	}
 
 	// upload TLS files to the container before starting it if needed
@@ -339,23 +414,33 @@ func (vm *DockerVM) Start(ccid string, ccType string, peerConnection *ccintf.Pee
 			InputStream:          bytes.NewReader(payload.Bytes()),
 			Path:                 "/",
 			NoOverwriteDirNonDir: false,
+			Context:              ctx,
 		})
 		if err != nil {
-			return fmt.Errorf("Error uploading files to the container instance %s: %s", containerName, err)
+			return startError(ctx, containerName, fmt.Errorf("Error uploading files to the container instance %s: %s", containerName, err))
 		}
 	}
 
 	// start container with HostConfig was deprecated since v1.10 and removed in v1.2
-	err = vm.Client.StartContainer(containerName, nil)
+	err = vm.Client.StartContainerWithContext(containerName, nil, ctx)
 	if err != nil {
 		dockerLogger.Errorf("start-could not start container: %s", err)
-		return err
+		return startError(ctx, containerName, err)
 	}
 
 	dockerLogger.Debugf("Started container %s", containerName)
 	return nil
 }
 
+// startError reports a failed request to start a container as a timeout
+// when the start deadline has passed.
+func startError(ctx context.Context, containerName string, err error) error {
+	if ctx.Err() == context.DeadlineExceeded {
+		return errors.Wrapf(err, "timed out starting container %s", containerName)
+	}
+	return err
+}
+
 func addFiles(tw *tar.Writer, contents map[string][]byte) error {
 	for name, payload := range contents {
 		err := tw.WriteHeader(&tar.Header{
@@ -377,7 +462,7 @@ func addFiles(tw *tar.Writer, contents map[string][]byte) error {
 }
 
 // streamOutput mirrors output from the named container to a fabric logger.
-func streamOutput(logger *flogging.FabricLogger, client dockerClient, containerName string, containerLogger *flogging.FabricLogger) {
+func streamOutput(logger *flogging.FabricLogger, client dockerClient, clk clock.Clock, containerName string, containerLogger *flogging.FabricLogger) {
 	// Launch a few go routines to manage output streams from the container.
 	// They will be automatically destroyed when the container exits
 	attached := make(chan struct{})
@@ -412,7 +497,7 @@ func streamOutput(logger *flogging.FabricLogger, client dockerClient, containerN
 		case <-attached: // successful attach
 			close(attached) // close indicates the streams can now be copied
 
-		case <-time.After(10 * time.Second):
+		case <-clk.After(10 * time.Second):
 			logger.Errorf("Timeout while attaching to IO channel in container %s", containerName)
 			return
 		}
@@ -438,8 +523,36 @@ func streamOutput(logger *flogging.FabricLogger, client dockerClient, containerN
 	}()
 }
 
+// StreamLogs copies the stdout and stderr of the chaincode container to the
+// provided writer. The container output is followed until the container
+// exits or the context is done.
+func (vm *DockerVM) StreamLogs(ctx context.Context, ccid string, w io.Writer) error {
+	id := vm.ccidToContainerID(ccid)
+	err := vm.Client.Logs(docker.LogsOptions{
+		Context:      ctx,
+		Container:    id,
+		OutputStream: w,
+		ErrorStream:  w,
+		Follow:       true,
+		Stdout:       true,
+		Stderr:       true,
+		Since:        0, // from the start of the container output
+	})
+	if err != nil && ctx.Err() == nil {
+		return errors.Wrapf(err, "failed to stream logs for container %s", id)
+	}
+	return nil
+}
+
 // Stop stops a running chaincode
 func (vm *DockerVM) Stop(ccid string) error {
+	_, err := vm.StopWithResult(ccid)
+	return err
+}
+
+// StopWithResult stops a running chaincode and reports whether the container
+// drained gracefully within the stop timeout or had to be killed.
+func (vm *DockerVM) StopWithResult(ccid string) (StopResult, error) {
 	id := vm.ccidToContainerID(ccid)
 	return vm.stopInternal(id)
 }
@@ -454,22 +567,62 @@ func (vm *DockerVM) ccidToContainerID(ccid string) string {
 	return strings.Replace(vm.GetVMName(ccid), ":", "_", -1)
 }
 
-func (vm *DockerVM) stopInternal(id string) error {
+func (vm *DockerVM) stopInternal(id string) (StopResult, error) {
 	logger := dockerLogger.With("id", id)
+	var result StopResult
 
 	logger.Debugw("stopping container")
-	err := vm.Client.StopContainer(id, 0)
-	dockerLogger.Debugw("stop container result", "error", err)
-
-	logger.Debugw("killing container")
-	err = vm.Client.KillContainer(docker.KillContainerOptions{ID: id})
-	logger.Debugw("kill container result", "error", err)
+	err := vm.Client.KillContainer(docker.KillContainerOptions{ID: id, Signal: docker.SIGTERM})
+	logger.Debugw("stop container result", "error", err)
+	if err == nil {
+		if vm.StopTimeout > 0 {
+			result.Graceful = vm.waitForExit(id)
+			if !result.Graceful {
+				logger.Warnw("container did not stop within timeout, killing", "timeout", vm.StopTimeout)
+			}
+		}
+		if !result.Graceful {
+			err = vm.Client.KillContainer(docker.KillContainerOptions{ID: id, Signal: docker.SIGKILL})
+			logger.Debugw("kill container result", "error", err)
+			result.Killed = err == nil
+		}
+	}
 
 	logger.Debugw("removing container")
 	err = vm.Client.RemoveContainer(docker.RemoveContainerOptions{ID: id, Force: true})
 	logger.Debugw("remove container result", "error", err)
 
-	return err
+	return result, err
+}
+
+// waitForExit waits up to the stop timeout for the container to exit and
+// reports whether it did. A failed wait is reported as the container not
+// having exited.
+func (vm *DockerVM) waitForExit(id string) bool {
+	exited := make(chan bool, 1)
+	go func() {
+		_, err := vm.Client.WaitContainer(id)
+		if err != nil {
+			dockerLogger.Debugw("wait container failed", "id", id, "error", err)
+		}
+		exited <- err == nil
+	}()
+
+	timer := vm.clock().NewTimer(vm.StopTimeout)
+	defer timer.Stop()
+	select {
+	case ok := <-exited:
+		return ok
+	case <-timer.C():
+		return false
+	}
+}
+
+func (vm *DockerVM) clock() clock.Clock {
+	if vm.Clock == nil {
+		return clock.NewClock()
+	}
+	return vm.Clock
 }
 
 // GetVMName generates the VM name from peer information. It accepts a format
@@ -16,9 +16,13 @@ import (
 	"fmt"
 	"io"
 	"io/ioutil"
+	"os"
+	"path/filepath"
 	"testing"
 	"time"
 
+	"code.cloudfoundry.org/clock"
+	"code.cloudfoundry.org/clock/fakeclock"
 	docker "github.com/fsouza/go-dockerclient"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/common/flogging/floggingtest"
@@ -200,6 +204,81 @@ func Test_Start(t *testing.T) {
 
 	err = dvm.Start(ccid, "GOLANG", peerConnection)
 	gt.Expect(err).NotTo(HaveOccurred())
+
+	// mounts are merged into the host config
+	mountDir, err := ioutil.TempDir("", "mounts")
+	gt.Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(mountDir)
+	dvm.HostConfig = &docker.HostConfig{
+		NetworkMode: "host",
+		Mounts:      []docker.HostMount{{Type: "volume", Source: "cache", Target: "/cache"}},
+	}
+	dvm.Mounts = []docker.HostMount{{Type: "bind", Source: mountDir, Target: "/secrets", ReadOnly: true}}
+	err = dvm.Start(ccid, "GOLANG", peerConnection)
+	gt.Expect(err).NotTo(HaveOccurred())
+	opts := dockerClient.CreateContainerArgsForCall(dockerClient.CreateContainerCallCount() - 1)
+	gt.Expect(opts.HostConfig.NetworkMode).To(Equal("host"))
+	gt.Expect(opts.HostConfig.Mounts).To(Equal([]docker.HostMount{
+		{Type: "volume", Source: "cache", Target: "/cache"},
+		{Type: "bind", Source: mountDir, Target: "/secrets", ReadOnly: true},
+	}))
+	gt.Expect(dvm.HostConfig.Mounts).To(HaveLen(1))
+
+	// security options of the host config reach the container, with and
+	// without mounts to merge
+	dvm.HostConfig.SecurityOpt = []string{"no-new-privileges", "seccomp=profile.json"}
+	err = dvm.Start(ccid, "GOLANG", peerConnection)
+	gt.Expect(err).NotTo(HaveOccurred())
+	opts = dockerClient.CreateContainerArgsForCall(dockerClient.CreateContainerCallCount() - 1)
+	gt.Expect(opts.HostConfig.SecurityOpt).To(Equal([]string{"no-new-privileges", "seccomp=profile.json"}))
+	dvm.Mounts = nil
+	err = dvm.Start(ccid, "GOLANG", peerConnection)
+	gt.Expect(err).NotTo(HaveOccurred())
+	opts = dockerClient.CreateContainerArgsForCall(dockerClient.CreateContainerCallCount() - 1)
+	gt.Expect(opts.HostConfig.SecurityOpt).To(Equal([]string{"no-new-privileges", "seccomp=profile.json"}))
+
+	// mount sources must exist
+	dvm.Mounts = []docker.HostMount{{Type: "bind", Source: filepath.Join(mountDir, "missing"), Target: "/secrets"}}
+	err = dvm.Start(ccid, "GOLANG", peerConnection)
+	gt.Expect(err).To(MatchError(ContainSubstring("invalid source for mount /secrets")))
+}
+
+func Test_StartTimeout(t *testing.T) {
+	gt := NewGomegaWithT(t)
+	dockerClient := &mock.DockerClient{}
+	dvm := DockerVM{
+		BuildMetrics: NewBuildMetrics(&disabled.Provider{}),
+		Client:       dockerClient,
+		StartTimeout: 100 * time.Millisecond,
+	}
+	peerConnection := &ccintf.PeerConnection{Address: "peer-address"}
+
+	// the daemon never responds to the create request
+	dockerClient.CreateContainerStub = func(opts docker.CreateContainerOptions) (*docker.Container, error) {
+		<-opts.Context.Done()
+		return nil, opts.Context.Err()
+	}
+	errCh := make(chan error, 1)
+	go func() { errCh <- dvm.Start("simple:1.0", "GOLANG", peerConnection) }()
+	gt.Eventually(errCh, time.Second).Should(Receive(MatchError("timed out starting container simple-1.0: context deadline exceeded")))
+	dockerClient.CreateContainerStub = nil
+	dockerClient.CreateContainerReturns(&docker.Container{}, nil)
+
+	// the container never reports that it started
+	dockerClient.StartContainerWithContextStub = func(id string, cfg *docker.HostConfig, ctx context.Context) error {
+		<-ctx.Done()
+		return ctx.Err()
+	}
+	go func() { errCh <- dvm.Start("simple:1.0", "GOLANG", peerConnection) }()
+	gt.Eventually(errCh, time.Second).Should(Receive(MatchError("timed out starting container simple-1.0: context deadline exceeded")))
+
+	// requests that complete are not affected by the deadline
+	dockerClient.StartContainerWithContextStub = nil
+	err := dvm.Start("simple:1.0", "GOLANG", peerConnection)
+	gt.Expect(err).NotTo(HaveOccurred())
+	_, _, ctx := dockerClient.StartContainerWithContextArgsForCall(dockerClient.StartContainerWithContextCallCount() - 1)
+	_, ok := ctx.Deadline()
+	gt.Expect(ok).To(BeTrue())
 }
 
 func Test_streamOutput(t *testing.T) {
@@ -216,7 +295,7 @@ func Test_streamOutput(t *testing.T) {
 		return <-errCh
 	}
 
-	streamOutput(logger, client, "container-name", containerLogger)
+	streamOutput(logger, client, clock.NewClock(), "container-name", containerLogger)
 
 	var opts docker.AttachToContainerOptions
 	gt.Eventually(optsCh).Should(Receive(&opts))
@@ -236,6 +315,29 @@ func Test_streamOutput(t *testing.T) {
 	gt.Consistently(containerRecorder.Entries).Should(HaveLen(2))
 }
 
+func Test_streamOutputAttachTimeout(t *testing.T) {
+	gt := NewGomegaWithT(t)
+
+	logger, recorder := floggingtest.NewTestLogger(t)
+	containerLogger, _ := floggingtest.NewTestLogger(t)
+
+	client := &mock.DockerClient{}
+	block := make(chan struct{})
+	defer close(block)
+	client.AttachToContainerStub = func(opts docker.AttachToContainerOptions) error {
+		<-block
+		return nil
+	}
+
+	fakeClock := fakeclock.NewFakeClock(time.Now())
+	streamOutput(logger, client, fakeClock, "container-name", containerLogger)
+
+	gt.Eventually(fakeClock.WatcherCount).Should(Equal(1))
+	gt.Consistently(recorder.Entries).Should(BeEmpty())
+	fakeClock.Increment(10 * time.Second)
+	gt.Eventually(recorder).Should(gbytes.Say("Timeout while attaching to IO channel in container container-name"))
+}
+
 func Test_BuildMetric(t *testing.T) {
 	ccid := "simple:1.0"
 	client := &mock.DockerClient{}
@@ -273,6 +375,31 @@ func Test_BuildMetric(t *testing.T) {
 	}
 }
 
+func Test_BuildMetricDuration(t *testing.T) {
+	gt := NewGomegaWithT(t)
+
+	fakeClock := fakeclock.NewFakeClock(time.Now())
+	client := &mock.DockerClient{}
+	client.BuildImageStub = func(docker.BuildImageOptions) error {
+		fakeClock.Increment(90 * time.Second)
+		return nil
+	}
+	fakeChaincodeImageBuildDuration := &metricsfakes.Histogram{}
+	fakeChaincodeImageBuildDuration.WithReturns(fakeChaincodeImageBuildDuration)
+	dvm := DockerVM{
+		BuildMetrics: &BuildMetrics{
+			ChaincodeImageBuildDuration: fakeChaincodeImageBuildDuration,
+		},
+		Client: client,
+		Clock:  fakeClock,
+	}
+
+	err := dvm.buildImage("simple:1.0", &bytes.Buffer{})
+	gt.Expect(err).NotTo(HaveOccurred())
+	gt.Expect(fakeChaincodeImageBuildDuration.ObserveCallCount()).To(Equal(1))
+	gt.Expect(fakeChaincodeImageBuildDuration.ObserveArgsForCall(0)).To(Equal(90.0))
+}
+
 func Test_Stop(t *testing.T) {
 	dvm := DockerVM{Client: &mock.DockerClient{}}
 	ccid := "simple"
@@ -282,6 +409,133 @@ func Test_Stop(t *testing.T) {
 	require.NoError(t, err)
 }
 
+func Test_StopWithResult(t *testing.T) {
+	ccid := "simple"
+
+	t.Run("drains gracefully", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		dvm := DockerVM{Client: client, StopTimeout: time.Minute}
+
+		result, err := dvm.StopWithResult(ccid)
+		require.NoError(t, err)
+		require.Equal(t, StopResult{Graceful: true}, result)
+
+		require.Equal(t, 1, client.KillContainerCallCount())
+		require.Equal(t, docker.SIGTERM, client.KillContainerArgsForCall(0).Signal)
+		require.Equal(t, 1, client.WaitContainerCallCount())
+		require.Equal(t, 1, client.RemoveContainerCallCount())
+	})
+
+	t.Run("killed after timeout", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		killed := make(chan struct{})
+		client.KillContainerStub = func(opts docker.KillContainerOptions) error {
+			if opts.Signal == docker.SIGKILL {
+				close(killed)
+			}
+			return nil
+		}
+		client.WaitContainerStub = func(string) (int, error) {
+			<-killed
+			return 137, nil
+		}
+		dvm := DockerVM{Client: client, StopTimeout: 10 * time.Millisecond}
+
+		result, err := dvm.StopWithResult(ccid)
+		require.NoError(t, err)
+		require.Equal(t, StopResult{Killed: true}, result)
+
+		require.Equal(t, 2, client.KillContainerCallCount())
+		require.Equal(t, docker.SIGTERM, client.KillContainerArgsForCall(0).Signal)
+		require.Equal(t, docker.SIGKILL, client.KillContainerArgsForCall(1).Signal)
+		require.Equal(t, 1, client.RemoveContainerCallCount())
+	})
+
+	t.Run("killed when the fake clock passes the timeout", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		killed := make(chan struct{})
+		client.KillContainerStub = func(opts docker.KillContainerOptions) error {
+			if opts.Signal == docker.SIGKILL {
+				close(killed)
+			}
+			return nil
+		}
+		client.WaitContainerStub = func(string) (int, error) {
+			<-killed
+			return 137, nil
+		}
+		fakeClock := fakeclock.NewFakeClock(time.Now())
+		dvm := DockerVM{Client: client, StopTimeout: time.Hour, Clock: fakeClock}
+
+		resultCh := make(chan StopResult, 1)
+		go func() {
+			result, err := dvm.StopWithResult(ccid)
+			require.NoError(t, err)
+			resultCh <- result
+		}()
+
+		require.Eventually(t, func() bool { return fakeClock.WatcherCount() == 1 }, time.Second, time.Millisecond)
+		fakeClock.Increment(time.Hour - time.Second)
+		require.Never(t, func() bool { return len(resultCh) > 0 }, 50*time.Millisecond, time.Millisecond)
+		fakeClock.Increment(time.Second)
+
+		var result StopResult
+		require.Eventually(t, func() bool {
+			select {
+			case result = <-resultCh:
+				return true
+			default:
+				return false
+			}
+		}, time.Second, time.Millisecond)
+		require.Equal(t, StopResult{Killed: true}, result)
+		require.Equal(t, docker.SIGKILL, client.KillContainerArgsForCall(1).Signal)
+	})
+
+	t.Run("no stop timeout", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		dvm := DockerVM{Client: client}
+
+		result, err := dvm.StopWithResult(ccid)
+		require.NoError(t, err)
+		require.Equal(t, StopResult{Killed: true}, result)
+		require.Equal(t, 0, client.WaitContainerCallCount())
+	})
+
+	t.Run("wait fails", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		client.WaitContainerReturns(0, errors.New("no-wait-for-you"))
+		dvm := DockerVM{Client: client, StopTimeout: time.Minute}
+
+		result, err := dvm.StopWithResult(ccid)
+		require.NoError(t, err)
+		require.Equal(t, StopResult{Killed: true}, result)
+		require.Equal(t, 2, client.KillContainerCallCount())
+		require.Equal(t, docker.SIGKILL, client.KillContainerArgsForCall(1).Signal)
+	})
+
+	t.Run("container not running", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		client.KillContainerReturns(&docker.ContainerNotRunning{ID: "simple"})
+		dvm := DockerVM{Client: client, StopTimeout: time.Minute}
+
+		result, err := dvm.StopWithResult(ccid)
+		require.NoError(t, err)
+		require.Equal(t, StopResult{}, result)
+		require.Equal(t, 1, client.KillContainerCallCount())
+		require.Equal(t, 1, client.RemoveContainerCallCount())
+	})
+
+	t.Run("remove fails", func(t *testing.T) {
+		client := &mock.DockerClient{}
+		client.RemoveContainerReturns(errors.New("remove-failed"))
+		dvm := DockerVM{Client: client, StopTimeout: time.Minute}
+
+		_, err := dvm.StopWithResult(ccid)
+		require.EqualError(t, err, "remove-failed")
+	})
+}
+
 func Test_Wait(t *testing.T) {
 	dvm := DockerVM{}
 
@@ -301,6 +555,44 @@ func Test_Wait(t *testing.T) {
 	require.EqualError(t, err, "no-wait-for-you")
 }
 
+func Test_StreamLogs(t *testing.T) {
+	client := &mock.DockerClient{}
+	dvm := DockerVM{Client: client}
+
+	// happy path
+	buf := &bytes.Buffer{}
+	client.LogsStub = func(opts docker.LogsOptions) error {
+		_, err := opts.OutputStream.Write([]byte("chaincode output"))
+		return err
+	}
+	err := dvm.StreamLogs(context.Background(), "the-name:the-version", buf)
+	require.NoError(t, err)
+	require.Equal(t, "chaincode output", buf.String())
+	require.Equal(t, 1, client.LogsCallCount())
+	opts := client.LogsArgsForCall(0)
+	require.Equal(t, "the-name-the-version", opts.Container)
+	require.True(t, opts.Follow)
+	require.True(t, opts.Stdout)
+	require.True(t, opts.Stderr)
+	require.Equal(t, int64(0), opts.Since)
+	require.Equal(t, buf, opts.OutputStream)
+	require.Equal(t, buf, opts.ErrorStream)
+
+	// logs fail
+	client.LogsStub = nil
+	client.LogsReturns(errors.New("no-logs-for-you"))
+	err = dvm.StreamLogs(context.Background(), "the-name:the-version", buf)
+	require.EqualError(t, err, "failed to stream logs for container the-name-the-version: no-logs-for-you")
+
+	// context canceled
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	client.LogsReturns(context.Canceled)
+	err = dvm.StreamLogs(ctx, "the-name:the-version", buf)
+	require.NoError(t, err)
+	require.Equal(t, ctx, client.LogsArgsForCall(2).Context)
+}
+
 func TestHealthCheck(t *testing.T) {
 	client := &mock.DockerClient{}
 	vm := &DockerVM{Client: client}
@@ -404,6 +696,32 @@ func Test_buildImage(t *testing.T) {
 	require.Equal(t, "network-mode", opts.NetworkMode)
 	require.Equal(t, &bytes.Buffer{}, opts.InputStream)
 	require.NotNil(t, opts.OutputStream)
+	require.Empty(t, opts.AuthConfigs.Configs)
+}
+
+func Test_buildImageWithAuthConfigurations(t *testing.T) {
+	client := &mock.DockerClient{}
+	authConfigs := docker.AuthConfigurations{
+		Configs: map[string]docker.AuthConfiguration{
+			"registry.example.com": {
+				Username:      "username",
+				Password:      "password",
+				ServerAddress: "registry.example.com",
+			},
+		},
+	}
+	dvm := DockerVM{
+		BuildMetrics:       NewBuildMetrics(&disabled.Provider{}),
+		Client:             client,
+		AuthConfigurations: authConfigs,
+	}
+
+	err := dvm.buildImage("simple", &bytes.Buffer{})
+	require.NoError(t, err)
+	require.Equal(t, 1, client.BuildImageCallCount())
+
+	opts := client.BuildImageArgsForCall(0)
+	require.Equal(t, authConfigs, opts.AuthConfigs)
 }
 
 func Test_buildImageFailure(t *testing.T) {
@@ -68,6 +68,17 @@ type DockerClient struct {
 	killContainerReturnsOnCall map[int]struct {
 		result1 error
 	}
+	LogsStub        func(docker.LogsOptions) error
+	logsMutex       sync.RWMutex
+	logsArgsForCall []struct {
+		arg1 docker.LogsOptions
+	}
+	logsReturns struct {
+		result1 error
+	}
+	logsReturnsOnCall map[int]struct {
+		result1 error
+	}
 	PingWithContextStub        func(context.Context) error
 	pingWithContextMutex       sync.RWMutex
 	pingWithContextArgsForCall []struct {
@@ -90,16 +101,17 @@ type DockerClient struct {
 	removeContainerReturnsOnCall map[int]struct {
 		result1 error
 	}
-	StartContainerStub        func(string, *docker.HostConfig) error
-	startContainerMutex       sync.RWMutex
-	startContainerArgsForCall []struct {
+	StartContainerWithContextStub        func(string, *docker.HostConfig, context.Context) error
+	startContainerWithContextMutex       sync.RWMutex
+	startContainerWithContextArgsForCall []struct {
 		arg1 string
 		arg2 *docker.HostConfig
+		arg3 context.Context
 	}
-	startContainerReturns struct {
+	startContainerWithContextReturns struct {
 		result1 error
 	}
-	startContainerReturnsOnCall map[int]struct {
+	startContainerWithContextReturnsOnCall map[int]struct {
 		result1 error
 	}
 	StopContainerStub        func(string, uint) error
@@ -449,6 +461,66 @@ func (fake *DockerClient) KillContainerReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
+func (fake *DockerClient) Logs(arg1 docker.LogsOptions) error {
+	fake.logsMutex.Lock()
+	ret, specificReturn := fake.logsReturnsOnCall[len(fake.logsArgsForCall)]
+	fake.logsArgsForCall = append(fake.logsArgsForCall, struct {
+		arg1 docker.LogsOptions
+	}{arg1})
+	fake.recordInvocation("Logs", []interface{}{arg1})
+	fake.logsMutex.Unlock()
+	if fake.LogsStub != nil {
+		return fake.LogsStub(arg1)
+	}
+	if specificReturn {
+		return ret.result1
+	}
+	fakeReturns := fake.logsReturns
+	return fakeReturns.result1
+}
+
+func (fake *DockerClient) LogsCallCount() int {
+	fake.logsMutex.RLock()
+	defer fake.logsMutex.RUnlock()
+	return len(fake.logsArgsForCall)
+}
+
+func (fake *DockerClient) LogsCalls(stub func(docker.LogsOptions) error) {
+	fake.logsMutex.Lock()
+	defer fake.logsMutex.Unlock()
+	fake.LogsStub = stub
+}
+
+func (fake *DockerClient) LogsArgsForCall(i int) docker.LogsOptions {
+	fake.logsMutex.RLock()
+	defer fake.logsMutex.RUnlock()
+	argsForCall := fake.logsArgsForCall[i]
+	return argsForCall.arg1
+}
+
+func (fake *DockerClient) LogsReturns(result1 error) {
+	fake.logsMutex.Lock()
+	defer fake.logsMutex.Unlock()
+	fake.LogsStub = nil
+	fake.logsReturns = struct {
+		result1 error
+	}{result1}
+}
+
+func (fake *DockerClient) LogsReturnsOnCall(i int, result1 error) {
+	fake.logsMutex.Lock()
+	defer fake.logsMutex.Unlock()
+	fake.LogsStub = nil
+	if fake.logsReturnsOnCall == nil {
+		fake.logsReturnsOnCall = make(map[int]struct {
+			result1 error
+		})
+	}
+	fake.logsReturnsOnCall[i] = struct {
+		result1 error
+	}{result1}
+}
+
 func (fake *DockerClient) PingWithContext(arg1 context.Context) error {
 	fake.pingWithContextMutex.Lock()
 	ret, specificReturn := fake.pingWithContextReturnsOnCall[len(fake.pingWithContextArgsForCall)]
@@ -569,63 +641,64 @@ func (fake *DockerClient) RemoveContainerReturnsOnCall(i int, result1 error) {
 	}{result1}
 }
 
-func (fake *DockerClient) StartContainer(arg1 string, arg2 *docker.HostConfig) error {
-	fake.startContainerMutex.Lock()
-	ret, specificReturn := fake.startContainerReturnsOnCall[len(fake.startContainerArgsForCall)]
-	fake.startContainerArgsForCall = append(fake.startContainerArgsForCall, struct {
+func (fake *DockerClient) StartContainerWithContext(arg1 string, arg2 *docker.HostConfig, arg3 context.Context) error {
+	fake.startContainerWithContextMutex.Lock()
+	ret, specificReturn := fake.startContainerWithContextReturnsOnCall[len(fake.startContainerWithContextArgsForCall)]
+	fake.startContainerWithContextArgsForCall = append(fake.startContainerWithContextArgsForCall, struct {
 		arg1 string
 		arg2 *docker.HostConfig
-	}{arg1, arg2})
-	fake.recordInvocation("StartContainer", []interface{}{arg1, arg2})
-	fake.startContainerMutex.Unlock()
-	if fake.StartContainerStub != nil {
-		return fake.StartContainerStub(arg1, arg2)
+		arg3 context.Context
+	}{arg1, arg2, arg3})
+	fake.recordInvocation("StartContainerWithContext", []interface{}{arg1, arg2, arg3})
+	fake.startContainerWithContextMutex.Unlock()
+	if fake.StartContainerWithContextStub != nil {
+		return fake.StartContainerWithContextStub(arg1, arg2, arg3)
 	}
 	if specificReturn {
 		return ret.result1
 	}
-	fakeReturns := fake.startContainerReturns
+	fakeReturns := fake.startContainerWithContextReturns
 	return fakeReturns.result1
 }
 
-func (fake *DockerClient) StartContainerCallCount() int {
-	fake.startContainerMutex.RLock()
-	defer fake.startContainerMutex.RUnlock()
-	return len(fake.startContainerArgsForCall)
+func (fake *DockerClient) StartContainerWithContextCallCount() int {
+	fake.startContainerWithContextMutex.RLock()
+	defer fake.startContainerWithContextMutex.RUnlock()
+	return len(fake.startContainerWithContextArgsForCall)
 }
 
-func (fake *DockerClient) StartContainerCalls(stub func(string, *docker.HostConfig) error) {
-	fake.startContainerMutex.Lock()
-	defer fake.startContainerMutex.Unlock()
-	fake.StartContainerStub = stub
+func (fake *DockerClient) StartContainerWithContextCalls(stub func(string, *docker.HostConfig, context.Context) error) {
+	fake.startContainerWithContextMutex.Lock()
+	defer fake.startContainerWithContextMutex.Unlock()
+	fake.StartContainerWithContextStub = stub
 }
 
-func (fake *DockerClient) StartContainerArgsForCall(i int) (string, *docker.HostConfig) {
-	fake.startContainerMutex.RLock()
-	defer fake.startContainerMutex.RUnlock()
-	argsForCall := fake.startContainerArgsForCall[i]
-	return argsForCall.arg1, argsForCall.arg2
+func (fake *DockerClient) StartContainerWithContextArgsForCall(i int) (string, *docker.HostConfig, context.Context) {
+	fake.startContainerWithContextMutex.RLock()
+	defer fake.startContainerWithContextMutex.RUnlock()
+	argsForCall := fake.startContainerWithContextArgsForCall[i]
+	return argsForCall.arg1, argsForCall.arg2, argsForCall.arg3
 }
 
-func (fake *DockerClient) StartContainerReturns(result1 error) {
-	fake.startContainerMutex.Lock()
-	defer fake.startContainerMutex.Unlock()
-	fake.StartContainerStub = nil
-	fake.startContainerReturns = struct {
+func (fake *DockerClient) StartContainerWithContextReturns(result1 error) {
+	fake.startContainerWithContextMutex.Lock()
+	defer fake.startContainerWithContextMutex.Unlock()
+	fake.StartContainerWithContextStub = nil
+	fake.startContainerWithContextReturns = struct {
 		result1 error
 	}{result1}
 }
 
-func (fake *DockerClient) StartContainerReturnsOnCall(i int, result1 error) {
-	fake.startContainerMutex.Lock()
-	defer fake.startContainerMutex.Unlock()
-	fake.StartContainerStub = nil
-	if fake.startContainerReturnsOnCall == nil {
-		fake.startContainerReturnsOnCall = make(map[int]struct {
+func (fake *DockerClient) StartContainerWithContextReturnsOnCall(i int, result1 error) {
+	fake.startContainerWithContextMutex.Lock()
+	defer fake.startContainerWithContextMutex.Unlock()
+	fake.StartContainerWithContextStub = nil
+	if fake.startContainerWithContextReturnsOnCall == nil {
+		fake.startContainerWithContextReturnsOnCall = make(map[int]struct {
 			result1 error
 		})
 	}
-	fake.startContainerReturnsOnCall[i] = struct {
+	fake.startContainerWithContextReturnsOnCall[i] = struct {
 		result1 error
 	}{result1}
 }
@@ -828,12 +901,14 @@ func (fake *DockerClient) Invocations() map[string][][]interface{} {
 	defer fake.inspectImageMutex.RUnlock()
 	fake.killContainerMutex.RLock()
 	defer fake.killContainerMutex.RUnlock()
+	fake.logsMutex.RLock()
+	defer fake.logsMutex.RUnlock()
 	fake.pingWithContextMutex.RLock()
 	defer fake.pingWithContextMutex.RUnlock()
 	fake.removeContainerMutex.RLock()
 	defer fake.removeContainerMutex.RUnlock()
-	fake.startContainerMutex.RLock()
-	defer fake.startContainerMutex.RUnlock()
+	fake.startContainerWithContextMutex.RLock()
+	defer fake.startContainerWithContextMutex.RUnlock()
 	fake.stopContainerMutex.RLock()
 	defer fake.stopContainerMutex.RUnlock()
 	fake.uploadToContainerMutex.RLock()
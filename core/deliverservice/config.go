@@ -17,6 +17,7 @@ import (
 
 	"github.com/pkg/errors"
 	"github.com/spf13/viper"
+	"google.golang.org/grpc/encoding/gzip"
 )
 
 const (
@@ -44,6 +45,11 @@ type DeliverServiceConfig struct {
 	// OrdererEndpointOverrides is a map of orderer addresses which should be
 	// re-mapped to a different orderer endpoint.
 	OrdererEndpointOverrides map[string]*orderers.Endpoint
+
+	// Compression names the gRPC compressor used on deliver streams to the
+	// ordering service. Only gzip is supported; streams are not compressed
+	// when it is empty.
+	Compression string
 }
 
 type AddressOverride struct {
@@ -154,4 +160,9 @@ func (c *DeliverServiceConfig) loadDeliverServiceConfig() {
 	}
 
 	c.OrdererEndpointOverrides = overridesMap
+
+	c.Compression = viper.GetString("peer.deliveryclient.compression")
+	if c.Compression != "" && c.Compression != gzip.Name {
+		panic(errors.Errorf("unsupported peer.deliveryclient.compression '%s'", c.Compression))
+	}
 }
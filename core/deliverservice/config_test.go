@@ -88,6 +88,7 @@ func TestGlobalConfig(t *testing.T) {
 	viper.Set("peer.deliveryclient.connTimeout", "10s")
 	viper.Set("peer.keepalive.deliveryClient.interval", "5s")
 	viper.Set("peer.keepalive.deliveryClient.timeout", "2s")
+	viper.Set("peer.deliveryclient.compression", "gzip")
 
 	coreConfig := deliverservice.GlobalConfig()
 
@@ -106,11 +107,21 @@ func TestGlobalConfig(t *testing.T) {
 		SecOpts: comm.SecureOptions{
 			UseTLS: true,
 		},
+		Compression: "gzip",
 	}
 
 	require.Equal(t, expectedConfig, coreConfig)
 }
 
+func TestGlobalConfigUnsupportedCompression(t *testing.T) {
+	viper.Reset()
+	defer viper.Reset()
+
+	viper.Set("peer.deliveryclient.compression", "snappy")
+
+	require.PanicsWithError(t, "unsupported peer.deliveryclient.compression 'snappy'", func() { deliverservice.GlobalConfig() })
+}
+
 func TestGlobalConfigDefault(t *testing.T) {
 	viper.Reset()
 	defer viper.Reset()
@@ -95,10 +95,17 @@ func (da DialerAdapter) Dial(address string, certPool *x509.CertPool) (*grpc.Cli
 	return da.Client.NewConnection(address, comm.CertPoolOverride(certPool))
 }
 
-type DeliverAdapter struct{}
+type DeliverAdapter struct {
+	// Compression names the gRPC compressor used for the deliver stream.
+	Compression string
+}
 
-func (DeliverAdapter) Deliver(ctx context.Context, clientConn *grpc.ClientConn) (orderer.AtomicBroadcast_DeliverClient, error) {
-	return orderer.NewAtomicBroadcastClient(clientConn).Deliver(ctx)
+func (da DeliverAdapter) Deliver(ctx context.Context, clientConn *grpc.ClientConn) (orderer.AtomicBroadcast_DeliverClient, error) {
+	var opts []grpc.CallOption
+	if da.Compression != "" {
+		opts = append(opts, grpc.UseCompressor(da.Compression))
+	}
+	return orderer.NewAtomicBroadcastClient(clientConn).Deliver(ctx, opts...)
 }
 
 // StartDeliverForChannel starts blocks delivery for channel
@@ -131,7 +138,7 @@ func (d *deliverServiceImpl) StartDeliverForChannel(chainID string, ledgerInfo b
 		Orderers:          d.conf.OrdererSource,
 		DoneC:             make(chan struct{}),
 		Signer:            d.conf.Signer,
-		DeliverStreamer:   DeliverAdapter{},
+		DeliverStreamer:   DeliverAdapter{Compression: d.conf.DeliverServiceConfig.Compression},
 		Logger:            flogging.MustGetLogger("peer.blocksprovider").With("channel", chainID),
 		MaxRetryDelay:     d.conf.DeliverServiceConfig.ReConnectBackoffThreshold,
 		MaxRetryDuration:  d.conf.DeliverServiceConfig.ReconnectTotalTimeThreshold,
@@ -7,15 +7,22 @@ SPDX-License-Identifier: Apache-2.0
 package deliverservice
 
 import (
+	"context"
 	"fmt"
+	"net"
+	"sync/atomic"
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
 	"github.com/hyperledger/fabric/core/deliverservice/fake"
 	"github.com/hyperledger/fabric/internal/pkg/comm"
 	"github.com/hyperledger/fabric/internal/pkg/peer/blocksprovider"
 
 	"github.com/stretchr/testify/require"
+	"google.golang.org/grpc"
 )
 
 //go:generate counterfeiter -o fake/ledger_info.go --fake-name LedgerInfo . ledgerInfo
@@ -226,3 +233,73 @@ func TestStop(t *testing.T) {
 	}
 
 }
+
+type blockServer struct {
+	orderer.UnimplementedAtomicBroadcastServer
+	block *common.Block
+}
+
+func (bs *blockServer) Deliver(stream orderer.AtomicBroadcast_DeliverServer) error {
+	if _, err := stream.Recv(); err != nil {
+		return err
+	}
+	return stream.Send(&orderer.DeliverResponse{Type: &orderer.DeliverResponse_Block{Block: bs.block}})
+}
+
+type countingListener struct {
+	net.Listener
+	written int64
+}
+
+func (cl *countingListener) Accept() (net.Conn, error) {
+	conn, err := cl.Listener.Accept()
+	if err != nil {
+		return nil, err
+	}
+	return &countingConn{Conn: conn, written: &cl.written}, nil
+}
+
+type countingConn struct {
+	net.Conn
+	written *int64
+}
+
+func (cc *countingConn) Write(b []byte) (int, error) {
+	n, err := cc.Conn.Write(b)
+	atomic.AddInt64(cc.written, int64(n))
+	return n, err
+}
+
+func TestDeliverAdapterCompression(t *testing.T) {
+	// a highly compressible block of 1MB
+	block := &common.Block{Data: &common.BlockData{Data: [][]byte{make([]byte, 1024*1024)}}}
+
+	deliver := func(adapter DeliverAdapter) int64 {
+		l, err := net.Listen("tcp", "127.0.0.1:0")
+		require.NoError(t, err)
+		listener := &countingListener{Listener: l}
+		server := grpc.NewServer()
+		orderer.RegisterAtomicBroadcastServer(server, &blockServer{block: block})
+		go server.Serve(listener)
+		defer server.Stop()
+
+		conn, err := grpc.Dial(l.Addr().String(), grpc.WithInsecure(), grpc.WithBlock())
+		require.NoError(t, err)
+		defer conn.Close()
+
+		stream, err := adapter.Deliver(context.Background(), conn)
+		require.NoError(t, err)
+		require.NoError(t, stream.Send(&common.Envelope{}))
+		resp, err := stream.Recv()
+		require.NoError(t, err)
+		require.True(t, proto.Equal(block, resp.GetBlock()))
+
+		return atomic.LoadInt64(&listener.written)
+	}
+
+	uncompressed := deliver(DeliverAdapter{})
+	require.Greater(t, uncompressed, int64(1024*1024))
+
+	compressed := deliver(DeliverAdapter{Compression: "gzip"})
+	require.Less(t, compressed, int64(64*1024))
+}
@@ -139,10 +139,14 @@ func (p *Provider) initLedgerIDInventory() error {
 
 func (p *Provider) initBlockStoreProvider() error {
 	indexConfig := &blkstorage.IndexConfig{AttrsToIndex: attrsToIndex}
+	blockfileSize := maxBlockFileSize
+	if conf := p.initializer.Config.BlockStoreConfig; conf != nil && conf.MaxBlockfileSize > 0 {
+		blockfileSize = conf.MaxBlockfileSize
+	}
 	blkStoreProvider, err := blkstorage.NewProvider(
 		blkstorage.NewConf(
 			BlockStorePath(p.initializer.Config.RootFSPath),
-			maxBlockFileSize,
+			blockfileSize,
 		),
 		indexConfig,
 		p.initializer.MetricsProvider,
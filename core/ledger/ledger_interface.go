@@ -52,6 +52,15 @@ type Config struct {
 	HistoryDBConfig *HistoryDBConfig
 	// SnapshotsConfig holds the configuration parameters for the snapshots.
 	SnapshotsConfig *SnapshotsConfig
+	// BlockStoreConfig holds the configuration parameters for the block store.
+	BlockStoreConfig *BlockStoreConfig
+}
+
+// BlockStoreConfig is a structure used to configure the ledger block store.
+type BlockStoreConfig struct {
+	// MaxBlockfileSize is the size, in bytes, at which the block store rolls
+	// over to a new block file. When zero, the default size is used.
+	MaxBlockfileSize int
 }
 
 // StateDBConfig is a structure used to configure the state parameters for the ledger.
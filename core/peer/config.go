@@ -151,6 +151,9 @@ type Config struct {
 	// VMDockerTLSEnabled enables/disables TLS for dockers.
 	VMDockerTLSEnabled   bool
 	VMDockerAttachStdout bool
+	// VMDockerStopTimeout is how long a chaincode container is given to exit
+	// after receiving SIGTERM before it is killed.
+	VMDockerStopTimeout time.Duration
 	// VMNetworkMode sets the networking mode for the container.
 	VMNetworkMode string
 
@@ -268,6 +271,7 @@ func (c *Config) load() error {
 	c.VMEndpoint = viper.GetString("vm.endpoint")
 	c.VMDockerTLSEnabled = viper.GetBool("vm.docker.tls.enabled")
 	c.VMDockerAttachStdout = viper.GetBool("vm.docker.attachStdout")
+	c.VMDockerStopTimeout = viper.GetDuration("vm.docker.stopTimeout")
 
 	c.VMNetworkMode = viper.GetString("vm.docker.hostConfig.NetworkMode")
 	if c.VMNetworkMode == "" {
@@ -407,6 +411,8 @@ func GetServerConfig() (comm.ServerConfig, error) {
 	if viper.IsSet("peer.keepalive.minInterval") {
 		serverConfig.KaOpts.ServerMinInterval = viper.GetDuration("peer.keepalive.minInterval")
 	}
+	serverConfig.MaxRecvMsgSize = viper.GetInt("peer.maxRecvMsgSize")
+	serverConfig.MaxSendMsgSize = viper.GetInt("peer.maxSendMsgSize")
 	return serverConfig, nil
 }
 
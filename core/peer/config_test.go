@@ -113,6 +113,15 @@ func TestGetServerConfig(t *testing.T) {
 	sc, _ = GetServerConfig()
 	require.Equal(t, time.Duration(2)*time.Minute, sc.KaOpts.ServerMinInterval, "ServerConfig.KaOpts.ServerMinInterval should be set to 2 min")
 
+	// message size limits
+	require.Equal(t, 0, sc.MaxRecvMsgSize, "ServerConfig.MaxRecvMsgSize should default to 0")
+	require.Equal(t, 0, sc.MaxSendMsgSize, "ServerConfig.MaxSendMsgSize should default to 0")
+	viper.Set("peer.maxRecvMsgSize", 1024)
+	viper.Set("peer.maxSendMsgSize", 2048)
+	sc, _ = GetServerConfig()
+	require.Equal(t, 1024, sc.MaxRecvMsgSize, "ServerConfig.MaxRecvMsgSize should be set to 1024")
+	require.Equal(t, 2048, sc.MaxSendMsgSize, "ServerConfig.MaxSendMsgSize should be set to 2048")
+
 	// good config with TLS
 	viper.Set("peer.tls.enabled", true)
 	viper.Set("peer.tls.cert.file", filepath.Join("testdata", "Org1-server1-cert.pem"))
@@ -241,6 +250,7 @@ func TestGlobalConfig(t *testing.T) {
 	viper.Set("vm.endpoint", "unix:///var/run/docker.sock")
 	viper.Set("vm.docker.tls.enabled", false)
 	viper.Set("vm.docker.attachStdout", false)
+	viper.Set("vm.docker.stopTimeout", "5s")
 	viper.Set("vm.docker.hostConfig.NetworkMode", "TestingHost")
 	viper.Set("vm.docker.tls.cert.file", "test/vm/tls/cert/file")
 	viper.Set("vm.docker.tls.key.file", "test/vm/tls/key/file")
@@ -299,6 +309,7 @@ func TestGlobalConfig(t *testing.T) {
 		VMEndpoint:           "unix:///var/run/docker.sock",
 		VMDockerTLSEnabled:   false,
 		VMDockerAttachStdout: false,
+		VMDockerStopTimeout:  5 * time.Second,
 		VMNetworkMode:        "TestingHost",
 
 		ChaincodePull: false,
@@ -46,6 +46,7 @@ func main() {
 		Address: config.ListenAddress,
 		CC:      new(simple.SimpleChaincode),
 		TLSProps: shim.TLSProperties{
+			Disabled:      config.Key == "",
 			Key:           []byte(config.Key),
 			Cert:          []byte(config.Cert),
 			ClientCACerts: []byte(config.CA),
@@ -7,10 +7,17 @@ SPDX-License-Identifier: Apache-2.0
 package simple
 
 import (
+	"crypto/x509"
+	"encoding/json"
+	"encoding/pem"
 	"fmt"
 	"strconv"
+	"strings"
+	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric-protos-go/msp"
 	pb "github.com/hyperledger/fabric-protos-go/peer"
 )
 
@@ -76,8 +83,17 @@ func (t *SimpleChaincode) Invoke(stub shim.ChaincodeStubInterface) pb.Response {
 	case "mspid":
 		// Checks the shim's GetMSPID() API
 		return t.mspid(args)
+	case "creator":
+		// Describes the identity that created the transaction
+		return t.creator(stub, args)
+	case "sleep":
+		// Writes a key after sleeping
+		return t.sleep(stub, args)
+	case "panic":
+		// Panics with the arguments as the message
+		panic(fmt.Sprintf("chaincode panicked: %s", strings.Join(args, " ")))
 	default:
-		return shim.Error(`Invalid invoke function name. Expecting "invoke", "delete", "query", "respond", or "mspid"`)
+		return shim.Error(`Invalid invoke function name. Expecting "invoke", "delete", "query", "respond", "mspid", "creator", "sleep", or "panic"`)
 	}
 }
 
@@ -225,3 +241,64 @@ func (t *SimpleChaincode) mspid(args []string) pb.Response {
 	fmt.Printf("MSPID:%s\n", mspid)
 	return shim.Success([]byte(mspid))
 }
+
+// sleep waits for the duration before writing the value to the key
+func (t *SimpleChaincode) sleep(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 3 {
+		return shim.Error("expected three arguments")
+	}
+
+	duration, err := time.ParseDuration(args[0])
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	time.Sleep(duration)
+
+	err = stub.PutState(args[1], []byte(args[2]))
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(nil)
+}
+
+// Creator describes the identity that created a transaction.
+type Creator struct {
+	MSPID              string   `json:"mspid"`
+	CommonName         string   `json:"common_name"`
+	OrganizationalUnit []string `json:"organizational_unit"`
+}
+
+// creator returns the MSP ID, common name, and organizational units of the
+// transaction creator as JSON
+func (t *SimpleChaincode) creator(stub shim.ChaincodeStubInterface, args []string) pb.Response {
+	if len(args) != 0 {
+		return shim.Error("expected no arguments")
+	}
+
+	serializedID, err := stub.GetCreator()
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed to get creator: %s", err))
+	}
+	sid := &msp.SerializedIdentity{}
+	if err := proto.Unmarshal(serializedID, sid); err != nil {
+		return shim.Error(fmt.Sprintf("failed to unmarshal creator: %s", err))
+	}
+	block, _ := pem.Decode(sid.IdBytes)
+	if block == nil {
+		return shim.Error("creator certificate is not PEM encoded")
+	}
+	cert, err := x509.ParseCertificate(block.Bytes)
+	if err != nil {
+		return shim.Error(fmt.Sprintf("failed to parse creator certificate: %s", err))
+	}
+
+	creator, err := json.Marshal(Creator{
+		MSPID:              sid.Mspid,
+		CommonName:         cert.Subject.CommonName,
+		OrganizationalUnit: cert.Subject.OrganizationalUnit,
+	})
+	if err != nil {
+		return shim.Error(err.Error())
+	}
+	return shim.Success(creator)
+}
@@ -0,0 +1,29 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package main
+
+import (
+	"fmt"
+	"os"
+	"time"
+
+	"github.com/hyperledger/fabric-chaincode-go/shim"
+	"github.com/hyperledger/fabric/integration/chaincode/simple"
+)
+
+// startDelay is how long the chaincode waits before registering with the
+// peer. It exceeds the startup timeout the tests configure on the peer.
+const startDelay = time.Minute
+
+func main() {
+	time.Sleep(startDelay)
+
+	if err := shim.Start(&simple.SimpleChaincode{}); err != nil {
+		fmt.Fprintf(os.Stderr, "Exiting slow starting chaincode: %s", err)
+		os.Exit(2)
+	}
+}
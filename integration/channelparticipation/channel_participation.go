@@ -25,8 +25,7 @@ import (
 func Join(n *nwo.Network, o *nwo.Orderer, channel string, block *common.Block, expectedChannelInfo ChannelInfo) {
 	blockBytes, err := proto.Marshal(block)
 	Expect(err).NotTo(HaveOccurred())
-	url := fmt.Sprintf("https://127.0.0.1:%d/participation/v1/channels", n.OrdererPort(o, nwo.OperationsPort))
-	req := generateJoinRequest(url, channel, blockBytes)
+	req := generateJoinRequest(participationURL(n, o, "/participation/v1/channels"), channel, blockBytes)
 	authClient, _ := nwo.OrdererOperationalClients(n, o)
 
 	body := doBody(authClient, req)
@@ -36,6 +35,34 @@ func Join(n *nwo.Network, o *nwo.Orderer, channel string, block *common.Block, e
 	Expect(*c).To(Equal(expectedChannelInfo))
 }
 
+// JoinFailure attempts to join the orderer to the channel with the config
+// block and expects the request to be rejected with the status code and
+// error message.
+func JoinFailure(n *nwo.Network, o *nwo.Orderer, channel string, block *common.Block, expectedStatus int, expectedError string) {
+	blockBytes, err := proto.Marshal(block)
+	Expect(err).NotTo(HaveOccurred())
+	req := generateJoinRequest(participationURL(n, o, "/participation/v1/channels"), channel, blockBytes)
+	authClient, _ := nwo.OrdererOperationalClients(n, o)
+
+	doBodyFailure(authClient, req, expectedStatus, expectedError)
+}
+
+// RemoveFailure attempts to remove the channel from the orderer and expects
+// the request to be rejected with the status code and error message.
+func RemoveFailure(n *nwo.Network, o *nwo.Orderer, channel string, expectedStatus int, expectedError string) {
+	req, err := http.NewRequest(http.MethodDelete, participationURL(n, o, "/participation/v1/channels/"+channel), nil)
+	Expect(err).NotTo(HaveOccurred())
+	authClient, _ := nwo.OrdererOperationalClients(n, o)
+
+	doBodyFailure(authClient, req, expectedStatus, expectedError)
+}
+
+// participationURL returns the URL of path on the channel participation API,
+// which is served on the orderer's operations listener.
+func participationURL(n *nwo.Network, o *nwo.Orderer, path string) string {
+	return fmt.Sprintf("https://%s%s", n.OrdererOperationsAddress(o), path)
+}
+
 func generateJoinRequest(url, channel string, blockBytes []byte) *http.Request {
 	joinBody := new(bytes.Buffer)
 	writer := multipart.NewWriter(joinBody)
@@ -63,6 +90,24 @@ func doBody(client *http.Client, req *http.Request) []byte {
 	return bodyBytes
 }
 
+type errorResponse struct {
+	Error string `json:"error"`
+}
+
+func doBodyFailure(client *http.Client, req *http.Request, expectedStatus int, expectedError string) {
+	resp, err := client.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(resp.StatusCode).To(Equal(expectedStatus))
+	bodyBytes, err := ioutil.ReadAll(resp.Body)
+	Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+
+	errResp := &errorResponse{}
+	err = json.Unmarshal(bodyBytes, errResp)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(errResp.Error).To(Equal(expectedError))
+}
+
 type channelList struct {
 	SystemChannel *channelInfoShort  `json:"systemChannel"`
 	Channels      []channelInfoShort `json:"channels"`
@@ -75,7 +120,7 @@ type channelInfoShort struct {
 
 func List(n *nwo.Network, o *nwo.Orderer, expectedChannels []string, systemChannel ...string) {
 	authClient, unauthClient := nwo.OrdererOperationalClients(n, o)
-	listChannelsURL := fmt.Sprintf("https://127.0.0.1:%d/participation/v1/channels", n.OrdererPort(o, nwo.OperationsPort))
+	listChannelsURL := participationURL(n, o, "/participation/v1/channels")
 
 	body := getBody(authClient, listChannelsURL)()
 	list := &channelList{}
@@ -139,7 +184,7 @@ type ChannelInfo struct {
 
 func ListOne(n *nwo.Network, o *nwo.Orderer, expectedChannelInfo ChannelInfo) {
 	authClient, _ := nwo.OrdererOperationalClients(n, o)
-	listChannelURL := fmt.Sprintf("https://127.0.0.1:%d/%s", n.OrdererPort(o, nwo.OperationsPort), expectedChannelInfo.URL)
+	listChannelURL := participationURL(n, o, expectedChannelInfo.URL)
 
 	body := getBody(authClient, listChannelURL)()
 	c := &ChannelInfo{}
@@ -208,7 +208,7 @@ var _ = Describe("DiscoveryService", func() {
 		))
 
 		By("discovering endorsers for chaincode that has been installed to org1 and org2")
-		de := discoverEndorsers(network, endorsers)
+		de := nwo.DiscoverEndorsers(network, org1Peer0, "User1", "testchannel", "mycc")
 		Eventually(endorsersByGroups(de), network.EventuallyTimeout).Should(ConsistOf(
 			[]nwo.DiscoveredPeer{network.DiscoveredPeer(org1Peer0)},
 			[]nwo.DiscoveredPeer{network.DiscoveredPeer(org2Peer0)},
@@ -569,6 +569,14 @@ var _ = Describe("DiscoveryService", func() {
 		Expect(discovered[0].Layouts).To(HaveLen(1))
 		Expect(discovered[0].Layouts[0].QuantitiesByGroup).To(ConsistOf(uint32(1), uint32(1)))
 
+		By("resolving the endorsement layouts of each collection to organizations")
+		Expect(nwo.CollectionEndorsementLayouts(network, org1Peer0, "User1", "testchannel", "mycc", "collectionDetails")()).To(ConsistOf(
+			[]string{"Org1MSP", "Org2MSP"},
+		))
+		Expect(nwo.CollectionEndorsementLayouts(network, org1Peer0, "User1", "testchannel", "mycc", "collectionMarbles")()).To(ConsistOf(
+			[]string{"Org1MSP", "Org2MSP", "Org3MSP"},
+		))
+
 		By("trying to discover endorsers as an org3 admin")
 		endorsers = commands.Endorsers{
 			UserCert:  network.PeerUserCert(org3Peer0, "Admin"),
@@ -601,14 +609,8 @@ var _ = Describe("DiscoveryService", func() {
 	})
 })
 
-type ChaincodeEndorsers struct {
-	Chaincode         string
-	EndorsersByGroups map[string][]nwo.DiscoveredPeer
-	Layouts           []*discovery.Layout
-}
-
-func discoverEndorsers(n *nwo.Network, command commands.Endorsers) func() []ChaincodeEndorsers {
-	return func() []ChaincodeEndorsers {
+func discoverEndorsers(n *nwo.Network, command commands.Endorsers) func() []nwo.ChaincodeEndorsers {
+	return func() []nwo.ChaincodeEndorsers {
 		sess, err := n.Discover(command)
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit())
@@ -616,14 +618,14 @@ func discoverEndorsers(n *nwo.Network, command commands.Endorsers) func() []Chai
 			return nil
 		}
 
-		discovered := []ChaincodeEndorsers{}
+		discovered := []nwo.ChaincodeEndorsers{}
 		err = json.Unmarshal(sess.Out.Contents(), &discovered)
 		Expect(err).NotTo(HaveOccurred())
 		return discovered
 	}
 }
 
-func endorsersByGroups(discover func() []ChaincodeEndorsers) func() map[string][]nwo.DiscoveredPeer {
+func endorsersByGroups(discover func() []nwo.ChaincodeEndorsers) func() map[string][]nwo.DiscoveredPeer {
 	return func() map[string][]nwo.DiscoveredPeer {
 		discovered := discover()
 		if len(discovered) == 1 {
@@ -16,6 +16,7 @@ import (
 	"syscall"
 	"time"
 
+	docker "github.com/fsouza/go-dockerclient"
 	"github.com/hyperledger/fabric/common/crypto/tlsgen"
 	"github.com/hyperledger/fabric/core/container/externalbuilder"
 	"github.com/hyperledger/fabric/integration/nwo"
@@ -143,6 +144,47 @@ var _ = Describe("ChaincodeAsExternalServer", func() {
 		nwo.InitChaincode(network, "testchannel", orderer, chaincode, network.PeersWithChannel("testchannel")...)
 		RunQueryInvokeQuery(network, orderer, peer, "testchannel")
 		RunRespondWith(network, orderer, peer, "testchannel")
+
+		By("ensuring no chaincode container was created")
+		client, err := docker.NewClientFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		nwo.AssertNoChaincodeContainer(client, network, chaincode)
+	})
+
+	It("deploys a chaincode as a service using the ccaas builder", func() {
+		orderer := network.Orderer("orderer")
+		peer := network.Peer("Org1", "peer0")
+
+		ccaas := nwo.Chaincode{
+			Name:            "mycc",
+			Version:         "0.0",
+			Path:            chaincode.Path,
+			Lang:            "ccaas",
+			PackageFile:     filepath.Join(testDir, "ccaas.tar.gz"),
+			Ctor:            `{"Args":["init","a","100","b","200"]}`,
+			InitRequired:    true,
+			SignaturePolicy: `AND ('Org1MSP.member','Org2MSP.member')`,
+			Sequence:        "1",
+			Label:           "my_ccaas_chaincode",
+			ServerAddress:   fmt.Sprintf("127.0.0.1:%d", network.ReservePort()),
+		}
+
+		By("packaging the chaincode with a connection to the server")
+		nwo.PackageChaincodeServer(ccaas)
+		ccaas.SetPackageIDFromPackageFile()
+
+		By("starting the chaincode server")
+		ccserver = ifrit.Invoke(network.ChaincodeServerRunner(ccaas))
+		Eventually(ccserver.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+		By("deploying and exercising the chaincode")
+		nwo.DeployChaincode(network, "testchannel", orderer, ccaas)
+		RunQueryInvokeQuery(network, orderer, peer, "testchannel")
+
+		By("ensuring no chaincode container was created")
+		client, err := docker.NewClientFromEnv()
+		Expect(err).NotTo(HaveOccurred())
+		nwo.AssertNoChaincodeContainer(client, network, ccaas)
 	})
 })
 
@@ -67,13 +67,11 @@ var _ = Describe("SignalHandling", func() {
 	It("handles signals", func() {
 		By("verifying SIGUSR1 to the peer dumps go routines")
 		peerProcess.Signal(syscall.SIGUSR1)
-		Eventually(peerRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say("Received signal: "))
-		Eventually(peerRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say(`Go routines report`))
+		nwo.LogMatcher(peerRunner).Says(network.EventuallyTimeout, "Received signal: ", `Go routines report`)
 
 		By("verifying SIGUSR1 to the orderer dumps go routines")
 		ordererProcess.Signal(syscall.SIGUSR1)
-		Eventually(ordererRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say("Received signal: "))
-		Eventually(ordererRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say(`Go routines report`))
+		nwo.LogMatcher(ordererRunner).Says(network.EventuallyTimeout, "Received signal: ", `Go routines report`)
 
 		By("verifying SIGUSR1 does not terminate processes")
 		Consistently(peerProcess.Wait()).ShouldNot(Receive())
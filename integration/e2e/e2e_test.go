@@ -7,17 +7,13 @@ SPDX-License-Identifier: Apache-2.0
 package e2e
 
 import (
-	"bufio"
-	"bytes"
 	"context"
-	"crypto/sha256"
 	"encoding/json"
 	"fmt"
-	"io"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"os"
-	"path"
 	"path/filepath"
 	"strings"
 	"syscall"
@@ -109,10 +105,7 @@ var _ = Describe("EndToEnd", func() {
 
 			runArtifactsFilePath = filepath.Join(testDir, "run-artifacts.txt")
 			os.Setenv("RUN_ARTIFACTS_FILE", runArtifactsFilePath)
-			for i, e := range network.ExternalBuilders {
-				e.PropagateEnvironment = append(e.PropagateEnvironment, "RUN_ARTIFACTS_FILE")
-				network.ExternalBuilders[i] = e
-			}
+			network.PropagateEnvToExternalBuilders("RUN_ARTIFACTS_FILE")
 
 			network.GenerateConfigTree()
 			for _, peer := range network.PeersWithChannel("testchannel") {
@@ -144,12 +137,7 @@ var _ = Describe("EndToEnd", func() {
 			// Ensure that the temporary directories generated by launched external
 			// chaincodes have been cleaned up. This must be done after the peers
 			// have been terminated.
-			contents, err := ioutil.ReadFile(runArtifactsFilePath)
-			Expect(err).NotTo(HaveOccurred())
-			scanner := bufio.NewScanner(bytes.NewBuffer(contents))
-			for scanner.Scan() {
-				Expect(scanner.Text()).NotTo(BeAnExistingFile())
-			}
+			nwo.AssertExternalBuildArtifactsCleaned(runArtifactsFilePath)
 		})
 
 		It("executes a basic solo network with 2 orgs and no docker", func() {
@@ -182,13 +170,11 @@ var _ = Describe("EndToEnd", func() {
 			By("deploying the chaincode")
 			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
 
+			By("ensuring no chaincode container was created")
+			nwo.AssertNoChaincodeContainer(client, network, chaincode)
+
 			By("ensuring external cc run artifacts exist after deploying")
-			contents, err := ioutil.ReadFile(runArtifactsFilePath)
-			Expect(err).NotTo(HaveOccurred())
-			scanner := bufio.NewScanner(bytes.NewBuffer(contents))
-			for scanner.Scan() {
-				Expect(scanner.Text()).To(BeADirectory())
-			}
+			nwo.AssertExternalBuildArtifactsExist(runArtifactsFilePath)
 
 			By("getting the client peer by name")
 			peer := network.Peer("Org1", "peer0")
@@ -211,6 +197,215 @@ var _ = Describe("EndToEnd", func() {
 		})
 	})
 
+	Describe("solo network with plaintext operations endpoints", func() {
+		BeforeEach(func() {
+			network = nwo.New(nwo.BasicSolo(), testDir, nil, StartPort(), components)
+			network.MetricsProvider = "prometheus"
+			network.SetOrdererOperationsTLS(network.Orderer("orderer"), false)
+			network.SetPeerOperationsTLS(network.Peer("Org1", "peer0"), false)
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			networkRunner := network.NetworkGroupRunner()
+			process = ifrit.Invoke(networkRunner)
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("serves the operations endpoints without TLS", func() {
+			orderer := network.Orderer("orderer")
+			peer := network.Peer("Org1", "peer0")
+			plaintextClient := &http.Client{}
+			network.CreateAndJoinChannel(orderer, "testchannel")
+
+			By("scraping metrics over plaintext")
+			Expect(network.PeerOperationsURL(peer, "metrics")).To(HavePrefix("http://"))
+			CheckPeerPrometheusMetrics(plaintextClient, network.PeerOperationsURL(peer, "metrics"))
+			Expect(network.OrdererOperationsURL(orderer, "metrics")).To(HavePrefix("http://"))
+			CheckOrdererPrometheusMetrics(plaintextClient, network.OrdererOperationsURL(orderer, "metrics"))
+
+			By("accessing the logspec without a client cert")
+			CheckLogspecOperations(plaintextClient, network.PeerOperationsURL(peer, "logspec"))
+			CheckLogspecOperations(plaintextClient, network.OrdererOperationsURL(orderer, "logspec"))
+
+			By("leaving TLS enabled on other components")
+			otherPeer := network.Peer("Org2", "peer0")
+			Expect(network.PeerOperationsURL(otherPeer, "healthz")).To(HavePrefix("https://"))
+			authClient, _ := nwo.PeerOperationalClients(network, otherPeer)
+			CheckHealthEndpoint(authClient, network.PeerOperationsURL(otherPeer, "healthz"))
+			_, err := plaintextClient.Get(network.PeerOperationsURL(otherPeer, "healthz"))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("solo network with an operations endpoint on a non-loopback address", func() {
+		var hostIP string
+
+		BeforeEach(func() {
+			hostIP = nonLoopbackIPv4()
+			if hostIP == "" {
+				Skip("no non-loopback IPv4 address is available")
+			}
+
+			network = nwo.New(nwo.BasicSolo(), testDir, nil, StartPort(), components)
+			network.MetricsProvider = "prometheus"
+			peer := network.Peer("Org1", "peer0")
+			network.SetPeerOperationsTLS(peer, false)
+			network.SetPeerOperationsListenAddress(peer, hostIP)
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			networkRunner := network.NetworkGroupRunner()
+			process = ifrit.Invoke(networkRunner)
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("serves the operations endpoints only on the configured address", func() {
+			peer := network.Peer("Org1", "peer0")
+			plaintextClient := &http.Client{}
+
+			By("reaching the operations endpoints on the configured address")
+			Expect(network.PeerOperationsURL(peer, "healthz")).To(HavePrefix("http://" + hostIP + ":"))
+			CheckHealthEndpoint(plaintextClient, network.PeerOperationsURL(peer, "healthz"))
+			CheckPeerPrometheusMetrics(plaintextClient, network.PeerOperationsURL(peer, "metrics"))
+
+			By("failing to reach the operations endpoints on the loopback address")
+			_, err := plaintextClient.Get(fmt.Sprintf("http://127.0.0.1:%d/healthz", network.PeerPort(peer, nwo.OperationsPort)))
+			Expect(err).To(HaveOccurred())
+		})
+	})
+
+	Describe("solo network with peers using different garbage collection targets", func() {
+		BeforeEach(func() {
+			network = nwo.New(nwo.BasicSolo(), testDir, nil, StartPort(), components)
+			network.MetricsProvider = "prometheus"
+			network.SetPeerGOGC(network.Peer("Org1", "peer0"), "off")
+			network.SetPeerGOGC(network.Peer("Org2", "peer0"), "25")
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			networkRunner := network.NetworkGroupRunner()
+			process = ifrit.Invoke(networkRunner)
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("reports garbage collections according to the GOGC of each peer", func() {
+			orderer := network.Orderer("orderer")
+			network.CreateAndJoinChannel(orderer, "testchannel")
+
+			By("verifying the peer with collection disabled has not collected")
+			gcOff := network.Peer("Org1", "peer0")
+			Expect(nwo.PeerMetric(network, gcOff, "go_gc_duration_seconds_count")()).To(Equal(float64(0)))
+			Expect(nwo.PeerMetric(network, gcOff, "go_memstats_heap_alloc_bytes")()).To(BeNumerically(">", 0))
+
+			By("verifying the peer with an aggressive target has collected")
+			gcAggressive := network.Peer("Org2", "peer0")
+			Eventually(nwo.PeerMetric(network, gcAggressive, "go_gc_duration_seconds_count"), network.EventuallyTimeout).Should(BeNumerically(">", 0))
+		})
+	})
+
+	Describe("solo network with an organization outside the consortium", func() {
+		BeforeEach(func() {
+			config := nwo.MultiChannelBasicSolo()
+			config.Consortiums[0].Organizations = []string{"Org1"}
+			network = nwo.New(config, testDir, nil, StartPort(), components)
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			networkRunner := network.NetworkGroupRunner()
+			process = ifrit.Invoke(networkRunner)
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("creates channels with the organization once it joins the consortium", func() {
+			orderer := network.Orderer("orderer")
+			peer := network.Peer("Org1", "peer0")
+
+			By("failing to create a channel that includes an organization outside the consortium")
+			exitCode := network.CreateChannelExitCode("testchannel", orderer, peer)
+			Expect(exitCode).NotTo(Equal(0))
+
+			By("adding the organization to the consortium")
+			nwo.AddConsortiumOrg(network, orderer, "SampleConsortium", "Org2")
+			Expect(network.Consortiums[0].Organizations).To(ConsistOf("Org1", "Org2"))
+
+			By("limiting the ordering service to a single channel")
+			nwo.SetMaxChannels(network, orderer, 1)
+
+			By("creating and joining the channel")
+			network.CreateAndJoinChannel(orderer, "testchannel")
+
+			By("failing to create a channel beyond the limit")
+			exitCode = network.CreateChannelExitCode("testchannel2", orderer, peer)
+			Expect(exitCode).NotTo(Equal(0))
+
+			By("removing the channel limit")
+			nwo.SetMaxChannels(network, orderer, 0)
+			network.CreateChannel("testchannel2", orderer, peer)
+		})
+	})
+
+	Describe("solo network bootstrapped from a custom genesis block", func() {
+		BeforeEach(func() {
+			network = nwo.New(nwo.BasicSolo(), testDir, nil, StartPort(), components)
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			By("generating a genesis block for a differently named system channel")
+			sess, err := network.ConfigTxGen(commands.OutputBlock{
+				ChannelID:   "customsystemchannel",
+				Profile:     network.SystemChannel.Profile,
+				ConfigPath:  network.RootDir,
+				OutputBlock: filepath.Join(testDir, "custom_genesis.pb"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			network.SetOrdererBootstrapBlock(network.Orderer("orderer"), filepath.Join(testDir, "custom_genesis.pb"))
+
+			networkRunner := network.NetworkGroupRunner()
+			process = ifrit.Invoke(networkRunner)
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("serves the bootstrapped channel and refuses a block that is not for a system channel", func() {
+			orderer := network.Orderer("orderer")
+			peer := network.Peer("Org1", "peer0")
+
+			By("fetching the genesis block of the bootstrapped channel")
+			sess, err := network.OrdererAdminSession(orderer, peer, commands.ChannelFetch{
+				ChannelID:  "customsystemchannel",
+				Block:      "oldest",
+				Orderer:    network.OrdererAddress(orderer, nwo.ListenPort),
+				OutputFile: filepath.Join(testDir, "customsystemchannel_oldest.pb"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say(`Received block: 0`))
+
+			By("creating an application channel")
+			network.CreateAndJoinChannel(orderer, "testchannel")
+
+			By("fetching the genesis block of the application channel")
+			sess, err = network.PeerAdminSession(peer, commands.ChannelFetch{
+				ChannelID:  "testchannel",
+				Block:      "oldest",
+				Orderer:    network.OrdererAddress(orderer, nwo.ListenPort),
+				OutputFile: filepath.Join(testDir, "testchannel_oldest.pb"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("restarting the orderer with the application channel block")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetOrdererBootstrapBlock(orderer, filepath.Join(testDir, "testchannel_oldest.pb"))
+
+			ordererRunner := network.OrdererRunner(orderer)
+			process = ifrit.Invoke(ordererRunner)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive(HaveOccurred()))
+			Expect(ordererRunner.Err()).To(gbytes.Say("Failed validating bootstrap block: the block isn't a system channel block because it lacks ConsortiumsConfig"))
+		})
+	})
+
 	Describe("basic kafka network with 2 orgs", func() {
 		BeforeEach(func() {
 			network = nwo.New(nwo.BasicKafka(), testDir, client, StartPort(), components)
@@ -276,8 +471,8 @@ var _ = Describe("EndToEnd", func() {
 			By("listing the containers after committing the chaincode definition")
 			initialContainerFilter := map[string][]string{
 				"name": {
-					chaincodeContainerNameFilter(network, chaincode),
-					chaincodeContainerNameFilter(network, gopathChaincode),
+					nwo.ChaincodeContainerNameFilter(network, chaincode),
+					nwo.ChaincodeContainerNameFilter(network, gopathChaincode),
 				},
 			}
 
@@ -285,8 +480,15 @@ var _ = Describe("EndToEnd", func() {
 			Expect(err).NotTo(HaveOccurred())
 			Expect(containers).To(HaveLen(2))
 
+			shimRequests := nwo.ChaincodeMetric(network, network.Peer("Org1", "peer0"), chaincode, "chaincode_shim_requests_received")
+			initialShimRequests := shimRequests()
+			Expect(initialShimRequests).To(BeNumerically(">", 0))
+
 			RunQueryInvokeQuery(network, orderer, network.Peer("Org1", "peer0"), "testchannel")
 
+			By("checking the peer reports the shim requests of the chaincode")
+			Eventually(shimRequests, network.EventuallyTimeout).Should(BeNumerically(">", initialShimRequests))
+
 			CheckPeerOperationEndpoints(network, network.Peer("Org2", "peer0"))
 			CheckOrdererOperationEndpoints(network, orderer)
 
@@ -315,8 +517,8 @@ var _ = Describe("EndToEnd", func() {
 			Expect(containers).To(HaveLen(0))
 			updatedContainerFilter := map[string][]string{
 				"name": {
-					chaincodeContainerNameFilter(network, chaincode),
-					chaincodeContainerNameFilter(network, gopathChaincode),
+					nwo.ChaincodeContainerNameFilter(network, chaincode),
+					nwo.ChaincodeContainerNameFilter(network, gopathChaincode),
 				},
 			}
 			containers, err = client.ListContainers(docker.ListContainersOptions{Filters: updatedContainerFilter})
@@ -325,6 +527,13 @@ var _ = Describe("EndToEnd", func() {
 
 			RunQueryInvokeQuery(network, orderer, network.Peer("Org1", "peer0"), "testchannel")
 
+			By("re-approving the committed definition without committing it again")
+			packageInstallApproveChaincode(network, "testchannel", orderer, chaincode, network.Peer("Org1", "peer0"))
+			Expect(nwo.IsCommitRequired(network, "testchannel", chaincode, testPeers[0])).To(BeFalse())
+			height := nwo.GetLedgerHeight(network, testPeers[0], "testchannel")
+			nwo.CommitChaincodeIfRequired(network, "testchannel", orderer, chaincode, testPeers[0], testPeers...)
+			Expect(nwo.GetLedgerHeight(network, testPeers[0], "testchannel")).To(Equal(height))
+
 			By("retrieving the local mspid of the peer via simple chaincode")
 			sess, err := network.PeerUserSession(network.Peer("Org2", "peer0"), "User1", commands.ChaincodeQuery{
 				ChannelID: "testchannel",
@@ -349,11 +558,8 @@ var _ = Describe("EndToEnd", func() {
 			network.Consensus.ChannelParticipationEnabled = true
 			network.GenerateConfigTree()
 			for _, peer := range network.Peers {
-				core := network.ReadPeerConfig(peer)
-				core.Peer.Gossip.UseLeaderElection = false
-				core.Peer.Gossip.OrgLeader = true
-				core.Peer.Deliveryclient.ReconnectTotalTimeThreshold = time.Duration(time.Second)
-				network.WritePeerConfig(peer, core)
+				network.SetDeliveryMode(peer, false, true)
+				network.SetDeliveryClientTimeouts(peer, nwo.DeliveryClientTimeouts{ReconnectTotalTimeThreshold: time.Second})
 			}
 			network.Bootstrap()
 
@@ -393,22 +599,34 @@ var _ = Describe("EndToEnd", func() {
 			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
 			RunQueryInvokeQuery(network, orderer, peer, "testchannel")
 
-			By("Create second channel and deploy chaincode")
+			By("Create second channel and deploy chaincode with a policy requiring either org")
 			network.CreateAndJoinChannel(orderer, "testchannel2")
 			channelparticipation.List(network, orderer, []string{"testchannel", "testchannel2"}, "systemchannel")
-			peers := network.PeersWithChannel("testchannel2")
 			nwo.EnableCapabilities(network, "testchannel2", "Application", "V2_0", orderer, network.Peer("Org1", "peer0"), network.Peer("Org2", "peer0"))
-			nwo.ApproveChaincodeForMyOrg(network, "testchannel2", orderer, chaincode, peers...)
-			nwo.CheckCommitReadinessUntilReady(network, "testchannel2", chaincode, network.PeerOrgs(), peers...)
-			nwo.CommitChaincode(network, "testchannel2", orderer, chaincode, peers[0], peers...)
-			nwo.InitChaincode(network, "testchannel2", orderer, chaincode, peers...)
+			nwo.DeployChaincodeOnChannel(network, "testchannel2", orderer, chaincode, `OR ('Org1MSP.member','Org2MSP.member')`)
 			RunQueryInvokeQuery(network, orderer, peer, "testchannel2")
 
+			By("Invoking with an endorsement from Org1 only on each channel")
+			invokeOrg1Only := func(channel string) *gexec.Session {
+				sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+					ChannelID:    channel,
+					Orderer:      network.OrdererAddress(orderer, nwo.ListenPort),
+					Name:         "mycc",
+					Ctor:         `{"Args":["invoke","a","b","10"]}`,
+					WaitForEvent: true,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				return sess
+			}
+			sess := invokeOrg1Only("testchannel2")
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say(`\Qcommitted with status (VALID)\E`))
+			sess = invokeOrg1Only("testchannel")
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`\QError: transaction invalidated with status (ENDORSEMENT_POLICY_FAILURE)\E`))
+
 			By("Update consensus metadata to increase snapshot interval")
-			snapDir := path.Join(network.RootDir, "orderers", orderer.ID(), "etcdraft", "snapshot", "testchannel")
-			files, err := ioutil.ReadDir(snapDir)
-			Expect(err).NotTo(HaveOccurred())
-			numOfSnaps := len(files)
+			numOfSnaps := nwo.OrdererRaftState(network, orderer, "testchannel").SnapshotCount
 
 			nwo.UpdateConsensusMetadata(network, peer, orderer, "testchannel", func(originalMetadata []byte) []byte {
 				metadata := &etcdraft.ConfigMetadata{}
@@ -425,16 +643,18 @@ var _ = Describe("EndToEnd", func() {
 				return newMetadata
 			})
 
+			configJSON := nwo.ChannelConfigJSON(network, peer, orderer, "testchannel")
+			Expect(configJSON).To(MatchRegexp(`"max_inflight_blocks":\s*1000\b`))
+			Expect(configJSON).To(MatchRegexp(`"snapshot_interval_size":\s*10485760\b`))
+
 			// assert that no new snapshot is taken because SnapshotIntervalSize has just enlarged
-			files, err = ioutil.ReadDir(snapDir)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(len(files)).To(Equal(numOfSnaps))
+			Expect(nwo.OrdererRaftState(network, orderer, "testchannel").SnapshotCount).To(Equal(numOfSnaps))
 
 			By("ensuring that static leaders do not give up on retrieving blocks after the orderer goes down")
 			ordererProcess.Signal(syscall.SIGTERM)
 			Eventually(ordererProcess.Wait(), network.EventuallyTimeout).Should(Receive())
 			for _, peerRunner := range peerRunners {
-				Eventually(peerRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say("peer is a static leader, ignoring peer.deliveryclient.reconnectTotalTimeThreshold"))
+				nwo.LogMatcher(peerRunner).Says(network.EventuallyTimeout, "peer is a static leader, ignoring peer.deliveryclient.reconnectTotalTimeThreshold")
 			}
 		})
 	})
@@ -444,11 +664,12 @@ var _ = Describe("EndToEnd", func() {
 			network = nwo.New(nwo.MinimalRaft(), testDir, client, StartPort(), components)
 			network.GenerateConfigTree()
 
-			configtxConfig := network.ReadConfigTxConfig()
-			ordererEndpoints := configtxConfig.Profiles["SampleDevModeEtcdRaft"].Orderer.Organizations[0].OrdererEndpoints
-			correctOrdererEndpoint := ordererEndpoints[0]
-			ordererEndpoints[0] = "127.0.0.1:1"
-			network.WriteConfigTxConfig(configtxConfig)
+			var correctOrdererEndpoint string
+			nwo.MutateProfile(network, "SampleDevModeEtcdRaft", func(profile *fabricconfig.Channel) {
+				ordererEndpoints := profile.Orderer.Organizations[0].OrdererEndpoints
+				correctOrdererEndpoint = ordererEndpoints[0]
+				ordererEndpoints[0] = "127.0.0.1:1"
+			})
 
 			peer := network.Peer("Org1", "peer0")
 			peerConfig := network.ReadPeerConfig(peer)
@@ -522,18 +743,16 @@ var _ = Describe("EndToEnd", func() {
 			By("removing chaincode containers from all peers")
 			listChaincodeContainers := docker.ListContainersOptions{
 				Filters: map[string][]string{
-					"name": {chaincodeContainerNameFilter(network, chaincode)},
+					"name": {nwo.ChaincodeContainerNameFilter(network, chaincode)},
 				},
 			}
 			ctx := context.Background()
-			containers, err := client.ListContainers(listChaincodeContainers)
-			Expect(err).NotTo(HaveOccurred())
-			Expect(containers).NotTo(BeEmpty())
+			containers := nwo.WaitForChaincodeContainer(client, network, chaincode, network.EventuallyTimeout)
 
 			var originalContainerIDs []string
 			for _, container := range containers {
 				originalContainerIDs = append(originalContainerIDs, container.ID)
-				err = client.RemoveContainer(docker.RemoveContainerOptions{
+				err := client.RemoveContainer(docker.RemoveContainerOptions{
 					ID:            container.ID,
 					RemoveVolumes: true,
 					Force:         true,
@@ -567,6 +786,80 @@ var _ = Describe("EndToEnd", func() {
 			for _, container := range newContainers {
 				Expect(originalContainerIDs).NotTo(ContainElement(container.ID))
 			}
+
+			By("killing the chaincode container of a single peer")
+			otherPeer := network.Peer("Org2", "peer0")
+			otherContainers := nwo.ChaincodeContainersForPeer(client, network, otherPeer)
+			Expect(otherContainers).To(HaveLen(1))
+			killed := nwo.KillChaincodeContainersForPeer(client, network, peer)
+			Expect(killed).To(HaveLen(1))
+
+			By("invoking chaincode against the peer whose container was killed")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+				ChannelID: "testchannel",
+				Orderer:   network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:      "mycc",
+				Ctor:      `{"Args":["invoke","a","b","10"]}`,
+				PeerAddresses: []string{
+					network.PeerAddress(peer, nwo.ListenPort),
+				},
+				WaitForEvent: true,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("checking only that peer relaunched its chaincode container")
+			relaunched := nwo.ChaincodeContainersForPeer(client, network, peer)
+			Expect(relaunched).To(HaveLen(1))
+			Expect(killed).NotTo(ContainElement(relaunched[0].ID))
+			Expect(nwo.ChaincodeContainersForPeer(client, network, otherPeer)).To(Equal(otherContainers))
+		})
+
+		It("uninstalls chaincode packages and removes their containers", func() {
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(testDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `OR ('Org1MSP.peer', 'Org2MSP.peer')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+
+			peer := network.Peers[0]
+			orderer := network.Orderer("orderer")
+
+			By("deploying the chaincode")
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("Org1", "peer0"), network.Peer("Org2", "peer0"))
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+			chaincode.SetPackageIDFromPackageFile()
+			RunQueryInvokeQuery(network, orderer, peer, "testchannel")
+			nwo.WaitForChaincodeContainer(client, network, chaincode, network.EventuallyTimeout)
+
+			By("uninstalling the chaincode while the network is stopped")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			nwo.UninstallChaincode(network, chaincode, network.PeersWithChannel("testchannel")...)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("verifying the package and its containers are gone")
+			Expect(nwo.PeersWithPackageInstalled(network, chaincode.PackageID, network.PeersWithChannel("testchannel")...)).To(BeEmpty())
+			nwo.AssertNoChaincodeContainer(client, network, chaincode)
+
+			By("failing to query the uninstalled chaincode")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say("chaincode definition for 'mycc' exists, but chaincode is not installed"))
 		})
 	})
 })
@@ -582,16 +875,19 @@ func RunQueryInvokeQuery(n *nwo.Network, orderer *nwo.Orderer, peer *nwo.Peer, c
 	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
 	Expect(sess).To(gbytes.Say("100"))
 
-	sess, err = n.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
-		ChannelID: channel,
-		Orderer:   n.OrdererAddress(orderer, nwo.ListenPort),
-		Name:      "mycc",
-		Ctor:      `{"Args":["invoke","a","b","10"]}`,
-		PeerAddresses: []string{
-			n.PeerAddress(n.Peer("Org1", "peer0"), nwo.ListenPort),
-			n.PeerAddress(n.Peer("Org2", "peer0"), nwo.ListenPort),
-		},
-		WaitForEvent: true,
+	// retry the invoke if the orderer is unavailable during a leader change
+	sess, err = n.SessionWithRetry(func() (*gexec.Session, error) {
+		return n.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+			ChannelID: channel,
+			Orderer:   n.OrdererAddress(orderer, nwo.ListenPort),
+			Name:      "mycc",
+			Ctor:      `{"Args":["invoke","a","b","10"]}`,
+			PeerAddresses: nwo.PeerAddresses(n, nwo.ListenPort,
+				n.Peer("Org1", "peer0"),
+				n.Peer("Org2", "peer0"),
+			),
+			WaitForEvent: true,
+		})
 	})
 	Expect(err).NotTo(HaveOccurred())
 	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
@@ -684,9 +980,9 @@ func CheckOrdererStatsdMetrics(prefix string, mr *MetricsReader, timeout time.Du
 }
 
 func CheckPeerOperationEndpoints(network *nwo.Network, peer *nwo.Peer) {
-	metricsURL := fmt.Sprintf("https://127.0.0.1:%d/metrics", network.PeerPort(peer, nwo.OperationsPort))
-	logspecURL := fmt.Sprintf("https://127.0.0.1:%d/logspec", network.PeerPort(peer, nwo.OperationsPort))
-	healthURL := fmt.Sprintf("https://127.0.0.1:%d/healthz", network.PeerPort(peer, nwo.OperationsPort))
+	metricsURL := network.PeerOperationsURL(peer, "metrics")
+	logspecURL := network.PeerOperationsURL(peer, "logspec")
+	healthURL := network.PeerOperationsURL(peer, "healthz")
 
 	authClient, unauthClient := nwo.PeerOperationalClients(network, peer)
 
@@ -704,9 +1000,9 @@ func CheckPeerOperationEndpoints(network *nwo.Network, peer *nwo.Peer) {
 }
 
 func CheckOrdererOperationEndpoints(network *nwo.Network, orderer *nwo.Orderer) {
-	metricsURL := fmt.Sprintf("https://127.0.0.1:%d/metrics", network.OrdererPort(orderer, nwo.OperationsPort))
-	logspecURL := fmt.Sprintf("https://127.0.0.1:%d/logspec", network.OrdererPort(orderer, nwo.OperationsPort))
-	healthURL := fmt.Sprintf("https://127.0.0.1:%d/healthz", network.OrdererPort(orderer, nwo.OperationsPort))
+	metricsURL := network.OrdererOperationsURL(orderer, "metrics")
+	logspecURL := network.OrdererOperationsURL(orderer, "logspec")
+	healthURL := network.OrdererOperationsURL(orderer, "healthz")
 
 	authClient, unauthClient := nwo.OrdererOperationalClients(network, orderer)
 
@@ -830,18 +1126,16 @@ func packageInstallApproveChaincode(network *nwo.Network, channel string, ordere
 	nwo.ApproveChaincodeForMyOrg(network, channel, orderer, chaincode, peers...)
 }
 
-func hashFile(file string) string {
-	f, err := os.Open(file)
-	Expect(err).NotTo(HaveOccurred())
-	defer f.Close()
-
-	h := sha256.New()
-	_, err = io.Copy(h, f)
+// nonLoopbackIPv4 returns the first IPv4 address of the host that is not a
+// loopback address or an empty string when there is none.
+func nonLoopbackIPv4() string {
+	addrs, err := net.InterfaceAddrs()
 	Expect(err).NotTo(HaveOccurred())
-
-	return fmt.Sprintf("%x", h.Sum(nil))
-}
-
-func chaincodeContainerNameFilter(n *nwo.Network, chaincode nwo.Chaincode) string {
-	return fmt.Sprintf("^/%s-.*-%s-%s$", n.NetworkID, chaincode.Label, hashFile(chaincode.PackageFile))
+	for _, addr := range addrs {
+		ipNet, ok := addr.(*net.IPNet)
+		if ok && !ipNet.IP.IsLoopback() && ipNet.IP.To4() != nil {
+			return ipNet.IP.String()
+		}
+	}
+	return ""
 }
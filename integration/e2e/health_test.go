@@ -88,7 +88,7 @@ var _ = Describe("Health", func() {
 			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
 
 			authClient, _ = nwo.PeerOperationalClients(network, peer)
-			healthURL = fmt.Sprintf("https://127.0.0.1:%d/healthz", network.PeerPort(peer, nwo.OperationsPort))
+			healthURL = network.PeerOperationsURL(peer, "healthz")
 		})
 
 		AfterEach(func() {
@@ -163,7 +163,7 @@ var _ = Describe("Health", func() {
 
 			orderer := network.Orderers[0]
 			authClient, _ = nwo.OrdererOperationalClients(network, orderer)
-			healthURL = fmt.Sprintf("https://127.0.0.1:%d/healthz", network.OrdererPort(orderer, nwo.OperationsPort))
+			healthURL = network.OrdererOperationsURL(orderer, "healthz")
 		})
 
 		AfterEach(func() {
@@ -77,31 +77,17 @@ var _ = Describe("Gossip State Transfer and Membership", func() {
 		//      peer0: follower
 		//      peer1: leader
 		for _, peer := range network.Peers {
-			if peer.Organization == "Org1" {
-				if peer.Name == "peer0" {
-					core := network.ReadPeerConfig(peer)
-					core.Peer.Gossip.State.Enabled = true
-					core.Peer.Gossip.UseLeaderElection = true
-					core.Peer.Gossip.OrgLeader = false
-					network.WritePeerConfig(peer, core)
-				}
-				if peer.Name == "peer1" {
-					core := network.ReadPeerConfig(peer)
-					core.Peer.Gossip.State.Enabled = true
-					core.Peer.Gossip.UseLeaderElection = true
-					core.Peer.Gossip.OrgLeader = false
-					core.Peer.Gossip.Bootstrap = fmt.Sprintf("127.0.0.1:%d", network.ReservePort())
-					network.WritePeerConfig(peer, core)
-				}
+			core := network.ReadPeerConfig(peer)
+			core.Peer.Gossip.State.Enabled = true
+			if peer.Organization == "Org1" && peer.Name == "peer1" {
+				core.Peer.Gossip.Bootstrap = fmt.Sprintf("127.0.0.1:%d", network.ReservePort())
 			}
-			if peer.Organization == "Org2" {
-				core := network.ReadPeerConfig(peer)
-				core.Peer.Gossip.State.Enabled = true
-				core.Peer.Gossip.UseLeaderElection = false
-				core.Peer.Gossip.OrgLeader = peer.Name == "peer1"
-				network.WritePeerConfig(peer, core)
+			network.WritePeerConfig(peer, core)
+			if peer.Organization == "Org1" {
+				network.SetDeliveryMode(peer, true, false)
 			}
 		}
+		network.SetOrgLeaders("Org2", network.Peer("Org2", "peer1"))
 
 		network.Bootstrap()
 		orderer := network.Orderer("orderer")
@@ -271,6 +271,96 @@ var _ = Describe("CouchDB indexes", func() {
 			verifySizeIndexDoesNotExist(network, "testchannel", orderer, network.Peer("Org1", "peer0"), "marbles")
 		})
 	})
+
+	When("the state cache of the couchdb peer is resized", func() {
+		It("serves repeated reads from the cache only when it is enabled", func() {
+			peer := network.Peer("Org1", "peer0")
+			readDocs := nwo.PeerMetric(network, peer, `couchdb_processing_time_count{database="testchannel_marbles",function_name="ReadDoc",result="200"}`)
+			readMarble := func() {
+				sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+					ChannelID: "testchannel",
+					Name:      "marbles",
+					Ctor:      prepareChaincodeInvokeArgs("readMarble", "marble_cached"),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			}
+
+			By("leaving the state cache disabled by default")
+			Expect(network.ReadPeerConfig(peer).Ledger.State.CouchDBConfig.CacheSize).To(BeZero())
+
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, peer, network.Peer("Org2", "peer0"))
+			nwo.DeployChaincode(network, "testchannel", orderer, newlifecycleChaincode, network.Peers...)
+			initMarble(network, "testchannel", orderer, peer, "marbles", "marble_cached")
+
+			By("reading the marble repeatedly from couchdb")
+			readMarble()
+			before := readDocs()
+			for i := 0; i < 3; i++ {
+				readMarble()
+			}
+			Expect(readDocs()).To(BeNumerically(">=", before+3))
+
+			By("enabling the state cache")
+			network.SetPeerStateCacheSize(peer, 64)
+			process = network.Restart(process)
+
+			By("reading the marble repeatedly from the cache")
+			readMarble()
+			before = readDocs()
+			for i := 0; i < 3; i++ {
+				readMarble()
+			}
+			Expect(readDocs()).To(Equal(before))
+		})
+	})
+
+	When("the couchdb state database of a peer is rebuilt", func() {
+		It("returns the same state after the rebuild", func() {
+			peer := network.Peer("Org1", "peer0")
+
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, peer, network.Peer("Org2", "peer0"))
+			nwo.DeployChaincode(network, "testchannel", orderer, newlifecycleChaincode, network.Peers...)
+			marbles := map[string]string{}
+			for _, name := range []string{"marble_rebuilt1", "marble_rebuilt2"} {
+				initMarble(network, "testchannel", orderer, peer, "marbles", name)
+				marbles[name] = nwo.QueryChaincodeOnPeer(network, peer, "testchannel", "marbles", prepareChaincodeInvokeArgs("readMarble", name))
+			}
+
+			By("rebuilding the databases of the couchdb peer")
+			process = nwo.RebuildStateDB(network, peer, process, network.NetworkGroupRunner())
+
+			By("reading the same marbles from the rebuilt state database")
+			for name, marble := range marbles {
+				Expect(nwo.QueryChaincodeOnPeer(network, peer, "testchannel", "marbles", prepareChaincodeInvokeArgs("readMarble", name))).To(MatchJSON(marble))
+			}
+		})
+	})
+
+	When("the couchdb state database is read directly", func() {
+		It("holds the state observed by the chaincode", func() {
+			peer := network.Peer("Org1", "peer0")
+
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, peer, network.Peer("Org2", "peer0"))
+			nwo.DeployChaincode(network, "testchannel", orderer, newlifecycleChaincode, network.Peers...)
+			initMarble(network, "testchannel", orderer, peer, "marbles", "marble_direct")
+
+			By("querying the marble through the chaincode")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "marbles",
+				Ctor:      prepareChaincodeInvokeArgs("readMarble", "marble_direct"),
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("reading the marble from couchdb")
+			value := nwo.ReadStateDB(network, peer, "testchannel", "marbles", "marble_direct")
+			Expect(value).NotTo(BeNil())
+			Expect(sess.Out.Contents()).To(MatchJSON(value))
+			Expect(nwo.ReadStateDB(network, peer, "testchannel", "marbles", "marble_missing")).To(BeNil())
+		})
+	})
 })
 
 func initMarble(n *nwo.Network, channel string, orderer *nwo.Orderer, peer *nwo.Peer, ccName, marbleName string) {
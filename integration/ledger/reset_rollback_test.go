@@ -191,6 +191,22 @@ var _ = Describe("rollback, reset, pause and resume peer node commands", func()
 		setup.startPeer(peer)
 		Expect(dbPath).To(BeADirectory())
 		helper.assertPresentInCollectionM("marblesp", "marble2", peer)
+
+		By("Reading marble1 to marble7 on peer org2.peer0 before rebuilding its databases")
+		marbles := map[string]string{}
+		for i := 1; i <= 7; i++ {
+			marbleName := fmt.Sprintf("marble%d", i)
+			marbles[marbleName] = helper.readMarble(peer, marbleName)
+		}
+
+		By("Rebuilding the databases of the peer")
+		Expect(setup.peerProcess).To(HaveLen(1))
+		setup.peerProcess[0] = nwo.RebuildStateDB(setup.network, peer, setup.peerProcess[0], setup.network.PeerRunner(peer))
+
+		By("Verifying the rebuilt state database returns the same marbles")
+		for marbleName, marble := range marbles {
+			Expect(helper.readMarble(peer, marbleName)).To(Equal(marble))
+		}
 	})
 })
 
@@ -472,6 +488,18 @@ func (th *testHelper) assertPresentInCollectionMPD(chaincodeName, marbleName str
 	}
 }
 
+// readMarble returns the marble read from collection 'collectionMarbles' at the given peer
+func (th *testHelper) readMarble(peer *nwo.Peer, marbleName string) string {
+	sess, err := th.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+		ChannelID: th.channelID,
+		Name:      "marblesp",
+		Ctor:      fmt.Sprintf(`{"Args":["readMarble","%s"]}`, marbleName),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, th.EventuallyTimeout).Should(gexec.Exit(0))
+	return string(sess.Out.Contents())
+}
+
 func (th *testHelper) assertDisabledEndorser(chaincodeName string, peer *nwo.Peer) {
 	command := commands.ChaincodeQuery{
 		ChannelID: th.channelID,
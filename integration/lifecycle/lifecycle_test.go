@@ -164,6 +164,16 @@ var _ = Describe("Lifecycle", func() {
 
 		nwo.ApproveChaincodeForMyOrg(network, "testchannel", orderer, chaincode, testPeers...)
 
+		By("invoking the approved chaincode before its definition is committed")
+		nwo.InvokeExpectingError(network, org1peer0, commands.ChaincodeInvoke{
+			ChannelID:     "testchannel",
+			Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+			Name:          "My_1st-Chaincode",
+			Ctor:          `{"Args":["invoke","a","b","10"]}`,
+			PeerAddresses: []string{network.PeerAddress(org1peer0, nwo.ListenPort)},
+			WaitForEvent:  true,
+		}, `make sure the chaincode My_1st-Chaincode has been successfully defined on channel testchannel and try again: chaincode My_1st-Chaincode not found`)
+
 		nwo.CheckCommitReadinessUntilReady(network, "testchannel", chaincode, network.PeerOrgs(), testPeers...)
 		nwo.CommitChaincode(network, "testchannel", orderer, chaincode, testPeers[0], testPeers...)
 		nwo.InitChaincode(network, "testchannel", orderer, chaincode, testPeers...)
@@ -331,7 +341,9 @@ var _ = Describe("Lifecycle", func() {
 		Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
 
 		By("installing the chaincode to the org3 peers")
+		Expect(nwo.PeersWithPackageInstalled(network, chaincode.PackageID, testPeers...)).NotTo(ContainElement(org3peer0))
 		nwo.InstallChaincode(network, chaincode, org3peer0)
+		Expect(nwo.PeersWithPackageInstalled(network, chaincode.PackageID, testPeers...)).To(ConsistOf(testPeers))
 
 		By("ensuring org3 peers do not execute the chaincode before approving the definition")
 		org3AndOrg1PeerAddresses := []string{
@@ -13,8 +13,11 @@ import (
 	"os"
 	"path/filepath"
 	"syscall"
+	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/common/crypto/tlsgen"
 	"github.com/hyperledger/fabric/integration/nwo"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	. "github.com/onsi/ginkgo"
@@ -185,6 +188,103 @@ var _ = Describe("MSP identity test on a network with mutual TLS required", func
 		Expect(sess).To(gbytes.Say("90"))
 
 	})
+
+	It("applies custom node OU identifiers to the channel MSP of an organization", func() {
+		By("mapping the client role of org1 to the auditor organizational unit")
+		nwo.ConfigureOrgMSP(network, "Org1", func(config *msp.FabricMSPConfig) {
+			config.FabricNodeOus.ClientOuIdentifier.OrganizationalUnitIdentifier = "auditor"
+		})
+
+		network.GenerateConfigTree()
+		network.Bootstrap()
+
+		By("starting all processes for fabric")
+		networkRunner := network.NetworkGroupRunner()
+		process = ifrit.Invoke(networkRunner)
+		Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+		org1Peer0 := network.Peer("Org1", "peer0")
+		orderer := network.Orderer("orderer")
+
+		By("creating and joining channels")
+		network.CreateAndJoinChannels(orderer)
+		nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("Org1", "peer0"), network.Peer("Org2", "peer0"))
+
+		By("deploying the chaincode")
+		chaincode := nwo.Chaincode{
+			Name:            "mycc",
+			Version:         "0.0",
+			Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+			Lang:            "golang",
+			PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+			SignaturePolicy: `OR ('Org1MSP.peer', 'Org2MSP.peer')`,
+			Sequence:        "1",
+			Label:           "my_simple_chaincode",
+		}
+		nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+		By("enrolling an org1 identity under the auditor organizational unit")
+		nwo.EnrollUser(network, "Org1", "Auditor", "auditor")
+
+		By("querying the chaincode with the auditor identity")
+		sess, err := network.PeerUserSession(org1Peer0, "Auditor", commands.ChaincodeQuery{
+			ChannelID: "testchannel",
+			Name:      "mycc",
+			Ctor:      `{"Args":["mspid"]}`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+		Expect(sess).To(gbytes.Say("Org1MSP"))
+
+		By("querying the chaincode with an identity under the default client organizational unit")
+		sess, err = network.PeerUserSession(org1Peer0, "User1", commands.ChaincodeQuery{
+			ChannelID: "testchannel",
+			Name:      "mycc",
+			Ctor:      `{"Args":["mspid"]}`,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+		Expect(sess.Err).To(gbytes.Say("access denied"))
+	})
+
+	It("does not deliver blocks to a peer whose TLS client certificate is not trusted by the orderer", func() {
+		network.ClientAuthRequired = true
+		network.GenerateConfigTree()
+		network.Bootstrap()
+
+		By("issuing the TLS client certificate of org2 peer0 from an untrusted CA")
+		untrustedCA, err := tlsgen.NewCA()
+		Expect(err).NotTo(HaveOccurred())
+		clientKeyPair, err := untrustedCA.NewClientCertKeyPair()
+		Expect(err).NotTo(HaveOccurred())
+		certFile := filepath.Join(tempDir, "untrusted-client.crt")
+		keyFile := filepath.Join(tempDir, "untrusted-client.key")
+		Expect(ioutil.WriteFile(certFile, clientKeyPair.Cert, 0644)).To(Succeed())
+		Expect(ioutil.WriteFile(keyFile, clientKeyPair.Key, 0600)).To(Succeed())
+
+		org1Peer0 := network.Peer("Org1", "peer0")
+		org2Peer0 := network.Peer("Org2", "peer0")
+		network.SetPeerTLSClientCertificate(org2Peer0, certFile, keyFile)
+
+		By("starting all processes for fabric")
+		networkRunner := network.NetworkGroupRunner()
+		process = ifrit.Invoke(networkRunner)
+		Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+		orderer := network.Orderer("orderer")
+		network.CreateAndJoinChannels(orderer)
+
+		By("creating new blocks on the channel")
+		network.UpdateChannelAnchors(orderer, "testchannel")
+
+		By("verifying only the peer with a trusted client certificate receives them")
+		Eventually(func() int {
+			return nwo.GetLedgerHeight(network, org1Peer0, "testchannel")
+		}, network.EventuallyTimeout).Should(BeNumerically(">", 1))
+		Consistently(func() int {
+			return nwo.GetLedgerHeight(network, org2Peer0, "testchannel")
+		}, 10*time.Second).Should(Equal(1))
+	})
 })
 
 func RunQueryInvokeQuery(n *nwo.Network, orderer *nwo.Orderer, peer *nwo.Peer, initialQueryResult int) {
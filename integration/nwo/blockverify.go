@@ -0,0 +1,107 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/bccsp/sw"
+	"github.com/hyperledger/fabric/common/channelconfig"
+	"github.com/hyperledger/fabric/common/policies"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/pkg/errors"
+)
+
+// VerifyBlockSignatures checks the block the way peers check the blocks
+// delivered by the ordering service. The data hash in the block header must
+// match the block's data and the signatures in the block metadata must
+// satisfy the BlockValidation policy of the channel config in force when the
+// block was cut, which requires signatures from the orderer organizations.
+// The config is read from the blocks fetched from the orderer through the
+// peer's CLI. The genesis block of a channel is not signed and is always
+// rejected. It returns an error describing the first check that fails.
+func VerifyBlockSignatures(n *Network, peer *Peer, orderer *Orderer, channel string, block *common.Block) error {
+	if block.Header == nil || block.Data == nil {
+		return errors.New("block has no header or data")
+	}
+	if block.Header.Number == 0 {
+		return errors.New("the genesis block of a channel is not signed")
+	}
+
+	// the config in force for a block, including a config block, is the
+	// latest config committed before it
+	configBlock := fetchBlock(n, peer, orderer, channel, block.Header.Number-1)
+	if configBlock.Header.Number != 0 {
+		lastConfig, err := protoutil.GetLastConfigIndexFromBlock(configBlock)
+		Expect(err).NotTo(HaveOccurred())
+		if lastConfig != configBlock.Header.Number {
+			configBlock = fetchBlock(n, peer, orderer, channel, lastConfig)
+		}
+	}
+
+	cryptoProvider, err := sw.NewDefaultSecurityLevelWithKeystore(sw.NewDummyKeyStore())
+	Expect(err).NotTo(HaveOccurred())
+	bundle, err := channelconfig.NewBundle(channel, configFromBlock(configBlock), cryptoProvider)
+	Expect(err).NotTo(HaveOccurred())
+
+	if !bytes.Equal(protoutil.BlockDataHash(block.Data), block.Header.DataHash) {
+		return errors.Errorf("data hash in the header of block %d does not match its data", block.Header.Number)
+	}
+
+	if block.Metadata == nil || len(block.Metadata.Metadata) <= int(common.BlockMetadataIndex_SIGNATURES) {
+		return errors.Errorf("block %d has no signature metadata", block.Header.Number)
+	}
+	metadata, err := protoutil.GetMetadataFromBlock(block, common.BlockMetadataIndex_SIGNATURES)
+	if err != nil {
+		return errors.WithMessagef(err, "failed unmarshaling signature metadata of block %d", block.Header.Number)
+	}
+
+	var signatureSet []*protoutil.SignedData
+	for _, metadataSignature := range metadata.Signatures {
+		sigHdr, err := protoutil.UnmarshalSignatureHeader(metadataSignature.SignatureHeader)
+		if err != nil {
+			return errors.WithMessagef(err, "failed unmarshaling signature header of block %d", block.Header.Number)
+		}
+		signatureSet = append(signatureSet, &protoutil.SignedData{
+			Identity:  sigHdr.Creator,
+			Data:      util.ConcatenateBytes(metadata.Value, metadataSignature.SignatureHeader, protoutil.BlockHeaderBytes(block.Header)),
+			Signature: metadataSignature.Signature,
+		})
+	}
+
+	policy, ok := bundle.PolicyManager().GetPolicy(policies.BlockValidation)
+	Expect(ok).To(BeTrue(), "no block validation policy on channel %s", channel)
+	return policy.EvaluateSignedData(signatureSet)
+}
+
+// fetchBlock fetches the numbered block of the channel from the orderer.
+func fetchBlock(n *Network, peer *Peer, orderer *Orderer, channel string, blockNum uint64) *common.Block {
+	tempDir, err := ioutil.TempDir(n.RootDir, "fetchBlock")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "block.pb")
+	sess, err := n.OrdererAdminSession(orderer, peer, commands.ChannelFetch{
+		ChannelID:  channel,
+		Block:      strconv.FormatUint(blockNum, 10),
+		Orderer:    n.OrdererAddress(orderer, ListenPort),
+		OutputFile: output,
+		ClientAuth: n.ClientAuthRequired,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	return UnmarshalBlockFromFile(output)
+}
@@ -0,0 +1,137 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"context"
+	"fmt"
+	"io/ioutil"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric/cmd/common/signer"
+	"github.com/hyperledger/fabric/internal/pkg/comm"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+)
+
+// SignerForUser returns a signer for the specified user of an organization.
+// The signer can be used to create envelopes that are submitted directly to
+// an orderer without going through the peer CLI.
+func SignerForUser(n *Network, orgName, user string) *signer.Signer {
+	org := n.Organization(orgName)
+	Expect(org).NotTo(BeNil())
+
+	nodeOrganizationType := "peerOrganizations"
+	if len(n.PeersInOrg(orgName)) == 0 {
+		nodeOrganizationType = "ordererOrganizations"
+	}
+	mspDir := n.userCryptoDir(org, nodeOrganizationType, user, "msp")
+
+	// file names are the SKI and non-deterministic
+	keystore := filepath.Join(mspDir, "keystore")
+	keys, err := ioutil.ReadDir(keystore)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(keys).To(HaveLen(1))
+
+	s, err := signer.NewSigner(signer.Config{
+		MSPID:        org.MSPID,
+		IdentityPath: filepath.Join(mspDir, "signcerts", fmt.Sprintf("%s@%s-cert.pem", user, org.Domain)),
+		KeyPath:      filepath.Join(keystore, keys[0].Name()),
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	return s
+}
+
+// Broadcast submits the envelope to the Broadcast API of the orderer and
+// returns the orderer's response.
+func Broadcast(n *Network, o *Orderer, env *common.Envelope) *orderer.BroadcastResponse {
+	conn := clientConn(n, n.OrdererLocalTLSDir(o), n.OrdererAddress(o, ListenPort))
+	defer conn.Close()
+
+	ctx, cancel := context.WithTimeout(context.Background(), n.EventuallyTimeout)
+	defer cancel()
+	broadcaster, err := orderer.NewAtomicBroadcastClient(conn).Broadcast(ctx)
+	Expect(err).NotTo(HaveOccurred())
+
+	err = broadcaster.Send(env)
+	Expect(err).NotTo(HaveOccurred())
+
+	resp, err := broadcaster.Recv()
+	Expect(err).NotTo(HaveOccurred())
+
+	return resp
+}
+
+// BroadcastExpectingStatus submits the envelope for the channel to the
+// Broadcast API of the orderer and asserts that the orderer responds with the
+// expected status. The response is returned so the reason given by the
+// orderer can be checked.
+func BroadcastExpectingStatus(n *Network, o *Orderer, channel string, env *common.Envelope, expectedStatus common.Status) *orderer.BroadcastResponse {
+	channelID, err := protoutil.ChannelID(env)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(channelID).To(Equal(channel), "envelope is not for channel %s", channel)
+
+	resp := Broadcast(n, o, env)
+	Expect(resp.Status).To(Equal(expectedStatus), "unexpected response: %s", resp.Info)
+	return resp
+}
+
+// BroadcastOversized submits to the orderer an endorser transaction for the
+// channel with a payload of at least size bytes, signed by User1 of the first
+// peer organization, and asserts that the orderer rejects it with
+// BAD_REQUEST. The size should exceed the AbsoluteMaxBytes of the channel's
+// batch size but stay within the maximum message size of the orderer's gRPC
+// server.
+func BroadcastOversized(n *Network, o *Orderer, channel string, size int) *orderer.BroadcastResponse {
+	peerOrgs := n.PeerOrgs()
+	Expect(peerOrgs).NotTo(BeEmpty())
+	signer := SignerForUser(n, peerOrgs[0].Name, "User1")
+
+	env, err := protoutil.CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, channel, signer, &common.ConfigValue{Value: make([]byte, size)}, 0, 0)
+	Expect(err).NotTo(HaveOccurred())
+	return BroadcastExpectingStatus(n, o, channel, env, common.Status_BAD_REQUEST)
+}
+
+// clientConn returns a gRPC connection to the address using the CA
+// certificate and, when client authentication is required, the server key
+// pair found in the node's TLS directory.
+func clientConn(n *Network, tlsDir, address string) *grpc.ClientConn {
+	conn, err := grpcClient(n, tlsDir).NewConnection(address)
+	Expect(err).NotTo(HaveOccurred())
+	return conn
+}
+
+// grpcClient returns a gRPC client configured with the CA certificate and,
+// when client authentication is required, the server key pair found in the
+// node's TLS directory.
+func grpcClient(n *Network, tlsDir string) *comm.GRPCClient {
+	caPEM, err := ioutil.ReadFile(filepath.Join(tlsDir, "ca.crt"))
+	Expect(err).NotTo(HaveOccurred())
+	secOpts := comm.SecureOptions{
+		UseTLS:        true,
+		ServerRootCAs: [][]byte{caPEM},
+	}
+	if n.ClientAuthRequired {
+		secOpts.RequireClientCert = true
+		secOpts.Certificate, err = ioutil.ReadFile(filepath.Join(tlsDir, "server.crt"))
+		Expect(err).NotTo(HaveOccurred())
+		secOpts.Key, err = ioutil.ReadFile(filepath.Join(tlsDir, "server.key"))
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	client, err := comm.NewGRPCClient(comm.ClientConfig{
+		SecOpts: secOpts,
+		Timeout: 5 * time.Second,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return client
+}
@@ -0,0 +1,278 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bytes"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"fmt"
+	"io/ioutil"
+	"math/big"
+	"net"
+	"os"
+	"path/filepath"
+	"time"
+
+	"github.com/hyperledger/fabric/internal/cryptogen/ca"
+	"github.com/hyperledger/fabric/internal/cryptogen/csp"
+	. "github.com/onsi/gomega"
+)
+
+// SetPeerTLSCertValidity reissues the TLS server certificate of the peer so
+// that it expires once the validity has elapsed from now. The certificate
+// keeps its key pair and subject alternative names and is signed by the TLS
+// CA of the peer's organization, or by its intermediate TLS CA when the
+// network uses TLSIntermediateCAs. A validity of a few seconds produces a
+// certificate that expires almost immediately. The peer presents the
+// certificate the next time it starts.
+func (n *Network) SetPeerTLSCertValidity(p *Peer, validity time.Duration) {
+	n.reissueTLSCert(n.PeerLocalTLSDir(p), n.Organization(p.Organization), validity)
+}
+
+// SetOrdererTLSCertValidity reissues the TLS server certificate of the
+// orderer so that it expires once the validity has elapsed from now. See
+// SetPeerTLSCertValidity.
+func (n *Network) SetOrdererTLSCertValidity(o *Orderer, validity time.Duration) {
+	n.reissueTLSCert(n.OrdererLocalTLSDir(o), n.Organization(o.Organization), validity)
+}
+
+func (n *Network) reissueTLSCert(tlsDir string, org *Organization, validity time.Duration) {
+	Expect(org).NotTo(BeNil())
+	Expect(validity).To(BeNumerically(">", 0))
+
+	issuer, chain := n.tlsIssuer(org)
+	now := time.Now()
+	reissueCert(filepath.Join(tlsDir, "server.crt"), issuer, now.Add(-5*time.Minute), now.Add(validity), chain...)
+}
+
+// reissueTLSCACerts reissues the self-signed TLS CA certificate of every
+// organization so that it expires once the validity has elapsed from now, and
+// replaces each copy of the previous certificate in the organization's crypto
+// material, such as the tlscacerts of its MSPs and the ca.crt of its nodes.
+func (n *Network) reissueTLSCACerts(validity time.Duration) {
+	Expect(validity).To(BeNumerically(">", 0))
+
+	for _, org := range n.Organizations {
+		if org.MSPType == "idemix" {
+			continue
+		}
+
+		caCertPath := n.OrgTLSCACert(org)
+		orgDir := filepath.Dir(filepath.Dir(caCertPath))
+		tlsCA := loadCA(filepath.Join(orgDir, "tlsca"), "tlsca."+org.Domain)
+		oldPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: tlsCA.SignCert.Raw})
+
+		now := time.Now()
+		reissueCert(caCertPath, tlsCA, now.Add(-5*time.Minute), now.Add(validity))
+		newPEM, err := ioutil.ReadFile(caCertPath)
+		Expect(err).NotTo(HaveOccurred())
+
+		err = filepath.Walk(orgDir, func(path string, info os.FileInfo, err error) error {
+			Expect(err).NotTo(HaveOccurred())
+			if !info.Mode().IsRegular() {
+				return nil
+			}
+			contents, err := ioutil.ReadFile(path)
+			Expect(err).NotTo(HaveOccurred())
+			if bytes.Contains(contents, oldPEM) {
+				err = ioutil.WriteFile(path, bytes.Replace(contents, oldPEM, newPEM, -1), info.Mode())
+				Expect(err).NotTo(HaveOccurred())
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+// tlsIssuer returns the CA that issues the TLS certificates of the
+// organization's components along with the intermediate certificates that
+// accompany them. Without intermediate TLS CAs the root TLS CA issues the
+// certificates directly and no intermediates are returned.
+func (n *Network) tlsIssuer(org *Organization) (*ca.CA, []*x509.Certificate) {
+	orgDir := filepath.Dir(filepath.Dir(n.OrgTLSCACert(org)))
+	if !n.TLSIntermediateCAs {
+		return loadCA(filepath.Join(orgDir, "tlsca"), "tlsca."+org.Domain), nil
+	}
+	ica := loadCA(filepath.Join(orgDir, "tlsica"), "tlsica."+org.Domain)
+	return ica, []*x509.Certificate{ica.SignCert}
+}
+
+// issueTLSCertsFromIntermediateCAs creates an intermediate TLS CA for every
+// organization, signed by the organization's root TLS CA, and reissues the
+// TLS certificates of the organization's nodes and users from it. Each
+// certificate file holds the leaf followed by the intermediate so the full
+// chain is presented during handshakes. The intermediate is added to the
+// tlsintermediatecerts folder of every MSP of the organization while ca.crt
+// and the TLS CA bundles keep trusting only the root.
+func (n *Network) issueTLSCertsFromIntermediateCAs() {
+	for _, org := range n.Organizations {
+		if org.MSPType == "idemix" {
+			continue
+		}
+
+		orgDir := filepath.Dir(filepath.Dir(n.OrgTLSCACert(org)))
+		tlsCA := loadCA(filepath.Join(orgDir, "tlsca"), "tlsca."+org.Domain)
+		ica := newIntermediateCA(filepath.Join(orgDir, "tlsica"), "tlsica."+org.Domain, tlsCA)
+		icaPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: ica.SignCert.Raw})
+		icaFile := fmt.Sprintf("tlsica.%s-cert.pem", org.Domain)
+
+		err := filepath.Walk(orgDir, func(path string, info os.FileInfo, err error) error {
+			Expect(err).NotTo(HaveOccurred())
+			switch {
+			case info.IsDir() && info.Name() == "msp":
+				dir := filepath.Join(path, "tlsintermediatecerts")
+				Expect(os.MkdirAll(dir, 0755)).To(Succeed())
+				Expect(ioutil.WriteFile(filepath.Join(dir, icaFile), icaPEM, 0644)).To(Succeed())
+			case filepath.Base(filepath.Dir(path)) == "tls" && (info.Name() == "server.crt" || info.Name() == "client.crt"):
+				cert := readCert(path)
+				reissueCert(path, ica, cert.NotBefore, cert.NotAfter, ica.SignCert)
+			}
+			return nil
+		})
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func newIntermediateCA(caDir, name string, parent *ca.CA) *ca.CA {
+	Expect(os.MkdirAll(caDir, 0755)).To(Succeed())
+	key, err := csp.GeneratePrivateKey(caDir)
+	Expect(err).NotTo(HaveOccurred())
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	Expect(err).NotTo(HaveOccurred())
+	ski := sha256.Sum256(elliptic.Marshal(key.Curve, key.X, key.Y))
+	subject := parent.SignCert.Subject
+	subject.CommonName = name
+
+	template := &x509.Certificate{
+		SerialNumber:          serialNumber,
+		Subject:               subject,
+		NotBefore:             parent.SignCert.NotBefore,
+		NotAfter:              parent.SignCert.NotAfter,
+		KeyUsage:              x509.KeyUsageDigitalSignature | x509.KeyUsageKeyEncipherment | x509.KeyUsageCertSign | x509.KeyUsageCRLSign,
+		ExtKeyUsage:           []x509.ExtKeyUsage{x509.ExtKeyUsageClientAuth, x509.ExtKeyUsageServerAuth},
+		BasicConstraintsValid: true,
+		IsCA:                  true,
+		SubjectKeyId:          ski[:],
+	}
+	der, err := x509.CreateCertificate(rand.Reader, template, parent.SignCert, &key.PublicKey, parent.Signer)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := x509.ParseCertificate(der)
+	Expect(err).NotTo(HaveOccurred())
+	err = ioutil.WriteFile(filepath.Join(caDir, name+"-cert.pem"), pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der}), 0644)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &ca.CA{
+		Name:     name,
+		Signer:   &csp.ECDSASigner{PrivateKey: key},
+		SignCert: cert,
+	}
+}
+
+// reissueCert signs the leaf certificate stored at certPath again with the
+// issuer, keeping its key pair, subject, and extensions, and writes it back
+// followed by the chain of intermediate certificates.
+func reissueCert(certPath string, issuer *ca.CA, notBefore, notAfter time.Time, chain ...*x509.Certificate) {
+	cert := readCert(certPath)
+
+	serialNumber, err := rand.Int(rand.Reader, new(big.Int).Lsh(big.NewInt(1), 128))
+	Expect(err).NotTo(HaveOccurred())
+	template := *cert
+	template.SerialNumber = serialNumber
+	template.NotBefore = notBefore.UTC()
+	template.NotAfter = notAfter.UTC()
+	template.AuthorityKeyId = nil
+
+	der, err := x509.CreateCertificate(rand.Reader, &template, issuer.SignCert, cert.PublicKey, issuer.Signer)
+	Expect(err).NotTo(HaveOccurred())
+
+	certPEM := pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: der})
+	for _, c := range chain {
+		certPEM = append(certPEM, pem.EncodeToMemory(&pem.Block{Type: "CERTIFICATE", Bytes: c.Raw})...)
+	}
+	err = ioutil.WriteFile(certPath, certPEM, 0644)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func readCert(certPath string) *x509.Certificate {
+	certPEM, err := ioutil.ReadFile(certPath)
+	Expect(err).NotTo(HaveOccurred())
+	block, _ := pem.Decode(certPEM)
+	Expect(block).NotTo(BeNil(), "no PEM data found in %s", certPath)
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+	return cert
+}
+
+// AssertPeerListenerCertSANs asserts that the certificate presented by the
+// TLS listener of the peer on the named port carries each of the expected
+// subject alternative names. A name that parses as an IP address is matched
+// against the IP addresses of the certificate and any other name against its
+// DNS names.
+func AssertPeerListenerCertSANs(n *Network, p *Peer, portName PortName, expectedSANs ...string) {
+	assertCertSANs(ListenerCertificate(n.PeerAddress(p, portName)), expectedSANs)
+}
+
+// AssertOrdererListenerCertSANs asserts that the certificate presented by the
+// TLS listener of the orderer on the named port carries each of the expected
+// subject alternative names. See AssertPeerListenerCertSANs.
+func AssertOrdererListenerCertSANs(n *Network, o *Orderer, portName PortName, expectedSANs ...string) {
+	assertCertSANs(ListenerCertificate(n.OrdererAddress(o, portName)), expectedSANs)
+}
+
+// ListenerCertificate dials the TLS listener at the address and returns the
+// leaf certificate it presents. The certificate is not verified and it is
+// captured even when the listener rejects the handshake because no client
+// certificate was presented.
+func ListenerCertificate(address string) *x509.Certificate {
+	return ListenerCertificateChain(address)[0]
+}
+
+// ListenerCertificateChain dials the TLS listener at the address and returns
+// the certificates it presents, starting with the leaf. See
+// ListenerCertificate.
+func ListenerCertificateChain(address string) []*x509.Certificate {
+	var chain []*x509.Certificate
+	conn, err := tls.DialWithDialer(&net.Dialer{Timeout: 10 * time.Second}, "tcp", address, &tls.Config{
+		InsecureSkipVerify: true,
+		VerifyPeerCertificate: func(rawCerts [][]byte, _ [][]*x509.Certificate) error {
+			for _, raw := range rawCerts {
+				cert, err := x509.ParseCertificate(raw)
+				if err != nil {
+					return err
+				}
+				chain = append(chain, cert)
+			}
+			return nil
+		},
+	})
+	if err == nil {
+		conn.Close()
+	}
+	Expect(chain).NotTo(BeEmpty(), "no certificate was presented by %s: %v", address, err)
+	return chain
+}
+
+func assertCertSANs(cert *x509.Certificate, expectedSANs []string) {
+	var ips []string
+	for _, ip := range cert.IPAddresses {
+		ips = append(ips, ip.String())
+	}
+
+	for _, san := range expectedSANs {
+		if ip := net.ParseIP(san); ip != nil {
+			Expect(ips).To(ContainElement(ip.String()), "certificate %s lacks IP SAN %s", cert.Subject, san)
+			continue
+		}
+		Expect(cert.DNSNames).To(ContainElement(san), "certificate %s lacks DNS SAN %s", cert.Subject, san)
+	}
+}
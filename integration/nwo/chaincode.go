@@ -0,0 +1,36 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"time"
+
+	. "github.com/onsi/gomega"
+)
+
+// SetChaincodeExecuteTimeout sets how long the peer waits for a chaincode to
+// complete a transaction before failing it. The peer ignores timeouts shorter
+// than a second. The change takes effect the next time the peer starts.
+func (n *Network) SetChaincodeExecuteTimeout(p *Peer, timeout time.Duration) {
+	Expect(timeout).To(BeNumerically(">=", time.Second))
+
+	core := n.ReadPeerConfig(p)
+	core.Chaincode.ExecuteTimeout = timeout
+	n.WritePeerConfig(p, core)
+}
+
+// SetChaincodeStartupTimeout sets how long the peer waits for a chaincode it
+// launches to register before failing the launch. The peer ignores timeouts
+// shorter than five seconds. The change takes effect the next time the peer
+// starts.
+func (n *Network) SetChaincodeStartupTimeout(p *Peer, timeout time.Duration) {
+	Expect(timeout).To(BeNumerically(">=", 5*time.Second))
+
+	core := n.ReadPeerConfig(p)
+	core.Chaincode.StartupTimeout = timeout
+	n.WritePeerConfig(p, core)
+}
@@ -0,0 +1,251 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"crypto/tls"
+	"crypto/x509"
+	"encoding/pem"
+	"io"
+	"io/ioutil"
+	"net"
+	"path/filepath"
+	"strconv"
+	"sync"
+
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	. "github.com/onsi/gomega"
+)
+
+// clusterProxy relays the cluster traffic sent to an orderer so that traffic
+// from selected orderers can be dropped. The proxy terminates TLS with the
+// orderer's server certificate and dials the orderer with the certificate of
+// the orderer that opened the connection, so the orderer continues to
+// authenticate its peers.
+type clusterProxy struct {
+	network  *Network
+	backend  string
+	listener net.Listener
+	rootCAs  *x509.CertPool
+
+	mutex   sync.Mutex
+	blocked map[string]bool
+	conns   map[string]map[net.Conn]struct{}
+}
+
+// EnableOrdererTrafficProxy places a proxy in front of the cluster listener
+// of every orderer in the network. Each orderer is moved to a free port while
+// its proxy takes over the advertised cluster port, so orderers reach one
+// another through the proxies. It must be called after the configuration
+// tree has been generated and before the orderers are started. The proxies
+// are closed by Cleanup.
+func (n *Network) EnableOrdererTrafficProxy() {
+	if n.clusterProxies == nil {
+		n.clusterProxies = map[string]*clusterProxy{}
+	}
+	for _, o := range n.Orderers {
+		if _, ok := n.clusterProxies[o.ID()]; ok {
+			continue
+		}
+		n.clusterProxies[o.ID()] = n.startClusterProxy(o)
+	}
+}
+
+// BlockOrdererTraffic drops all cluster traffic sent from one orderer to
+// another. Traffic in the opposite direction is unaffected. Existing
+// connections are closed and new connections are refused until the traffic
+// is unblocked.
+func BlockOrdererTraffic(n *Network, from, to *Orderer) {
+	proxy := n.clusterProxies[to.ID()]
+	Expect(proxy).NotTo(BeNil(), "orderer traffic proxy is not enabled for %s", to.ID())
+	proxy.block(from.ID())
+}
+
+// UnblockOrdererTraffic restores cluster traffic sent from one orderer to
+// another.
+func UnblockOrdererTraffic(n *Network, from, to *Orderer) {
+	proxy := n.clusterProxies[to.ID()]
+	Expect(proxy).NotTo(BeNil(), "orderer traffic proxy is not enabled for %s", to.ID())
+	proxy.unblock(from.ID())
+}
+
+func (n *Network) closeClusterProxies() {
+	for id, proxy := range n.clusterProxies {
+		proxy.close()
+		delete(n.clusterProxies, id)
+	}
+}
+
+func (n *Network) startClusterProxy(o *Orderer) *clusterProxy {
+	// reserve a free port for the orderer's own cluster listener
+	l, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+	backendPort := l.Addr().(*net.TCPAddr).Port
+	Expect(l.Close()).To(Succeed())
+
+	ordererConfig := n.ReadOrdererConfig(o)
+	if ordererConfig.General.Cluster == nil {
+		ordererConfig.General.Cluster = &fabricconfig.Cluster{}
+	}
+	ordererConfig.General.Cluster.ListenAddress = "127.0.0.1"
+	ordererConfig.General.Cluster.ListenPort = backendPort
+	n.WriteOrdererConfig(o, ordererConfig)
+
+	tlsDir := n.OrdererLocalTLSDir(o)
+	cert, err := tls.LoadX509KeyPair(filepath.Join(tlsDir, "server.crt"), filepath.Join(tlsDir, "server.key"))
+	Expect(err).NotTo(HaveOccurred())
+	caPEM, err := ioutil.ReadFile(filepath.Join(tlsDir, "ca.crt"))
+	Expect(err).NotTo(HaveOccurred())
+	rootCAs := x509.NewCertPool()
+	Expect(rootCAs.AppendCertsFromPEM(caPEM)).To(BeTrue())
+
+	listener, err := tls.Listen("tcp", n.OrdererAddress(o, ClusterPort), &tls.Config{
+		Certificates: []tls.Certificate{cert},
+		ClientAuth:   tls.RequireAnyClientCert,
+		NextProtos:   []string{"h2"},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	proxy := &clusterProxy{
+		network:  n,
+		backend:  net.JoinHostPort("127.0.0.1", strconv.Itoa(backendPort)),
+		listener: listener,
+		rootCAs:  rootCAs,
+		blocked:  map[string]bool{},
+		conns:    map[string]map[net.Conn]struct{}{},
+	}
+	go proxy.serve()
+
+	return proxy
+}
+
+func (p *clusterProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.relay(conn.(*tls.Conn))
+	}
+}
+
+func (p *clusterProxy) relay(conn *tls.Conn) {
+	defer conn.Close()
+
+	if err := conn.Handshake(); err != nil {
+		return
+	}
+	peerCerts := conn.ConnectionState().PeerCertificates
+	if len(peerCerts) == 0 {
+		return
+	}
+	source := p.sourceOrderer(peerCerts[0].Raw)
+	if source == nil {
+		return
+	}
+
+	tlsDir := p.network.OrdererLocalTLSDir(source)
+	clientCert, err := tls.LoadX509KeyPair(filepath.Join(tlsDir, "server.crt"), filepath.Join(tlsDir, "server.key"))
+	if err != nil {
+		return
+	}
+
+	if !p.track(source.ID(), conn) {
+		return
+	}
+	defer p.untrack(source.ID(), conn)
+
+	backend, err := tls.Dial("tcp", p.backend, &tls.Config{
+		Certificates: []tls.Certificate{clientCert},
+		RootCAs:      p.rootCAs,
+		NextProtos:   []string{"h2"},
+	})
+	if err != nil {
+		return
+	}
+	defer backend.Close()
+
+	if !p.track(source.ID(), backend) {
+		return
+	}
+	defer p.untrack(source.ID(), backend)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backend, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(conn, backend)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+// sourceOrderer returns the orderer whose TLS certificate matches the
+// provided DER encoded certificate.
+func (p *clusterProxy) sourceOrderer(der []byte) *Orderer {
+	for _, o := range p.network.Orderers {
+		certPEM, err := ioutil.ReadFile(filepath.Join(p.network.OrdererLocalTLSDir(o), "server.crt"))
+		if err != nil {
+			continue
+		}
+		block, _ := pem.Decode(certPEM)
+		if block != nil && string(block.Bytes) == string(der) {
+			return o
+		}
+	}
+	return nil
+}
+
+func (p *clusterProxy) track(source string, conn net.Conn) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.blocked[source] {
+		return false
+	}
+	if p.conns[source] == nil {
+		p.conns[source] = map[net.Conn]struct{}{}
+	}
+	p.conns[source][conn] = struct{}{}
+	return true
+}
+
+func (p *clusterProxy) untrack(source string, conn net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.conns[source], conn)
+}
+
+func (p *clusterProxy) block(source string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.blocked[source] = true
+	for conn := range p.conns[source] {
+		conn.Close()
+	}
+}
+
+func (p *clusterProxy) unblock(source string) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.blocked, source)
+}
+
+func (p *clusterProxy) close() {
+	p.listener.Close()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for _, conns := range p.conns {
+		for conn := range conns {
+			conn.Close()
+		}
+	}
+}
@@ -35,6 +35,19 @@ func (n NodeReset) Args() []string {
 	}
 }
 
+type NodeRebuildDBs struct {
+}
+
+func (n NodeRebuildDBs) SessionName() string {
+	return "peer-node-rebuild-dbs"
+}
+
+func (n NodeRebuildDBs) Args() []string {
+	return []string{
+		"node", "rebuild-dbs",
+	}
+}
+
 type NodeRollback struct {
 	ChannelID   string
 	BlockNumber int
@@ -129,6 +142,24 @@ func (c ChannelJoin) Args() []string {
 	return args
 }
 
+type ChannelList struct {
+	ClientAuth bool
+}
+
+func (c ChannelList) SessionName() string {
+	return "peer-channel-list"
+}
+
+func (c ChannelList) Args() []string {
+	args := []string{
+		"channel", "list",
+	}
+	if c.ClientAuth {
+		args = append(args, "--clientauth")
+	}
+	return args
+}
+
 type ChannelFetch struct {
 	ChannelID  string
 	Block      string
@@ -617,10 +648,11 @@ func (c ChaincodeListInstantiatedLegacy) Args() []string {
 }
 
 type ChaincodeQuery struct {
-	ChannelID  string
-	Name       string
-	Ctor       string
-	ClientAuth bool
+	ChannelID     string
+	Name          string
+	Ctor          string
+	PeerAddresses []string
+	ClientAuth    bool
 }
 
 func (c ChaincodeQuery) SessionName() string {
@@ -634,6 +666,9 @@ func (c ChaincodeQuery) Args() []string {
 		"--name", c.Name,
 		"--ctor", c.Ctor,
 	}
+	for _, p := range c.PeerAddresses {
+		args = append(args, "--peerAddresses", p)
+	}
 	if c.ClientAuth {
 		args = append(args, "--clientauth")
 	}
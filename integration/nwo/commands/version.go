@@ -0,0 +1,18 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package commands
+
+// Version prints the build information of the peer or orderer binary.
+type Version struct{}
+
+func (v Version) SessionName() string {
+	return "version"
+}
+
+func (v Version) Args() []string {
+	return []string{"version"}
+}
@@ -7,15 +7,22 @@ SPDX-License-Identifier: Apache-2.0
 package nwo
 
 import (
+	"bytes"
+	"fmt"
 	"io/ioutil"
 	"os"
 	"path/filepath"
+	"strconv"
 	"strings"
+	"time"
 
 	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/protolator"
+	"github.com/hyperledger/fabric-config/protolator/protoext/ordererext"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/msp"
 	protosorderer "github.com/hyperledger/fabric-protos-go/orderer"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	"github.com/hyperledger/fabric/internal/configtxlator/update"
 	"github.com/hyperledger/fabric/protoutil"
@@ -51,6 +58,25 @@ func GetConfigBlock(n *Network, peer *Peer, orderer *Orderer, channel string) *c
 // GetConfig retrieves the last config of the given channel.
 func GetConfig(n *Network, peer *Peer, orderer *Orderer, channel string) *common.Config {
 	configBlock := GetConfigBlock(n, peer, orderer, channel)
+	return configFromBlock(configBlock)
+}
+
+// ChannelConfigJSON retrieves the latest config of the channel from the
+// orderer as an admin of the peer's organization and returns it as indented
+// JSON, decoded the way configtxlator decodes it. Nested messages such as MSP
+// configs and consensus metadata are expanded so tests can make targeted
+// assertions about the config.
+func ChannelConfigJSON(n *Network, peer *Peer, orderer *Orderer, channel string) string {
+	config := GetConfig(n, peer, orderer, channel)
+
+	buf := &bytes.Buffer{}
+	err := protolator.DeepMarshalJSON(buf, config)
+	Expect(err).NotTo(HaveOccurred())
+	return buf.String()
+}
+
+// configFromBlock extracts the config from a config block.
+func configFromBlock(configBlock *common.Block) *common.Config {
 	// unmarshal the envelope bytes
 	envelope, err := protoutil.GetEnvelopeFromBlock(configBlock.Data.Data[0])
 	Expect(err).NotTo(HaveOccurred())
@@ -75,6 +101,43 @@ func UpdateConfig(n *Network, orderer *Orderer, channel string, current, updated
 	Expect(err).NotTo(HaveOccurred())
 	defer os.RemoveAll(tempDir)
 
+	updateFile := filepath.Join(tempDir, "update.pb")
+	ComputeUpdateConfig(updateFile, n, channel, current, updated, additionalSigners...)
+
+	var currentBlockNumber uint64
+	// get current configuration block number
+	if getConfigBlockFromOrderer {
+		currentBlockNumber = CurrentConfigBlockNumber(n, submitter, orderer, channel)
+	} else {
+		currentBlockNumber = CurrentConfigBlockNumber(n, submitter, nil, channel)
+	}
+
+	sess, err := n.PeerAdminSession(submitter, commands.ChannelUpdate{
+		ChannelID:  channel,
+		Orderer:    n.OrdererAddress(orderer, ListenPort),
+		File:       updateFile,
+		ClientAuth: n.ClientAuthRequired,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	Expect(sess.Err).To(gbytes.Say("Successfully submitted channel update"))
+
+	if getConfigBlockFromOrderer {
+		ccb := func() uint64 { return CurrentConfigBlockNumber(n, submitter, orderer, channel) }
+		Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
+		return
+	}
+	// wait for the block to be committed to all peers that
+	// have joined the channel
+	for _, peer := range n.PeersWithChannel(channel) {
+		ccb := func() uint64 { return CurrentConfigBlockNumber(n, peer, nil, channel) }
+		Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
+	}
+}
+
+// ComputeUpdateConfig computes a configuration update, signs it with the
+// admins of the additional signers, and writes it to updateFile.
+func ComputeUpdateConfig(updateFile string, n *Network, channel string, current, updated *common.Config, additionalSigners ...*Peer) {
 	// compute update
 	configUpdate, err := update.Compute(current, updated)
 	Expect(err).NotTo(HaveOccurred())
@@ -91,7 +154,6 @@ func UpdateConfig(n *Network, orderer *Orderer, channel string, current, updated
 	Expect(err).NotTo(HaveOccurred())
 	Expect(signedEnvelope).NotTo(BeNil())
 
-	updateFile := filepath.Join(tempDir, "update.pb")
 	err = ioutil.WriteFile(updateFile, protoutil.MarshalOrPanic(signedEnvelope), 0600)
 	Expect(err).NotTo(HaveOccurred())
 
@@ -103,14 +165,18 @@ func UpdateConfig(n *Network, orderer *Orderer, channel string, current, updated
 		Expect(err).NotTo(HaveOccurred())
 		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
 	}
+}
 
-	var currentBlockNumber uint64
-	// get current configuration block number
-	if getConfigBlockFromOrderer {
-		currentBlockNumber = CurrentConfigBlockNumber(n, submitter, orderer, channel)
-	} else {
-		currentBlockNumber = CurrentConfigBlockNumber(n, submitter, nil, channel)
-	}
+// UpdateConfigSession computes, signs, and submits a configuration update
+// without waiting for the update to complete. The caller should wait on the
+// returned session to retrieve the exit code. The signed update is removed
+// once the session exits.
+func UpdateConfigSession(n *Network, orderer *Orderer, channel string, current, updated *common.Config, submitter *Peer, additionalSigners ...*Peer) *gexec.Session {
+	tempDir, err := ioutil.TempDir(n.RootDir, "updateConfig")
+	Expect(err).NotTo(HaveOccurred())
+	updateFile := filepath.Join(tempDir, "update.pb")
+
+	ComputeUpdateConfig(updateFile, n, channel, current, updated, additionalSigners...)
 
 	sess, err := n.PeerAdminSession(submitter, commands.ChannelUpdate{
 		ChannelID:  channel,
@@ -118,21 +184,16 @@ func UpdateConfig(n *Network, orderer *Orderer, channel string, current, updated
 		File:       updateFile,
 		ClientAuth: n.ClientAuthRequired,
 	})
+	if err != nil {
+		os.RemoveAll(tempDir)
+	}
 	Expect(err).NotTo(HaveOccurred())
-	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
-	Expect(sess.Err).To(gbytes.Say("Successfully submitted channel update"))
 
-	if getConfigBlockFromOrderer {
-		ccb := func() uint64 { return CurrentConfigBlockNumber(n, submitter, orderer, channel) }
-		Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
-		return
-	}
-	// wait for the block to be committed to all peers that
-	// have joined the channel
-	for _, peer := range n.PeersWithChannel(channel) {
-		ccb := func() uint64 { return CurrentConfigBlockNumber(n, peer, nil, channel) }
-		Eventually(ccb, n.EventuallyTimeout).Should(BeNumerically(">", currentBlockNumber))
-	}
+	go func() {
+		<-sess.Exited
+		os.RemoveAll(tempDir)
+	}()
+	return sess
 }
 
 // CurrentConfigBlockNumber retrieves the block number from the header of the
@@ -176,6 +237,44 @@ func CurrentConfigBlockNumberFromPeer(n *Network, peer *Peer, channel, output st
 	return configBlock.Header.Number
 }
 
+// CurrentConfigSequenceFromPeer retrieves the sequence of the config in the
+// peer's current config block. Zero is returned when the peer is unable to
+// provide the config block, for example when it has not joined the channel.
+func CurrentConfigSequenceFromPeer(n *Network, peer *Peer, channel string) uint64 {
+	tempDir, err := ioutil.TempDir(n.RootDir, "currentConfigSequence")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	output := filepath.Join(tempDir, "config_block.pb")
+	sess, err := n.PeerAdminSession(peer, commands.ChannelFetch{
+		ChannelID:  channel,
+		Block:      "config",
+		OutputFile: output,
+		ClientAuth: n.ClientAuthRequired,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit())
+	if sess.ExitCode() != 0 {
+		return 0
+	}
+
+	configBlock := UnmarshalBlockFromFile(output)
+	return configFromBlock(configBlock).Sequence
+}
+
+// WaitUntilConfigBlockReceived waits until each of the peers has committed a
+// config block for the channel with a config sequence of at least sequence.
+// This should be used after a config update when subsequent interactions
+// depend on the updated config being in effect on peers other than the
+// submitter.
+func WaitUntilConfigBlockReceived(n *Network, channel string, sequence uint64, peers ...*Peer) {
+	for _, peer := range peers {
+		Eventually(func() uint64 {
+			return CurrentConfigSequenceFromPeer(n, peer, channel)
+		}, n.EventuallyTimeout).Should(BeNumerically(">=", sequence), "config sequence of peer %s", peer.ID())
+	}
+}
+
 // FetchConfigBlock fetches latest config block.
 func FetchConfigBlock(n *Network, peer *Peer, orderer *Orderer, channel string, output string) {
 	fetch := func() int {
@@ -196,6 +295,34 @@ func FetchConfigBlock(n *Network, peer *Peer, orderer *Orderer, channel string,
 	Eventually(fetch, n.EventuallyTimeout).Should(Equal(0))
 }
 
+// BlockTxCounts fetches the blocks fromBlock through toBlock, inclusive, of
+// the channel from the orderer and returns the number of transactions in each
+// block.
+func BlockTxCounts(n *Network, peer *Peer, orderer *Orderer, channel string, fromBlock, toBlock uint64) []int {
+	tempDir, err := ioutil.TempDir(n.RootDir, "blockTxCounts")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	var counts []int
+	for blockNum := fromBlock; blockNum <= toBlock; blockNum++ {
+		output := filepath.Join(tempDir, fmt.Sprintf("block_%d.pb", blockNum))
+		sess, err := n.OrdererAdminSession(orderer, peer, commands.ChannelFetch{
+			ChannelID:  channel,
+			Block:      strconv.FormatUint(blockNum, 10),
+			Orderer:    n.OrdererAddress(orderer, ListenPort),
+			OutputFile: output,
+			ClientAuth: n.ClientAuthRequired,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+		Expect(sess.Err).To(gbytes.Say("Received block: "))
+
+		block := UnmarshalBlockFromFile(output)
+		counts = append(counts, len(block.Data.Data))
+	}
+	return counts
+}
+
 // UpdateOrdererConfig computes, signs, and submits a configuration update
 // which requires orderers signature and waits for the update to complete.
 func UpdateOrdererConfig(n *Network, orderer *Orderer, channel string, current, updated *common.Config, submitter *Peer, additionalSigners ...*Orderer) {
@@ -246,7 +373,15 @@ func UpdateOrdererConfigSession(n *Network, orderer *Orderer, channel string, cu
 		File:       updateFile,
 		ClientAuth: n.ClientAuthRequired,
 	})
+	if err != nil {
+		os.RemoveAll(tempDir)
+	}
 	Expect(err).NotTo(HaveOccurred())
+
+	go func() {
+		<-sess.Exited
+		os.RemoveAll(tempDir)
+	}()
 	return sess
 }
 
@@ -300,17 +435,128 @@ func UpdateConsensusMetadata(network *Network, peer *Peer, orderer *Orderer, cha
 	config := GetConfig(network, peer, orderer, channel)
 	updatedConfig := proto.Clone(config).(*common.Config)
 
-	consensusTypeConfigValue := updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
+	consensusTypeValue := ConsensusTypeFromConfig(updatedConfig)
+	consensusTypeValue.Metadata = mutateMetadata(consensusTypeValue.Metadata)
+
+	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     protoutil.MarshalOrPanic(consensusTypeValue),
+	}
+
+	UpdateOrdererConfig(network, orderer, channel, config, updatedConfig, peer, orderer)
+}
+
+// ConsensusTypeFromConfig returns the orderer's ConsensusType value recorded
+// in the config.
+func ConsensusTypeFromConfig(config *common.Config) *protosorderer.ConsensusType {
+	consensusTypeConfigValue := config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
+	Expect(consensusTypeConfigValue).NotTo(BeNil())
+
 	consensusTypeValue := &protosorderer.ConsensusType{}
 	err := proto.Unmarshal(consensusTypeConfigValue.Value, consensusTypeValue)
 	Expect(err).NotTo(HaveOccurred())
+	return consensusTypeValue
+}
 
-	consensusTypeValue.Metadata = mutateMetadata(consensusTypeValue.Metadata)
+// ConsensusType returns the orderer type of the channel, such as "solo",
+// "kafka", or "etcdraft", as recorded in the latest channel config.
+func ConsensusType(n *Network, peer *Peer, orderer *Orderer, channel string) string {
+	return ConsensusTypeFromConfig(GetConfig(n, peer, orderer, channel)).Type
+}
 
-	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+// ConsensusState returns the state of the channel's consensus, which is
+// STATE_MAINTENANCE while a consensus type migration is in progress.
+func ConsensusState(n *Network, peer *Peer, orderer *Orderer, channel string) protosorderer.ConsensusType_State {
+	return ConsensusTypeFromConfig(GetConfig(n, peer, orderer, channel)).State
+}
+
+// UpdateAnchorPeers executes a config update that sets the anchor peers of the
+// organization on the channel. The update is submitted by the admin of the
+// first peer of the organization. The anchor flags of the organization's
+// peers in the network topology are updated to match.
+func UpdateAnchorPeers(n *Network, orderer *Orderer, channel, orgName string, anchors ...*Peer) {
+	orgPeers := n.PeersInOrg(orgName)
+	Expect(orgPeers).NotTo(BeEmpty(), "organization %s has no peers", orgName)
+	submitter := orgPeers[0]
+
+	config := GetConfig(n, submitter, orderer, channel)
+	updatedConfig := proto.Clone(config).(*common.Config)
+	orgGroup := updatedConfig.ChannelGroup.Groups["Application"].Groups[orgName]
+	Expect(orgGroup).NotTo(BeNil(), "organization %s is not a member of channel %s", orgName, channel)
+
+	anchorPeers := &pb.AnchorPeers{}
+	for _, p := range anchors {
+		Expect(p.Organization).To(Equal(orgName))
+		anchorPeers.AnchorPeers = append(anchorPeers.AnchorPeers, &pb.AnchorPeer{
+			Host: "127.0.0.1",
+			Port: int32(n.PeerPort(p, ListenPort)),
+		})
+	}
+	orgGroup.Values["AnchorPeers"] = &common.ConfigValue{
 		ModPolicy: "Admins",
-		Value:     protoutil.MarshalOrPanic(consensusTypeValue),
+		Value:     protoutil.MarshalOrPanic(anchorPeers),
+	}
+
+	UpdateConfig(n, orderer, channel, config, updatedConfig, false, submitter)
+
+	for _, p := range orgPeers {
+		for _, pc := range p.Channels {
+			if pc.Name != channel {
+				continue
+			}
+			pc.Anchor = false
+			for _, a := range anchors {
+				if a == p {
+					pc.Anchor = true
+				}
+			}
+		}
 	}
+}
+
+// AnchorPeersForChannel returns the anchor peers of the organization from the
+// current config of the channel.
+func AnchorPeersForChannel(n *Network, peer *Peer, orderer *Orderer, channel, orgName string) []*pb.AnchorPeer {
+	config := GetConfig(n, peer, orderer, channel)
+	orgGroup := config.ChannelGroup.Groups["Application"].Groups[orgName]
+	Expect(orgGroup).NotTo(BeNil(), "organization %s is not a member of channel %s", orgName, channel)
+
+	value, ok := orgGroup.Values["AnchorPeers"]
+	if !ok {
+		return nil
+	}
+	anchorPeers := &pb.AnchorPeers{}
+	err := proto.Unmarshal(value.Value, anchorPeers)
+	Expect(err).NotTo(HaveOccurred())
+	return anchorPeers.AnchorPeers
+}
+
+// BatchConfigMutator receives the orderer BatchSize and BatchTimeout and
+// mutates them.
+type BatchConfigMutator func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration)
+
+// UpdateOrdererBatchConfig executes a config update that updates the orderer
+// BatchSize and BatchTimeout according to the given BatchConfigMutator.
+func UpdateOrdererBatchConfig(network *Network, peer *Peer, orderer *Orderer, channel string, mutateBatchConfig BatchConfigMutator) {
+	config := GetConfig(network, peer, orderer, channel)
+	updatedConfig := proto.Clone(config).(*common.Config)
+	ordererValues := updatedConfig.ChannelGroup.Groups["Orderer"].Values
+
+	batchSizeValue := &protosorderer.BatchSize{}
+	err := proto.Unmarshal(ordererValues["BatchSize"].Value, batchSizeValue)
+	Expect(err).NotTo(HaveOccurred())
+
+	batchTimeoutValue := &protosorderer.BatchTimeout{}
+	err = proto.Unmarshal(ordererValues["BatchTimeout"].Value, batchTimeoutValue)
+	Expect(err).NotTo(HaveOccurred())
+	batchTimeout, err := time.ParseDuration(batchTimeoutValue.Timeout)
+	Expect(err).NotTo(HaveOccurred())
+
+	mutateBatchConfig(batchSizeValue, &batchTimeout)
+	batchTimeoutValue.Timeout = batchTimeout.String()
+
+	ordererValues["BatchSize"].Value = protoutil.MarshalOrPanic(batchSizeValue)
+	ordererValues["BatchTimeout"].Value = protoutil.MarshalOrPanic(batchTimeoutValue)
 
 	UpdateOrdererConfig(network, orderer, channel, config, updatedConfig, peer, orderer)
 }
@@ -338,3 +584,54 @@ func UpdateOrdererMSP(network *Network, peer *Peer, orderer *Orderer, channel, o
 
 	UpdateOrdererConfig(network, orderer, channel, config, updatedConfig, peer, orderer)
 }
+
+// AddConsortiumOrg executes a system channel config update that adds an
+// organization of the network to the consortium. The organization definition
+// is generated by configtxgen from the network's configtx.yaml, so the
+// organization's crypto material must already exist. The update is signed by
+// the orderer's admin.
+func AddConsortiumOrg(n *Network, orderer *Orderer, consortium, orgName string) {
+	submitter := n.Peers[0]
+	config := GetConfig(n, submitter, orderer, n.SystemChannel.Name)
+	updatedConfig := proto.Clone(config).(*common.Config)
+
+	consortiumGroup := updatedConfig.ChannelGroup.Groups["Consortiums"].Groups[consortium]
+	Expect(consortiumGroup).NotTo(BeNil(), "consortium %s does not exist", consortium)
+	Expect(consortiumGroup.Groups).NotTo(HaveKey(orgName), "organization %s is already a member of consortium %s", orgName, consortium)
+
+	sess, err := n.ConfigTxGen(commands.PrintOrg{
+		ConfigPath: n.RootDir,
+		PrintOrg:   orgName,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	orgGroup := &ordererext.DynamicOrdererOrgGroup{ConfigGroup: &common.ConfigGroup{}}
+	err = protolator.DeepUnmarshalJSON(bytes.NewBuffer(sess.Out.Contents()), orgGroup)
+	Expect(err).NotTo(HaveOccurred())
+	consortiumGroup.Groups[orgName] = orgGroup.ConfigGroup
+
+	UpdateOrdererConfig(n, orderer, n.SystemChannel.Name, config, updatedConfig, submitter, orderer)
+
+	for _, c := range n.Consortiums {
+		if c.Name == consortium {
+			c.Organizations = append(c.Organizations, orgName)
+		}
+	}
+}
+
+// SetMaxChannels executes a system channel config update that limits the
+// number of channels the ordering service will create. The system channel is
+// not counted against the limit and a limit of zero removes the restriction.
+// The update is signed by the orderer's admin.
+func SetMaxChannels(n *Network, orderer *Orderer, maxChannels uint64) {
+	submitter := n.Peers[0]
+	config := GetConfig(n, submitter, orderer, n.SystemChannel.Name)
+	updatedConfig := proto.Clone(config).(*common.Config)
+
+	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ChannelRestrictions"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     protoutil.MarshalOrPanic(&protosorderer.ChannelRestrictions{MaxCount: maxChannels}),
+	}
+
+	UpdateOrdererConfig(n, orderer, n.SystemChannel.Name, config, updatedConfig, submitter, orderer)
+}
@@ -159,9 +159,19 @@ Profiles:{{ range .Profiles }}
       {{- end }}
       {{- if eq $w.Consensus.Type "etcdraft" }}
       EtcdRaft:
-        Options:
-          TickInterval: 500ms
-          SnapshotIntervalSize: 1 KB
+        Options:{{ with $w.EtcdRaftOptions }}
+          TickInterval: {{ .TickInterval }}
+          SnapshotIntervalSize: {{ .SnapshotIntervalSize }}
+          {{- if .ElectionTick }}
+          ElectionTick: {{ .ElectionTick }}
+          {{- end }}
+          {{- if .HeartbeatTick }}
+          HeartbeatTick: {{ .HeartbeatTick }}
+          {{- end }}
+          {{- if .MaxInflightBlocks }}
+          MaxInflightBlocks: {{ .MaxInflightBlocks }}
+          {{- end }}
+          {{- end }}
         Consenters:{{ range .Orderers }}{{ with $w.Orderer . }}
         - Host: 127.0.0.1
           Port: {{ $w.OrdererPort . "Cluster" }}
@@ -0,0 +1,216 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"crypto/sha256"
+	"fmt"
+	"io"
+	"io/ioutil"
+	"net"
+	"os"
+	"regexp"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	docker "github.com/fsouza/go-dockerclient"
+	. "github.com/onsi/gomega"
+)
+
+// AssertNoChaincodeContainer asserts that no docker container exists for the
+// chaincode package on any peer of the network. This is used to verify that
+// chaincode launched by an external builder or as an external service does
+// not fall back to a docker container.
+func AssertNoChaincodeContainer(client *docker.Client, n *Network, chaincode Chaincode) {
+	containers, err := client.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"name": {ChaincodeContainerNameFilter(n, chaincode)},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Expect(containers).To(BeEmpty(), "found chaincode containers for %s", chaincode.Label)
+}
+
+// WaitForChaincodeContainer waits until at least one container launched for
+// the chaincode package is running and returns the running containers.
+func WaitForChaincodeContainer(client *docker.Client, n *Network, chaincode Chaincode, timeout time.Duration) []docker.APIContainers {
+	listOptions := docker.ListContainersOptions{
+		Filters: map[string][]string{
+			"name":   {ChaincodeContainerNameFilter(n, chaincode)},
+			"status": {"running"},
+		},
+	}
+
+	var containers []docker.APIContainers
+	Eventually(func() ([]docker.APIContainers, error) {
+		var err error
+		containers, err = client.ListContainers(listOptions)
+		return containers, err
+	}, timeout).ShouldNot(BeEmpty(), "no running chaincode containers for %s", chaincode.Label)
+
+	return containers
+}
+
+// ChaincodeContainerNameFilter returns a docker name filter that matches the
+// containers launched for the chaincode package by the peers of the network.
+// Container names are composed of the network ID, the peer ID, the package
+// label, and the hash of the package file.
+func ChaincodeContainerNameFilter(n *Network, chaincode Chaincode) string {
+	return fmt.Sprintf("^/%s-.*-%s-%s$", n.NetworkID, chaincode.Label, HashFile(chaincode.PackageFile))
+}
+
+// ChaincodeContainersForPeer returns the running chaincode containers that
+// were launched by the peer, regardless of the chaincode package.
+func ChaincodeContainersForPeer(client *docker.Client, n *Network, p *Peer) []docker.APIContainers {
+	containers, err := client.ListContainers(docker.ListContainersOptions{
+		Filters: map[string][]string{
+			"name":   {fmt.Sprintf("^/%s-", regexp.QuoteMeta(n.NetworkID+"-"+p.ID()))},
+			"status": {"running"},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+	return containers
+}
+
+// KillChaincodeContainersForPeer kills the running chaincode containers that
+// were launched by the peer and returns their IDs. The peer launches a new
+// container the next time the chaincode is needed while the containers of the
+// other peers are unaffected.
+func KillChaincodeContainersForPeer(client *docker.Client, n *Network, p *Peer) []string {
+	var killed []string
+	for _, c := range ChaincodeContainersForPeer(client, n, p) {
+		err := client.KillContainer(docker.KillContainerOptions{ID: c.ID})
+		Expect(err).NotTo(HaveOccurred())
+		killed = append(killed, c.ID)
+	}
+	return killed
+}
+
+// ChaincodeImagesForNetwork returns the sorted tags of the chaincode images
+// built by the peers of the network. Image names are composed of the network
+// ID, the peer ID, and the chaincode package ID, so each peer that builds a
+// package contributes a single image and rebuilding a package does not add
+// images.
+func ChaincodeImagesForNetwork(client *docker.Client, n *Network) []string {
+	images, err := client.ListImages(docker.ListImagesOptions{All: true})
+	Expect(err).NotTo(HaveOccurred())
+
+	var tags []string
+	for _, i := range images {
+		for _, tag := range i.RepoTags {
+			if strings.HasPrefix(tag, n.NetworkID+"-") {
+				tags = append(tags, tag)
+			}
+		}
+	}
+	sort.Strings(tags)
+	return tags
+}
+
+// removeChaincodeContainers removes the containers launched by the peer for
+// the chaincode package.
+func removeChaincodeContainers(client *docker.Client, n *Network, p *Peer, chaincode Chaincode) {
+	name := fmt.Sprintf("%s-%s-%s-%s", n.NetworkID, p.ID(), chaincode.Label, HashFile(chaincode.PackageFile))
+	containers, err := client.ListContainers(docker.ListContainersOptions{
+		All: true,
+		Filters: map[string][]string{
+			"name": {fmt.Sprintf("^/%s$", regexp.QuoteMeta(name))},
+		},
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	for _, c := range containers {
+		err := client.RemoveContainer(docker.RemoveContainerOptions{ID: c.ID, Force: true})
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+// OrgDockerNetworkName returns the name of the docker network created by
+// CreateOrgDockerNetwork for the organization.
+func (n *Network) OrgDockerNetworkName(orgName string) string {
+	return fmt.Sprintf("%s-%s", n.NetworkID, strings.ToLower(orgName))
+}
+
+// CreateOrgDockerNetwork creates an isolated bridge network for the
+// organization and configures each of its peers to attach the chaincode
+// containers it launches to that network. The chaincode reaches the peer
+// through the gateway of the network. Each peer reads the network mode from
+// vm.docker.hostConfig.NetworkMode in its own core config, so the setting
+// applies to that peer only. It must be called before the peers start and
+// the network is removed by Cleanup.
+func (n *Network) CreateOrgDockerNetwork(orgName string) {
+	Expect(n.Organization(orgName)).NotTo(BeNil(), "organization %s not found", orgName)
+
+	nw, err := n.DockerClient.CreateNetwork(docker.CreateNetworkOptions{
+		Name:   n.OrgDockerNetworkName(orgName),
+		Driver: "bridge",
+	})
+	Expect(err).NotTo(HaveOccurred())
+	n.dockerNetworks = append(n.dockerNetworks, nw.ID)
+	nw, err = n.DockerClient.NetworkInfo(nw.ID)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(nw.IPAM.Config).NotTo(BeEmpty(), "no address pool for docker network %s", nw.Name)
+	gateway := nw.IPAM.Config[0].Gateway
+
+	for _, p := range n.PeersInOrg(orgName) {
+		core := n.ReadPeerConfig(p)
+		core.VM.Docker.HostConfig.NetworkMode = nw.Name
+		core.Peer.ChaincodeAddress = net.JoinHostPort(gateway, strconv.Itoa(int(n.PeerPort(p, ChaincodePort))))
+		n.WritePeerConfig(p, core)
+	}
+}
+
+// SetChaincodeSeccompProfile configures every peer to apply the seccomp
+// profile in the file to the chaincode containers it launches. The docker
+// API expects the content of the profile rather than its path, so the file
+// is read when the helper is called. It must be called before the peers
+// start.
+func (n *Network) SetChaincodeSeccompProfile(profilePath string) {
+	profile, err := ioutil.ReadFile(profilePath)
+	Expect(err).NotTo(HaveOccurred())
+	n.SetChaincodeSecurityOpt("seccomp=" + string(profile))
+}
+
+// SetChaincodeSecurityOpt configures every peer to apply the docker security
+// options, such as "no-new-privileges" or "apparmor=<profile>", to the
+// chaincode containers it launches. The options replace those previously
+// configured.
+func (n *Network) SetChaincodeSecurityOpt(opts ...string) {
+	for _, p := range n.Peers {
+		core := n.ReadPeerConfig(p)
+		core.VM.Docker.HostConfig.SecurityOpt = opts
+		n.WritePeerConfig(p, core)
+	}
+}
+
+// removeOrgDockerNetworks removes the docker networks created by
+// CreateOrgDockerNetwork. The chaincode containers attached to them must have
+// been removed.
+func (n *Network) removeOrgDockerNetworks() {
+	for _, id := range n.dockerNetworks {
+		err := n.DockerClient.RemoveNetwork(id)
+		Expect(err).NotTo(HaveOccurred())
+	}
+	n.dockerNetworks = nil
+}
+
+// HashFile returns the hex encoded SHA256 hash of the file contents. This is
+// the hash used in chaincode package IDs.
+func HashFile(file string) string {
+	f, err := os.Open(file)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	h := sha256.New()
+	_, err = io.Copy(h, f)
+	Expect(err).NotTo(HaveOccurred())
+
+	return fmt.Sprintf("%x", h.Sum(nil))
+}
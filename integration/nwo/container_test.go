@@ -0,0 +1,60 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo_test
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+
+	"github.com/hyperledger/fabric/integration/nwo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("Chaincode containers", func() {
+	var (
+		tempDir     string
+		packageFile string
+	)
+
+	BeforeEach(func() {
+		var err error
+		tempDir, err = ioutil.TempDir("", "nwo")
+		Expect(err).NotTo(HaveOccurred())
+
+		packageFile = filepath.Join(tempDir, "package.tar.gz")
+		err = ioutil.WriteFile(packageFile, []byte("chaincode-package"), 0644)
+		Expect(err).NotTo(HaveOccurred())
+	})
+
+	AfterEach(func() {
+		os.RemoveAll(tempDir)
+	})
+
+	Describe("HashFile", func() {
+		It("returns the hex encoded sha256 hash of the file", func() {
+			Expect(nwo.HashFile(packageFile)).To(Equal("cf530d320297d5c5e9fec9937adba580f20dc46a19fdb9418283221692334a03"))
+		})
+	})
+
+	Describe("ChaincodeContainerNameFilter", func() {
+		It("matches container names built from the network id, label, and package hash", func() {
+			network := &nwo.Network{NetworkID: "network-id"}
+			chaincode := nwo.Chaincode{Label: "my-label", PackageFile: packageFile}
+
+			filter := nwo.ChaincodeContainerNameFilter(network, chaincode)
+			Expect(filter).To(Equal("^/network-id-.*-my-label-" + nwo.HashFile(packageFile) + "$"))
+
+			re := regexp.MustCompile(filter)
+			Expect(re.MatchString("/network-id-peer0.org1.example.com-my-label-" + nwo.HashFile(packageFile))).To(BeTrue())
+			Expect(re.MatchString("/other-network-peer0.org1.example.com-my-label-" + nwo.HashFile(packageFile))).To(BeFalse())
+			Expect(re.MatchString("/network-id-peer0.org1.example.com-other-label-" + nwo.HashFile(packageFile))).To(BeFalse())
+		})
+	})
+})
@@ -117,7 +117,7 @@ peer:
   localMspType: bccsp
   profile:
     enabled:     false
-    listenAddress: 127.0.0.1:{{ .PeerPort Peer "ProfilePort" }}
+    listenAddress: 127.0.0.1:{{ .PeerPort Peer "Profile" }}
   handlers:
     authFilters:
     - name: DefaultAuth
@@ -169,13 +169,14 @@ chaincode:
   golang:
     runtime: $(DOCKER_NS)/fabric-baseos:$(PROJECT_VERSION)
     dynamicLink: false
+    offline: false
   java:
     runtime: $(DOCKER_NS)/fabric-javaenv:latest
   node:
     runtime: $(DOCKER_NS)/fabric-nodeenv:latest
   installTimeout: 300s
-  startuptimeout: 300s
-  executetimeout: 30s
+  startupTimeout: 300s
+  executeTimeout: 30s
   mode: net
   keepalive: 0
   system:
@@ -213,9 +214,9 @@ ledger:
     enableHistoryDatabase: true
 
 operations:
-  listenAddress: 127.0.0.1:{{ .PeerPort Peer "Operations" }}
+  listenAddress: {{ .PeerOperationsAddress Peer }}
   tls:
-    enabled: true
+    enabled: {{ .PeerOperationsTLS Peer }}
     cert:
       file: {{ .PeerLocalTLSDir Peer }}/server.crt
     key:
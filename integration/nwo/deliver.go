@@ -0,0 +1,222 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"context"
+	"math"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/cmd/common/signer"
+	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	"github.com/hyperledger/fabric/internal/pkg/comm"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"google.golang.org/grpc"
+)
+
+// A BlockSubscriber streams the blocks of a channel from the Deliver service
+// of a peer or an orderer. When the stream fails, for example because the
+// peer restarted, the subscriber reconnects and resumes after the last block
+// it delivered, so every block is delivered exactly once and in order.
+type BlockSubscriber struct {
+	network *Network
+	address string
+	channel string
+	client  *comm.GRPCClient
+	signer  *signer.Signer
+	orderer bool
+
+	blocks chan *common.Block
+	stop   chan struct{}
+	done   chan struct{}
+	once   sync.Once
+}
+
+// NewBlockSubscriber starts streaming the channel's blocks, beginning with
+// startBlock, from the peer as User1 of the peer's organization. Stop must be
+// called to release the subscriber.
+func NewBlockSubscriber(n *Network, p *Peer, channel string, startBlock uint64) *BlockSubscriber {
+	s := &BlockSubscriber{
+		network: n,
+		address: n.PeerAddress(p, ListenPort),
+		channel: channel,
+		client:  grpcClient(n, n.PeerLocalTLSDir(p)),
+		signer:  SignerForUser(n, p.Organization, "User1"),
+		blocks:  make(chan *common.Block),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run(startBlock)
+	return s
+}
+
+// NewOrdererBlockSubscriber starts streaming the channel's blocks, beginning
+// with startBlock, from the orderer as the Admin of the orderer's
+// organization. Blocks are delivered as soon as the orderer has written them,
+// before any peer has committed them. Stop must be called to release the
+// subscriber.
+func NewOrdererBlockSubscriber(n *Network, o *Orderer, channel string, startBlock uint64) *BlockSubscriber {
+	s := &BlockSubscriber{
+		network: n,
+		address: n.OrdererAddress(o, ListenPort),
+		channel: channel,
+		client:  grpcClient(n, n.OrdererLocalTLSDir(o)),
+		signer:  SignerForUser(n, o.Organization, "Admin"),
+		orderer: true,
+		blocks:  make(chan *common.Block),
+		stop:    make(chan struct{}),
+		done:    make(chan struct{}),
+	}
+	go s.run(startBlock)
+	return s
+}
+
+// Blocks returns the channel on which blocks are delivered. It is closed
+// after the subscriber stops.
+func (s *BlockSubscriber) Blocks() <-chan *common.Block {
+	return s.blocks
+}
+
+// Stop stops the subscriber and waits for it to disconnect from the peer.
+func (s *BlockSubscriber) Stop() {
+	s.once.Do(func() { close(s.stop) })
+	<-s.done
+}
+
+func (s *BlockSubscriber) run(next uint64) {
+	defer ginkgo.GinkgoRecover()
+	defer close(s.done)
+	defer close(s.blocks)
+
+	for {
+		next = s.deliver(next)
+		select {
+		case <-s.stop:
+			return
+		case <-time.After(500 * time.Millisecond):
+		}
+	}
+}
+
+// deliver streams blocks, starting with next, until the stream fails or the
+// subscriber is stopped and returns the number of the next block to request.
+func (s *BlockSubscriber) deliver(next uint64) uint64 {
+	ctx, cancel := context.WithCancel(context.Background())
+	defer cancel()
+	go func() {
+		select {
+		case <-s.stop:
+			cancel()
+		case <-ctx.Done():
+		}
+	}()
+
+	conn, err := s.client.NewConnection(s.address)
+	if err != nil {
+		return next
+	}
+	defer conn.Close()
+
+	send, recv, err := s.openStream(ctx, conn)
+	if err != nil {
+		return next
+	}
+	if err := send(s.seekEnvelope(next)); err != nil {
+		return next
+	}
+
+	for {
+		block, err := recv()
+		if err != nil {
+			return next
+		}
+		if block == nil {
+			// a status response ends the stream
+			return next
+		}
+
+		select {
+		case s.blocks <- block:
+			next = block.Header.Number + 1
+		case <-s.stop:
+			return next
+		}
+	}
+}
+
+// openStream opens a Deliver stream to the peer or orderer and returns
+// functions to send seek requests on it and to receive the delivered blocks.
+// The received block is nil when the stream ends with a status response.
+func (s *BlockSubscriber) openStream(ctx context.Context, conn *grpc.ClientConn) (func(*common.Envelope) error, func() (*common.Block, error), error) {
+	if s.orderer {
+		stream, err := orderer.NewAtomicBroadcastClient(conn).Deliver(ctx)
+		if err != nil {
+			return nil, nil, err
+		}
+		recv := func() (*common.Block, error) {
+			resp, err := stream.Recv()
+			return resp.GetBlock(), err
+		}
+		return stream.Send, recv, nil
+	}
+
+	stream, err := pb.NewDeliverClient(conn).Deliver(ctx)
+	if err != nil {
+		return nil, nil, err
+	}
+	recv := func() (*common.Block, error) {
+		resp, err := stream.Recv()
+		return resp.GetBlock(), err
+	}
+	return stream.Send, recv, nil
+}
+
+func (s *BlockSubscriber) seekEnvelope(start uint64) *common.Envelope {
+	var tlsCertHash []byte
+	if s.network.ClientAuthRequired {
+		tlsCertHash = util.ComputeSHA256(s.client.Certificate().Certificate[0])
+	}
+
+	env, err := protoutil.CreateSignedEnvelopeWithTLSBinding(
+		common.HeaderType_DELIVER_SEEK_INFO,
+		s.channel,
+		s.signer,
+		&orderer.SeekInfo{
+			Start: &orderer.SeekPosition{
+				Type: &orderer.SeekPosition_Specified{Specified: &orderer.SeekSpecified{Number: start}},
+			},
+			Stop: &orderer.SeekPosition{
+				Type: &orderer.SeekPosition_Specified{Specified: &orderer.SeekSpecified{Number: math.MaxUint64}},
+			},
+			Behavior: orderer.SeekInfo_BLOCK_UNTIL_READY,
+		},
+		0,
+		0,
+		tlsCertHash,
+	)
+	Expect(err).NotTo(HaveOccurred())
+	return env
+}
+
+// SetPeerTLSClientCertificate sets the TLS certificate and key that the peer
+// presents when it connects to other components as a client. When client
+// authentication is required, this is the certificate the Deliver service of
+// the orderers uses to authenticate the peer. The change takes effect the next
+// time the peer starts.
+func (n *Network) SetPeerTLSClientCertificate(p *Peer, certFile, keyFile string) {
+	core := n.ReadPeerConfig(p)
+	core.Peer.TLS.ClientCert = &fabricconfig.FileRef{File: certFile}
+	core.Peer.TLS.ClientKey = &fabricconfig.FileRef{File: keyFile}
+	n.WritePeerConfig(p, core)
+}
@@ -0,0 +1,218 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"io"
+	"net"
+	"path/filepath"
+	"sync"
+	"sync/atomic"
+
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	. "github.com/onsi/gomega"
+)
+
+// deliverProxy relays the connections a peer opens to an orderer to receive
+// blocks so that block reception can be paused and measured. The proxy does
+// not terminate TLS; the peer and the orderer negotiate it through the relay.
+type deliverProxy struct {
+	backend  string
+	listener net.Listener
+	received int64 // accessed atomically
+
+	mutex  sync.Mutex
+	paused bool
+	conns  map[net.Conn]struct{}
+}
+
+// EnableDeliverProxy places a proxy between the peer and each orderer of the
+// network. The peer is configured with delivery client address overrides so
+// it connects to the proxies instead of the orderers when pulling blocks;
+// other clients of the orderers are unaffected. It must be called after the
+// configuration tree has been generated and before the peer is started. The
+// proxies are closed by Cleanup.
+func (n *Network) EnableDeliverProxy(p *Peer) {
+	if n.deliverProxies == nil {
+		n.deliverProxies = map[string][]*deliverProxy{}
+	}
+	if _, ok := n.deliverProxies[p.ID()]; ok {
+		return
+	}
+
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Deliveryclient == nil {
+		core.Peer.Deliveryclient = &fabricconfig.DeliveryClient{}
+	}
+	for _, o := range n.Orderers {
+		proxy := startDeliverProxy(n.OrdererAddress(o, ListenPort))
+		n.deliverProxies[p.ID()] = append(n.deliverProxies[p.ID()], proxy)
+		core.Peer.Deliveryclient.AddressOverrides = append(core.Peer.Deliveryclient.AddressOverrides, &fabricconfig.AddressOverride{
+			From:        n.OrdererAddress(o, ListenPort),
+			To:          proxy.listener.Addr().String(),
+			CACertsFile: filepath.Join(n.OrdererLocalTLSDir(o), "ca.crt"),
+		})
+	}
+	n.WritePeerConfig(p, core)
+}
+
+// PauseBlockDelivery stops the peer from receiving blocks from the ordering
+// service. Its connections to the orderers are closed and new connections
+// are refused until ResumeBlockDelivery is called. Gossip between peers is
+// unaffected, so the peer falls behind the chain unless it receives the
+// missing blocks from other peers. EnableDeliverProxy must have been called
+// for the peer.
+func PauseBlockDelivery(n *Network, p *Peer) {
+	proxies := n.deliverProxies[p.ID()]
+	Expect(proxies).NotTo(BeEmpty(), "deliver proxy is not enabled for %s", p.ID())
+	for _, proxy := range proxies {
+		proxy.pause()
+	}
+}
+
+// ResumeBlockDelivery allows the peer to receive blocks from the ordering
+// service again after PauseBlockDelivery. The peer reconnects on its own and
+// resumes from the height of its ledger.
+func ResumeBlockDelivery(n *Network, p *Peer) {
+	proxies := n.deliverProxies[p.ID()]
+	Expect(proxies).NotTo(BeEmpty(), "deliver proxy is not enabled for %s", p.ID())
+	for _, proxy := range proxies {
+		proxy.resume()
+	}
+}
+
+// DeliveredBytes returns the number of bytes the peer has received from the
+// ordering service on its deliver connections, as seen on the wire. It
+// includes TLS framing. EnableDeliverProxy must have been called for the
+// peer.
+func DeliveredBytes(n *Network, p *Peer) int64 {
+	proxies := n.deliverProxies[p.ID()]
+	Expect(proxies).NotTo(BeEmpty(), "deliver proxy is not enabled for %s", p.ID())
+	var total int64
+	for _, proxy := range proxies {
+		total += atomic.LoadInt64(&proxy.received)
+	}
+	return total
+}
+
+func (n *Network) closeDeliverProxies() {
+	for id, proxies := range n.deliverProxies {
+		for _, proxy := range proxies {
+			proxy.close()
+		}
+		delete(n.deliverProxies, id)
+	}
+}
+
+func startDeliverProxy(backend string) *deliverProxy {
+	listener, err := net.Listen("tcp", "127.0.0.1:0")
+	Expect(err).NotTo(HaveOccurred())
+
+	proxy := &deliverProxy{
+		backend:  backend,
+		listener: listener,
+		conns:    map[net.Conn]struct{}{},
+	}
+	go proxy.serve()
+
+	return proxy
+}
+
+func (p *deliverProxy) serve() {
+	for {
+		conn, err := p.listener.Accept()
+		if err != nil {
+			return
+		}
+		go p.relay(conn)
+	}
+}
+
+func (p *deliverProxy) relay(conn net.Conn) {
+	defer conn.Close()
+
+	if !p.track(conn) {
+		return
+	}
+	defer p.untrack(conn)
+
+	backend, err := net.Dial("tcp", p.backend)
+	if err != nil {
+		return
+	}
+	defer backend.Close()
+
+	if !p.track(backend) {
+		return
+	}
+	defer p.untrack(backend)
+
+	done := make(chan struct{}, 2)
+	go func() {
+		io.Copy(backend, conn)
+		done <- struct{}{}
+	}()
+	go func() {
+		io.Copy(&countingWriter{Writer: conn, count: &p.received}, backend)
+		done <- struct{}{}
+	}()
+	<-done
+}
+
+func (p *deliverProxy) track(conn net.Conn) bool {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	if p.paused {
+		return false
+	}
+	p.conns[conn] = struct{}{}
+	return true
+}
+
+func (p *deliverProxy) untrack(conn net.Conn) {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	delete(p.conns, conn)
+}
+
+func (p *deliverProxy) pause() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+
+	p.paused = true
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+func (p *deliverProxy) resume() {
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	p.paused = false
+}
+
+func (p *deliverProxy) close() {
+	p.listener.Close()
+
+	p.mutex.Lock()
+	defer p.mutex.Unlock()
+	for conn := range p.conns {
+		conn.Close()
+	}
+}
+
+type countingWriter struct {
+	io.Writer
+	count *int64
+}
+
+func (w *countingWriter) Write(b []byte) (int, error) {
+	n, err := w.Writer.Write(b)
+	atomic.AddInt64(w.count, int64(n))
+	return n, err
+}
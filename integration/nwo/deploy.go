@@ -12,6 +12,8 @@ import (
 	"io/ioutil"
 	"os"
 	"os/exec"
+	"path/filepath"
+	"sort"
 	"strconv"
 	"strings"
 
@@ -19,6 +21,7 @@ import (
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/peer/lifecycle"
 	"github.com/hyperledger/fabric/common/util"
+	"github.com/hyperledger/fabric/core/chaincode/persistence"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	"github.com/hyperledger/fabric/protoutil"
 	"github.com/onsi/ginkgo"
@@ -46,6 +49,7 @@ type Chaincode struct {
 	Label               string
 	SignaturePolicy     string
 	ChannelConfigPolicy string
+	ServerAddress       string // address of the chaincode server; only used when Lang is "ccaas"
 }
 
 func (c *Chaincode) SetPackageIDFromPackageFile() {
@@ -84,6 +88,47 @@ func DeployChaincode(n *Network, channel string, orderer *Orderer, chaincode Cha
 	}
 }
 
+// DeployChaincodeOnChannel is a helper that deploys the chaincode on the
+// channel like DeployChaincode, but with the endorsement policy of the
+// definition replaced by policyOverride. This allows the same package to be
+// deployed on several channels, each enforcing its own policy. When
+// policyOverride is empty, the policy of the chaincode is used.
+func DeployChaincodeOnChannel(n *Network, channel string, orderer *Orderer, chaincode Chaincode, policyOverride string, peers ...*Peer) {
+	if policyOverride != "" {
+		chaincode.SignaturePolicy = policyOverride
+		chaincode.ChannelConfigPolicy = ""
+	}
+	DeployChaincode(n, channel, orderer, chaincode, peers...)
+}
+
+// DeployChaincodeNoInit is a helper that deploys the chaincode like
+// DeployChaincode, but with a definition that does not require the chaincode
+// to be initialized. The chaincode can be invoked as soon as the definition
+// is committed. It waits until each peer reports the committed definition
+// without the init requirement.
+func DeployChaincodeNoInit(n *Network, channel string, orderer *Orderer, chaincode Chaincode, peers ...*Peer) {
+	if len(peers) == 0 {
+		peers = n.PeersWithChannel(channel)
+	}
+	if len(peers) == 0 {
+		return
+	}
+
+	chaincode.InitRequired = false
+	DeployChaincode(n, channel, orderer, chaincode, peers...)
+
+	sequence, err := strconv.ParseInt(chaincode.Sequence, 10, 64)
+	Expect(err).NotTo(HaveOccurred())
+	for _, p := range peers {
+		Eventually(listCommitted(n, p, channel, chaincode.Name), n.EventuallyTimeout).Should(
+			MatchFields(IgnoreExtras, Fields{
+				"Sequence":     Equal(sequence),
+				"InitRequired": BeFalse(),
+			}),
+		)
+	}
+}
+
 // DeployChaincodeLegacy is a helper that will install chaincode to all peers
 // that are connected to the specified channel, instantiate the chaincode on
 // one of the peers, and wait for the instantiation to complete on all of the
@@ -136,6 +181,8 @@ func PackageAndInstallChaincode(n *Network, chaincode Chaincode, peers ...*Peer)
 		switch chaincode.Lang {
 		case "binary":
 			PackageChaincodeBinary(chaincode)
+		case "ccaas":
+			PackageChaincodeServer(chaincode)
 		default:
 			PackageChaincode(n, chaincode, peers[0])
 		}
@@ -309,6 +356,30 @@ func CommitChaincode(n *Network, channel string, orderer *Orderer, chaincode Cha
 	EnsureChaincodeCommitted(n, channel, chaincode.Name, chaincode.Version, chaincode.Sequence, checkOrgs, checkPeers...)
 }
 
+// IsCommitRequired returns whether the chaincode definition still needs to be
+// committed on the channel. A commit is not required once the peer reports a
+// committed definition with the chaincode's sequence and version; approving
+// that definition again does not require it to be committed again.
+func IsCommitRequired(n *Network, channel string, chaincode Chaincode, peer *Peer) bool {
+	sequence, err := strconv.ParseInt(chaincode.Sequence, 10, 64)
+	Expect(err).NotTo(HaveOccurred())
+
+	committed := listCommitted(n, peer, channel, chaincode.Name)()
+	return committed.Sequence != sequence || committed.Version != chaincode.Version
+}
+
+// CommitChaincodeIfRequired commits the chaincode definition like
+// CommitChaincode unless IsCommitRequired reports the definition has already
+// been committed, in which case it only waits for the definition to be
+// committed on the check peers.
+func CommitChaincodeIfRequired(n *Network, channel string, orderer *Orderer, chaincode Chaincode, peer *Peer, checkPeers ...*Peer) {
+	if IsCommitRequired(n, channel, chaincode, peer) {
+		CommitChaincode(n, channel, orderer, chaincode, peer, checkPeers...)
+		return
+	}
+	EnsureChaincodeCommitted(n, channel, chaincode.Name, chaincode.Version, chaincode.Sequence, nil, checkPeers...)
+}
+
 // EnsureChaincodeCommitted polls each supplied peer until the chaincode definition
 // has been committed to the peer's ledger.
 func EnsureChaincodeCommitted(n *Network, channel, name, version, sequence string, checkOrgs []*Organization, peers ...*Peer) {
@@ -453,6 +524,29 @@ type queryInstalledOutput struct {
 	InstalledChaincodes []lifecycle.QueryInstalledChaincodesResult_InstalledChaincode `json:"installed_chaincodes"`
 }
 
+// UninstallChaincode removes the chaincode package from each of the peers
+// along with the peer's containers for the package. The peer CLI cannot
+// uninstall chaincode, so the package is deleted from the peer's install
+// directory; the peers must be stopped while the package is removed and the
+// removal is visible once they are restarted.
+func UninstallChaincode(n *Network, chaincode Chaincode, peers ...*Peer) {
+	if chaincode.PackageID == "" {
+		chaincode.SetPackageIDFromPackageFile()
+	}
+
+	for _, p := range peers {
+		packageFile := filepath.Join(n.PeerChaincodeInstallDir(p), persistence.CCFileName(chaincode.PackageID))
+		Expect(packageFile).To(BeARegularFile(), "chaincode package %s is not installed on %s", chaincode.PackageID, p.ID())
+		Expect(os.Remove(packageFile)).To(Succeed())
+
+		if n.DockerClient != nil {
+			removeChaincodeContainers(n.DockerClient, n, p, chaincode)
+		}
+	}
+}
+
+// QueryInstalled returns a function that lists the chaincode packages
+// installed on the peer as reported by queryinstalled.
 func QueryInstalled(n *Network, peer *Peer) func() []lifecycle.QueryInstalledChaincodesResult_InstalledChaincode {
 	return func() []lifecycle.QueryInstalledChaincodesResult_InstalledChaincode {
 		sess, err := n.PeerAdminSession(peer, commands.ChaincodeQueryInstalled{
@@ -467,6 +561,21 @@ func QueryInstalled(n *Network, peer *Peer) func() []lifecycle.QueryInstalledCha
 	}
 }
 
+// PeersWithPackageInstalled returns the subset of peers that have the
+// chaincode package installed.
+func PeersWithPackageInstalled(n *Network, packageID string, peers ...*Peer) []*Peer {
+	var installed []*Peer
+	for _, p := range peers {
+		for _, cc := range QueryInstalled(n, p)() {
+			if cc.PackageId == packageID {
+				installed = append(installed, p)
+				break
+			}
+		}
+	}
+	return installed
+}
+
 type checkCommitReadinessOutput struct {
 	Approvals map[string]bool `json:"approvals"`
 }
@@ -524,9 +633,10 @@ func queryApproved(n *Network, peer *Peer, channel, name, sequence string) func(
 }
 
 type queryCommittedOutput struct {
-	Sequence  int64           `json:"sequence"`
-	Version   string          `json:"version"`
-	Approvals map[string]bool `json:"approvals"`
+	Sequence     int64           `json:"sequence"`
+	Version      string          `json:"version"`
+	InitRequired bool            `json:"init_required"`
+	Approvals    map[string]bool `json:"approvals"`
 }
 
 // listCommitted returns the result of the queryCommitted command.
@@ -567,13 +677,19 @@ func listInstantiatedLegacy(n *Network, peer *Peer, channel string) func() *gbyt
 // EnableCapabilities enables a specific capabilities flag for a running network.
 // It generates the config update using the first peer, signs the configuration
 // with the subsequent peers, and then submits the config update using the
-// first peer.
+// first peer. No config update is submitted if the capability is already
+// enabled.
 func EnableCapabilities(network *Network, channel, capabilitiesGroup, capabilitiesVersion string, orderer *Orderer, peers ...*Peer) {
 	if len(peers) == 0 {
 		return
 	}
 
 	config := GetConfig(network, peers[0], orderer, channel)
+	for _, c := range capabilitiesFromConfig(config, capabilitiesGroup) {
+		if c == capabilitiesVersion {
+			return
+		}
+	}
 	updatedConfig := proto.Clone(config).(*common.Config)
 
 	updatedConfig.ChannelGroup.Groups[capabilitiesGroup].Values["Capabilities"] = &common.ConfigValue{
@@ -590,6 +706,37 @@ func EnableCapabilities(network *Network, channel, capabilitiesGroup, capabiliti
 	UpdateConfig(network, orderer, channel, config, updatedConfig, false, peers[0], peers...)
 }
 
+// CurrentCapabilities returns the sorted names of the capabilities enabled
+// for the capabilities group (Channel, Orderer, or Application) of the
+// channel.
+func CurrentCapabilities(network *Network, channel, capabilitiesGroup string, orderer *Orderer, peer *Peer) []string {
+	config := GetConfig(network, peer, orderer, channel)
+	return capabilitiesFromConfig(config, capabilitiesGroup)
+}
+
+func capabilitiesFromConfig(config *common.Config, capabilitiesGroup string) []string {
+	group := config.ChannelGroup
+	if capabilitiesGroup != "Channel" {
+		group = config.ChannelGroup.Groups[capabilitiesGroup]
+		Expect(group).NotTo(BeNil(), "capabilities group %s not found", capabilitiesGroup)
+	}
+
+	value, ok := group.Values["Capabilities"]
+	if !ok {
+		return nil
+	}
+	capabilities := &common.Capabilities{}
+	err := proto.Unmarshal(value.Value, capabilities)
+	Expect(err).NotTo(HaveOccurred())
+
+	var names []string
+	for name := range capabilities.Capabilities {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names
+}
+
 // WaitUntilEqualLedgerHeight waits until all specified peers have the
 // provided ledger height on a channel
 func WaitUntilEqualLedgerHeight(n *Network, channel string, height int, peers ...*Peer) {
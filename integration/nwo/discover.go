@@ -9,7 +9,9 @@ package nwo
 import (
 	"encoding/json"
 	"path/filepath"
+	"sort"
 
+	"github.com/hyperledger/fabric-protos-go/discovery"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gexec"
@@ -49,3 +51,90 @@ func DiscoverPeers(n *Network, p *Peer, user, channelName string) func() []Disco
 		return discovered
 	}
 }
+
+// ChaincodeEndorsers defines the endorsement descriptor returned by the
+// discovery service for a chaincode. The endorsers are grouped by the groups
+// referenced from the layouts.
+type ChaincodeEndorsers struct {
+	Chaincode         string
+	EndorsersByGroups map[string][]DiscoveredPeer
+	Layouts           []*discovery.Layout
+}
+
+// DiscoverEndorsers runs the discovery service endorsers command against the
+// peer for the named chaincode and returns the endorsement descriptors. Nil is
+// returned when the discovery service is unable to compute the descriptors so
+// the result can be polled until the expected layouts are available.
+func DiscoverEndorsers(n *Network, p *Peer, user, channelName, chaincodeName string) func() []ChaincodeEndorsers {
+	return discoverEndorsers(n, p, user, commands.Endorsers{
+		Channel:   channelName,
+		Chaincode: chaincodeName,
+	})
+}
+
+// DiscoverCollectionEndorsers runs the discovery service endorsers command
+// against the peer for writes by the named chaincode to the private data
+// collection. See DiscoverEndorsers.
+func DiscoverCollectionEndorsers(n *Network, p *Peer, user, channelName, chaincodeName, collectionName string) func() []ChaincodeEndorsers {
+	return discoverEndorsers(n, p, user, commands.Endorsers{
+		Channel:    channelName,
+		Chaincode:  chaincodeName,
+		Collection: chaincodeName + ":" + collectionName,
+	})
+}
+
+// CollectionEndorsementLayouts returns a function that reports the
+// endorsement layouts the discovery service computes for writes by the named
+// chaincode to the private data collection. Each layout is reported as the
+// sorted MSP IDs of the endorsements it requires, with an MSP ID repeated for
+// each endorsement required from its organization. Nil is returned when the
+// discovery service is unable to compute the layouts.
+func CollectionEndorsementLayouts(n *Network, p *Peer, user, channelName, chaincodeName, collectionName string) func() [][]string {
+	discover := DiscoverCollectionEndorsers(n, p, user, channelName, chaincodeName, collectionName)
+	return func() [][]string {
+		discovered := discover()
+		if len(discovered) != 1 {
+			return nil
+		}
+
+		var layouts [][]string
+		for _, layout := range discovered[0].Layouts {
+			var mspIDs []string
+			for group, quantity := range layout.QuantitiesByGroup {
+				endorsers := discovered[0].EndorsersByGroups[group]
+				Expect(endorsers).NotTo(BeEmpty(), "no endorsers in group %s", group)
+				for i := uint32(0); i < quantity; i++ {
+					mspIDs = append(mspIDs, endorsers[0].MSPID)
+				}
+			}
+			sort.Strings(mspIDs)
+			layouts = append(layouts, mspIDs)
+		}
+		return layouts
+	}
+}
+
+func discoverEndorsers(n *Network, p *Peer, user string, endorsers commands.Endorsers) func() []ChaincodeEndorsers {
+	endorsers.UserCert = n.PeerUserCert(p, user)
+	endorsers.UserKey = n.PeerUserKey(p, user)
+	endorsers.MSPID = n.Organization(p.Organization).MSPID
+	endorsers.Server = n.PeerAddress(p, ListenPort)
+	if n.ClientAuthRequired {
+		endorsers.ClientCert = filepath.Join(n.PeerUserTLSDir(p, user), "client.crt")
+		endorsers.ClientKey = filepath.Join(n.PeerUserTLSDir(p, user), "client.key")
+	}
+
+	return func() []ChaincodeEndorsers {
+		sess, err := n.Discover(endorsers)
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit())
+		if sess.ExitCode() != 0 {
+			return nil
+		}
+
+		var discovered []ChaincodeEndorsers
+		err = json.Unmarshal(sess.Out.Contents(), &discovered)
+		Expect(err).NotTo(HaveOccurred())
+		return discovered
+	}
+}
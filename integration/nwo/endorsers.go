@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"crypto/x509"
+	"encoding/pem"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"regexp"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+)
+
+// Endorser identifies a peer that endorsed a transaction by the MSP ID and
+// certificate common name of its signing identity.
+type Endorser struct {
+	MSPID      string
+	CommonName string
+}
+
+var committedTxIDRegexp = regexp.MustCompile(`txid \[([0-9a-f]+)\] committed with status \(VALID\)`)
+
+// InvokeWithEndorsers submits the invoke as User1 of the peer's organization,
+// waits for the transaction to commit as valid, and returns the endorsers
+// recorded in the committed transaction.
+func InvokeWithEndorsers(n *Network, orderer *Orderer, peer *Peer, invoke commands.ChaincodeInvoke) []Endorser {
+	invoke.WaitForEvent = true
+	sess, err := n.PeerUserSession(peer, "User1", invoke)
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	Expect(sess.Err).To(gbytes.Say("Chaincode invoke successful. result: status:200"))
+
+	match := committedTxIDRegexp.FindSubmatch(sess.Err.Contents())
+	Expect(match).NotTo(BeNil(), "transaction ID not found in invoke output")
+
+	return TransactionEndorsers(n, peer, orderer, invoke.ChannelID, string(match[1]))
+}
+
+// TransactionEndorsers searches the channel's blocks, from newest to oldest,
+// for the transaction and returns its endorsers.
+func TransactionEndorsers(n *Network, peer *Peer, orderer *Orderer, channel, txID string) []Endorser {
+	tempDir, err := ioutil.TempDir(n.RootDir, "transactionEndorsers")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	fetch := func(block string) *common.Block {
+		output := filepath.Join(tempDir, "block_"+block+".pb")
+		sess, err := n.OrdererAdminSession(orderer, peer, commands.ChannelFetch{
+			ChannelID:  channel,
+			Block:      block,
+			Orderer:    n.OrdererAddress(orderer, ListenPort),
+			OutputFile: output,
+			ClientAuth: n.ClientAuthRequired,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+		return UnmarshalBlockFromFile(output)
+	}
+
+	block := fetch("newest")
+	for {
+		if endorsers, ok := blockTransactionEndorsers(block, txID); ok {
+			return endorsers
+		}
+		Expect(block.Header.Number).NotTo(BeZero(), "transaction %s not found on channel %s", txID, channel)
+		block = fetch(strconv.FormatUint(block.Header.Number-1, 10))
+	}
+}
+
+// blockTransactionEndorsers returns the endorsers of the transaction if the
+// block contains it.
+func blockTransactionEndorsers(block *common.Block, txID string) ([]Endorser, bool) {
+	for _, envBytes := range block.Data.Data {
+		env, err := protoutil.GetEnvelopeFromBlock(envBytes)
+		Expect(err).NotTo(HaveOccurred())
+		payload, err := protoutil.UnmarshalPayload(env.Payload)
+		Expect(err).NotTo(HaveOccurred())
+		chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+		Expect(err).NotTo(HaveOccurred())
+		if chdr.TxId != txID {
+			continue
+		}
+
+		tx, err := protoutil.UnmarshalTransaction(payload.Data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tx.Actions).NotTo(BeEmpty())
+		ccActionPayload, err := protoutil.UnmarshalChaincodeActionPayload(tx.Actions[0].Payload)
+		Expect(err).NotTo(HaveOccurred())
+
+		var endorsers []Endorser
+		for _, endorsement := range ccActionPayload.Action.Endorsements {
+			endorsers = append(endorsers, endorserFromEndorsement(endorsement))
+		}
+		return endorsers, true
+	}
+	return nil, false
+}
+
+func endorserFromEndorsement(endorsement *pb.Endorsement) Endorser {
+	identity := &msp.SerializedIdentity{}
+	err := proto.Unmarshal(endorsement.Endorser, identity)
+	Expect(err).NotTo(HaveOccurred())
+
+	block, _ := pem.Decode(identity.IdBytes)
+	Expect(block).NotTo(BeNil())
+	cert, err := x509.ParseCertificate(block.Bytes)
+	Expect(err).NotTo(HaveOccurred())
+
+	return Endorser{
+		MSPID:      identity.Mspid,
+		CommonName: cert.Subject.CommonName,
+	}
+}
@@ -0,0 +1,86 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"crypto/x509"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+
+	"github.com/hyperledger/fabric/internal/cryptogen/ca"
+	"github.com/hyperledger/fabric/internal/cryptogen/csp"
+	"github.com/hyperledger/fabric/internal/cryptogen/msp"
+	. "github.com/onsi/gomega"
+)
+
+// EnrollUser generates an MSP and TLS key pair for a new client user of the
+// organization, signed by the organization's CA and TLS CA that were created
+// during crypto generation. The material is written where cryptogen places
+// its users, so PeerUserSession, SignerForUser, and the other user helpers
+// work with the new user name. When orgUnits are provided, the user's signing
+// certificate carries them instead of the organizational unit cryptogen would
+// assign. Enrolling a user that already exists fails.
+func EnrollUser(n *Network, orgName, user string, orgUnits ...string) {
+	org := n.Organization(orgName)
+	Expect(org).NotTo(BeNil(), "organization %s not found", orgName)
+
+	nodeOrganizationType := "peerOrganizations"
+	if len(n.PeersInOrg(orgName)) == 0 {
+		nodeOrganizationType = "ordererOrganizations"
+	}
+	orgDir := filepath.Join(n.CryptoPath(), nodeOrganizationType, org.Domain)
+
+	userDir := filepath.Dir(n.userCryptoDir(org, nodeOrganizationType, user, "msp"))
+	_, err := os.Stat(userDir)
+	Expect(os.IsNotExist(err)).To(BeTrue(), "user %s already exists in %s", user, orgName)
+
+	signCA := loadCA(filepath.Join(orgDir, "ca"), "ca."+org.Domain)
+	tlsCA := loadCA(filepath.Join(orgDir, "tlsca"), "tlsca."+org.Domain)
+	name := fmt.Sprintf("%s@%s", user, org.Domain)
+	err = msp.GenerateLocalMSP(userDir, name, nil, signCA, tlsCA, msp.CLIENT, org.EnableNodeOUs)
+	Expect(err).NotTo(HaveOccurred())
+
+	if len(orgUnits) != 0 {
+		// reissue the signing certificate for the key generated above
+		signCertsDir := filepath.Join(userDir, "msp", "signcerts")
+		key, err := csp.LoadPrivateKey(filepath.Join(userDir, "msp", "keystore"))
+		Expect(err).NotTo(HaveOccurred())
+		Expect(os.RemoveAll(signCertsDir)).To(Succeed())
+		Expect(os.MkdirAll(signCertsDir, 0755)).To(Succeed())
+		_, err = signCA.SignCertificate(signCertsDir, name, orgUnits, nil, &key.PublicKey, x509.KeyUsageDigitalSignature, []x509.ExtKeyUsage{})
+		Expect(err).NotTo(HaveOccurred())
+	}
+
+	if !org.EnableNodeOUs {
+		// like cryptogen, recognize the organization's admin rather than the
+		// user itself as the administrator of the local MSP
+		adminCertsDir := filepath.Join(userDir, "msp", "admincerts")
+		Expect(os.RemoveAll(adminCertsDir)).To(Succeed())
+		Expect(os.MkdirAll(adminCertsDir, 0755)).To(Succeed())
+
+		adminCertName := fmt.Sprintf("Admin@%s-cert.pem", org.Domain)
+		adminCert, err := ioutil.ReadFile(filepath.Join(n.userCryptoDir(org, nodeOrganizationType, "Admin", "msp"), "signcerts", adminCertName))
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(filepath.Join(adminCertsDir, adminCertName), adminCert, 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}
+}
+
+func loadCA(caDir, name string) *ca.CA {
+	key, err := csp.LoadPrivateKey(caDir)
+	Expect(err).NotTo(HaveOccurred())
+	cert, err := ca.LoadCertificateECDSA(caDir)
+	Expect(err).NotTo(HaveOccurred())
+
+	return &ca.CA{
+		Name:     name,
+		Signer:   &csp.ECDSASigner{PrivateKey: key},
+		SignCert: cert,
+	}
+}
@@ -0,0 +1,51 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bufio"
+	"os"
+
+	. "github.com/onsi/gomega"
+)
+
+// AssertExternalBuildArtifactsExist asserts that the run artifacts file
+// written by the binary external builder lists at least one directory and
+// that every listed directory exists. The builder records the artifacts
+// directory of each chaincode it runs when RUN_ARTIFACTS_FILE is propagated
+// to it.
+func AssertExternalBuildArtifactsExist(runArtifactsFile string) {
+	dirs := externalBuildArtifacts(runArtifactsFile)
+	Expect(dirs).NotTo(BeEmpty(), "no run artifacts recorded in %s", runArtifactsFile)
+	for _, dir := range dirs {
+		Expect(dir).To(BeADirectory())
+	}
+}
+
+// AssertExternalBuildArtifactsCleaned asserts that none of the directories
+// listed in the run artifacts file written by the binary external builder
+// exist. The peer removes the artifacts when the chaincode stops, so this must
+// be called after the peers have been terminated.
+func AssertExternalBuildArtifactsCleaned(runArtifactsFile string) {
+	for _, dir := range externalBuildArtifacts(runArtifactsFile) {
+		Expect(dir).NotTo(BeAnExistingFile())
+	}
+}
+
+func externalBuildArtifacts(runArtifactsFile string) []string {
+	f, err := os.Open(runArtifactsFile)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	var dirs []string
+	scanner := bufio.NewScanner(f)
+	for scanner.Scan() {
+		dirs = append(dirs, scanner.Text())
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+	return dirs
+}
@@ -51,6 +51,9 @@ type Peer struct {
 	ValidatorPoolSize      int             `yaml:"validatorPoolSize,omitempty"`
 	Discovery              *Discovery      `yaml:"discovery,omitempty"`
 	Limits                 *Limits         `yaml:"limits,omitempty"`
+	Profile                *Service        `yaml:"profile,omitempty"`
+	MaxRecvMsgSize         int             `yaml:"maxRecvMsgSize,omitempty"`
+	MaxSendMsgSize         int             `yaml:"maxSendMsgSize,omitempty"`
 
 	ExtraProperties map[string]interface{} `yaml:",inline,omitempty"`
 }
@@ -188,7 +191,10 @@ type PKCS11 struct {
 
 type DeliveryClient struct {
 	ReconnectTotalTimeThreshold time.Duration      `yaml:"reconnectTotalTimeThreshold,omitempty"`
+	ConnTimeout                 time.Duration      `yaml:"connTimeout,omitempty"`
+	ReConnectBackoffThreshold   time.Duration      `yaml:"reConnectBackoffThreshold,omitempty"`
 	AddressOverrides            []*AddressOverride `yaml:"addressOverrides,omitempty"`
+	Compression                 string             `yaml:"compression,omitempty"`
 }
 
 type AddressOverride struct {
@@ -241,6 +247,7 @@ type VM struct {
 type Docker struct {
 	TLS          *TLS               `yaml:"tls,omitempty"`
 	AttachStdout bool               `yaml:"attachStdout"`
+	StopTimeout  time.Duration      `yaml:"stopTimeout,omitempty"`
 	HostConfig   *docker.HostConfig `yaml:"hostConfig,omitempty"`
 }
 
@@ -265,6 +272,7 @@ type Chaincode struct {
 type Golang struct {
 	Runtime     string `yaml:"runtime,omitempty"`
 	DynamicLink bool   `yaml:"dynamicLink"`
+	Offline     bool   `yaml:"offline"`
 
 	ExtraProperties map[string]interface{} `yaml:",inline,omitempty"`
 }
@@ -293,9 +301,13 @@ type SystemFlags struct {
 }
 
 type Ledger struct {
-	// Blockchain - not sure if it's needed
-	State   *StateConfig   `yaml:"state,omitempty"`
-	History *HistoryConfig `yaml:"history,omitempty"`
+	Blockchain *BlockchainConfig `yaml:"blockchain,omitempty"`
+	State      *StateConfig      `yaml:"state,omitempty"`
+	History    *HistoryConfig    `yaml:"history,omitempty"`
+}
+
+type BlockchainConfig struct {
+	MaxBlockfileSize int `yaml:"maxBlockfileSize,omitempty"`
 }
 
 type StateConfig struct {
@@ -313,6 +325,7 @@ type CouchDBConfig struct {
 	QueryLimit              int           `yaml:"queryLimit,omitempty"`
 	MaxBatchUpdateSize      int           `yaml:"maxBatchUpdateSize,omitempty"`
 	WarmIndexesAfterNBlocks int           `yaml:"warmIndexesAfteNBlocks,omitempty"`
+	CacheSize               int           `yaml:"cacheSize"`
 }
 
 type HistoryConfig struct {
@@ -32,6 +32,25 @@ type General struct {
 	Profile         *OrdererProfile        `yaml:"Profile,omitempty"`
 	BCCSP           *BCCSP                 `yaml:"BCCSP,omitempty"`
 	Authentication  *OrdererAuthentication `yaml:"Authentication,omitempty"`
+	Cluster         *Cluster               `yaml:"Cluster,omitempty"`
+	MaxRecvMsgSize  int                    `yaml:"MaxRecvMsgSize,omitempty"`
+	MaxSendMsgSize  int                    `yaml:"MaxSendMsgSize,omitempty"`
+
+	ExtraProperties map[string]interface{} `yaml:",inline,omitempty"`
+}
+
+type Cluster struct {
+	ClientCertificate       string        `yaml:"ClientCertificate,omitempty"`
+	ClientPrivateKey        string        `yaml:"ClientPrivateKey,omitempty"`
+	ServerCertificate       string        `yaml:"ServerCertificate,omitempty"`
+	ServerPrivateKey        string        `yaml:"ServerPrivateKey,omitempty"`
+	DialTimeout             time.Duration `yaml:"DialTimeout,omitempty"`
+	RPCTimeout              time.Duration `yaml:"RPCTimeout,omitempty"`
+	ReplicationBufferSize   int           `yaml:"ReplicationBufferSize,omitempty"`
+	ReplicationPullTimeout  time.Duration `yaml:"ReplicationPullTimeout,omitempty"`
+	ReplicationRetryTimeout time.Duration `yaml:"ReplicationRetryTimeout,omitempty"`
+	ListenAddress           string        `yaml:"ListenAddress,omitempty"`
+	ListenPort              int           `yaml:"ListenPort,omitempty"`
 
 	ExtraProperties map[string]interface{} `yaml:",inline,omitempty"`
 }
@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+	"regexp"
+
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	. "github.com/onsi/gomega"
+)
+
+// GossipMembership returns a function that reports the number of alive peers
+// known to the peer on the channel, excluding the peer itself. The value is
+// read from the gossip_membership_total_peers_known gauge served on the peer's
+// operations endpoint, so the network must use the prometheus metrics
+// provider. The function returns -1 until the gauge has been reported for the
+// channel.
+func GossipMembership(n *Network, p *Peer, channel string) func() int {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "gossip membership is read from prometheus metrics")
+
+	authClient, _ := PeerOperationalClients(n, p)
+	metricsURL := n.PeerOperationsURL(p, "metrics")
+
+	return func() int {
+		value, ok := channelGauge(authClient, metricsURL, "gossip_membership_total_peers_known", channel)
+		if !ok {
+			return -1
+		}
+		return int(value)
+	}
+}
+
+// WaitForGossipMembership waits until each peer knows of the expected number
+// of alive peers on the channel, excluding itself.
+func WaitForGossipMembership(n *Network, channel string, expected int, peers ...*Peer) {
+	for _, p := range peers {
+		Eventually(GossipMembership(n, p, channel), n.EventuallyTimeout).Should(Equal(expected), "gossip membership of %s on %s", p.ID(), channel)
+	}
+}
+
+// WaitForPvtDataReconciliation waits until the private data reconciler of
+// the peer has completed a full cycle on the channel that started after the
+// call. A cycle only ends once the peer finds no more missing private data
+// it is eligible to fetch, so afterwards the peer holds the private data it
+// missed at commit unless the cycle failed. The cycles are observed through
+// the gossip_privdata_reconciliation_duration histogram, so the network must
+// use the prometheus metrics provider.
+func WaitForPvtDataReconciliation(n *Network, p *Peer, channel string) {
+	cycles := PeerMetric(n, p, fmt.Sprintf(`gossip_privdata_reconciliation_duration_count{channel="%s"}`, channel))
+
+	// the cycle in progress when the call is made may have started before
+	// the private data went missing
+	initial := cycles()
+	if initial < 0 {
+		initial = 0
+	}
+	Eventually(cycles, n.EventuallyTimeout).Should(BeNumerically(">=", initial+2), "reconciliation cycles of %s on %s", p.ID(), channel)
+}
+
+// GossipStateTransfer holds the settings a peer uses to fetch the blocks it
+// is missing from other peers of its organization. Zero values leave the
+// current setting unchanged.
+type GossipStateTransfer struct {
+	// BatchSize is the number of blocks requested from a peer at once.
+	BatchSize int
+	// BlockBufferSize is the number of blocks buffered before commit.
+	BlockBufferSize int
+	// MaxBlockCountToStore is the number of recent blocks kept in memory to
+	// serve pull requests from other peers.
+	MaxBlockCountToStore int
+}
+
+// SetGossipStateTransfer enables gossip state transfer on the peer and
+// updates its settings. A peer only fetches missing blocks through state
+// transfer when it does not receive them from the ordering service, so it
+// should not be an org leader.
+func (n *Network) SetGossipStateTransfer(p *Peer, settings GossipStateTransfer) {
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Gossip.State == nil {
+		core.Peer.Gossip.State = &fabricconfig.GossipState{}
+	}
+	state := core.Peer.Gossip.State
+	state.Enabled = true
+	if settings.BatchSize != 0 {
+		state.BatchSize = settings.BatchSize
+	}
+	if settings.BlockBufferSize != 0 {
+		state.BlockBufferSize = settings.BlockBufferSize
+	}
+	if settings.MaxBlockCountToStore != 0 {
+		core.Peer.Gossip.MaxBlockCountToStore = settings.MaxBlockCountToStore
+	}
+	n.WritePeerConfig(p, core)
+}
+
+// GossipStateHeight returns a function that reports the ledger height the
+// gossip state provider of the peer has reached on the channel. The value is
+// read from the gossip_state_height gauge, so the network must use the
+// prometheus metrics provider. The function returns -1 until the gauge has
+// been reported for the channel.
+func GossipStateHeight(n *Network, p *Peer, channel string) func() int {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "gossip state height is read from prometheus metrics")
+
+	authClient, _ := PeerOperationalClients(n, p)
+	metricsURL := n.PeerOperationsURL(p, "metrics")
+
+	return func() int {
+		value, ok := channelGauge(authClient, metricsURL, "gossip_state_height", channel)
+		if !ok {
+			return -1
+		}
+		return int(value)
+	}
+}
+
+// StateTransferRequests returns the number of state transfer requests for
+// blocks of the channel found in the log output of a peer. Retries count as
+// separate requests. The requests are only logged when the gossip.state
+// logger of the peer is at the debug level.
+func StateTransferRequests(output []byte, channel string) int {
+	request := regexp.MustCompile(fmt.Sprintf(`requesting blocks in range \[\d+\.\.\.\d+\), for chainID %s\b`, regexp.QuoteMeta(channel)))
+	return len(request.FindAll(output, -1))
+}
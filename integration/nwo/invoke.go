@@ -0,0 +1,101 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strings"
+
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/onsi/gomega/gexec"
+	. "github.com/onsi/gomega/gstruct"
+)
+
+// InvokeExpectingError runs the invoke as User1 of the peer's organization
+// and asserts that it fails with an error matching the expectedErr regular
+// expression. It is used to verify that, for example, a chaincode whose
+// definition has been approved but not committed cannot be invoked.
+func InvokeExpectingError(n *Network, peer *Peer, invoke commands.ChaincodeInvoke, expectedErr string) {
+	sess, err := n.PeerUserSession(peer, "User1", invoke)
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(1))
+	Expect(sess.Err).To(gbytes.Say(expectedErr))
+}
+
+// InvokeExpectingChaincodeError invokes the chaincode on the peer as User1 of
+// the peer's organization and asserts that the peer reports an endorsement
+// failure whose response contains expectedErr, for example when the chaincode
+// returns an error or its process exits while executing the transaction. It
+// then asserts that the peer itself remains healthy. A chaincode that crashes
+// is launched again by the peer the next time it is needed.
+func InvokeExpectingChaincodeError(n *Network, peer *Peer, channel, chaincode, ctor, expectedErr string) {
+	sess, err := n.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+		ChannelID:     channel,
+		Name:          chaincode,
+		Ctor:          ctor,
+		PeerAddresses: []string{n.PeerAddress(peer, ListenPort)},
+		ClientAuth:    n.ClientAuthRequired,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(1))
+	Expect(sess.Err).To(gbytes.Say(`endorsement failure during invoke`))
+	Expect(string(sess.Err.Contents())).To(ContainSubstring(expectedErr))
+
+	authClient, _ := PeerOperationalClients(n, peer)
+	resp, err := authClient.Get(n.PeerOperationsURL(peer, "healthz"))
+	Expect(err).NotTo(HaveOccurred())
+	resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK), "peer %s is not healthy", peer.ID())
+}
+
+// QueryChaincodeOnPeer runs the query as User1 of the peer's organization
+// against that peer only and returns the result with surrounding whitespace
+// removed. Pinning the query to a peer allows the state of different peers to
+// be compared, for example after private data has been disseminated.
+func QueryChaincodeOnPeer(n *Network, peer *Peer, channel, chaincode, ctor string) string {
+	sess, err := n.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+		ChannelID:     channel,
+		Name:          chaincode,
+		Ctor:          ctor,
+		PeerAddresses: []string{n.PeerAddress(peer, ListenPort)},
+		ClientAuth:    n.ClientAuthRequired,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	return strings.TrimSpace(string(sess.Out.Contents()))
+}
+
+// QueryCreator queries the creator function of an instance of the simple
+// chaincode as the user and decodes the identity the chaincode observed into
+// creator.
+func QueryCreator(n *Network, peer *Peer, user, channel, chaincode string, creator interface{}) {
+	sess, err := n.PeerUserSession(peer, user, commands.ChaincodeQuery{
+		ChannelID: channel,
+		Name:      chaincode,
+		Ctor:      `{"Args":["creator"]}`,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+
+	err = json.Unmarshal(sess.Out.Contents(), creator)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// AssertCreatorMSP asserts that the simple chaincode observes the user as a
+// member of the expected MSP. The observed identity is decoded into creator,
+// which must point to a struct with MSPID and CommonName fields.
+func AssertCreatorMSP(n *Network, peer *Peer, user, channel, chaincode, expectedMSP string, creator interface{}) {
+	QueryCreator(n, peer, user, channel, chaincode, creator)
+	Expect(creator).To(PointTo(MatchFields(IgnoreExtras, Fields{
+		"MSPID":      Equal(expectedMSP),
+		"CommonName": Equal(fmt.Sprintf("%s@%s", user, n.Organization(peer.Organization).Domain)),
+	})))
+}
@@ -0,0 +1,149 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+	"math"
+	"sort"
+	"sync"
+	"time"
+
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+)
+
+// TimedInvoke submits the invoke as User1 of the peer's organization, waits
+// for the peer to deliver the commit event, and returns the time from starting
+// the invoke to the commit notification. The measurement includes the start
+// up time of the peer CLI, so it is only meaningful when compared with other
+// measurements taken the same way.
+func TimedInvoke(n *Network, orderer *Orderer, peer *Peer, invoke commands.ChaincodeInvoke) time.Duration {
+	invoke.Orderer = n.OrdererAddress(orderer, ListenPort)
+	invoke.WaitForEvent = true
+
+	start := time.Now()
+	sess, err := n.PeerUserSession(peer, "User1", invoke)
+	Expect(err).NotTo(HaveOccurred())
+	select {
+	case <-sess.Exited:
+	case <-time.After(n.EventuallyTimeout):
+		ginkgo.Fail("timed out waiting for the invoke to commit")
+	}
+	latency := time.Since(start)
+
+	Expect(sess.ExitCode()).To(Equal(0))
+	Expect(sess.Err).To(gbytes.Say(`\Qcommitted with status (VALID)\E`))
+	return latency
+}
+
+// TimedInvokes runs the invoke count times, one after the other, and returns
+// the latency of each as reported by TimedInvoke.
+func TimedInvokes(n *Network, orderer *Orderer, peer *Peer, invoke commands.ChaincodeInvoke, count int) []time.Duration {
+	latencies := make([]time.Duration, count)
+	for i := range latencies {
+		latencies[i] = TimedInvoke(n, orderer, peer, invoke)
+	}
+	return latencies
+}
+
+// MeasureCommitTime runs the action and returns, for each block added to the
+// channel while it runs, the time from the orderer delivering the block to the
+// peer's blockchain_height metric advancing past it. This covers the delivery
+// of the block to the peer, its validation, and its commit to the block
+// store, and does not rely on the peer's commit time metrics. The action must
+// not return before the transactions it submits have committed on the peer.
+// The network must use the prometheus metrics provider.
+func MeasureCommitTime(n *Network, o *Orderer, p *Peer, channel string, action func()) []time.Duration {
+	height := PeerMetric(n, p, fmt.Sprintf(`ledger_blockchain_height{channel="%s"}`, channel))
+	startHeight := uint64(height())
+	Expect(startHeight).NotTo(BeZero(), "peer %s has not joined channel %s", p.ID(), channel)
+
+	var mutex sync.Mutex
+	received := map[uint64]time.Time{}
+	committed := map[uint64]time.Time{}
+
+	subscriber := NewOrdererBlockSubscriber(n, o, channel, startHeight)
+	go func() {
+		for block := range subscriber.Blocks() {
+			now := time.Now()
+			mutex.Lock()
+			received[block.Header.Number] = now
+			mutex.Unlock()
+		}
+	}()
+
+	stopPolling := make(chan struct{})
+	pollingDone := make(chan struct{})
+	go func() {
+		defer ginkgo.GinkgoRecover()
+		defer close(pollingDone)
+		next := startHeight
+		for {
+			h := uint64(height())
+			now := time.Now()
+			mutex.Lock()
+			for ; next < h; next++ {
+				committed[next] = now
+			}
+			mutex.Unlock()
+			select {
+			case <-stopPolling:
+				return
+			case <-time.After(10 * time.Millisecond):
+			}
+		}
+	}()
+
+	action()
+	endHeight := uint64(height())
+
+	Eventually(func() int {
+		mutex.Lock()
+		defer mutex.Unlock()
+		count := 0
+		for num := startHeight; num < endHeight; num++ {
+			_, r := received[num]
+			_, c := committed[num]
+			if r && c {
+				count++
+			}
+		}
+		return count
+	}, n.EventuallyTimeout).Should(Equal(int(endHeight - startHeight)))
+	close(stopPolling)
+	<-pollingDone
+	subscriber.Stop()
+
+	mutex.Lock()
+	defer mutex.Unlock()
+	durations := make([]time.Duration, 0, endHeight-startHeight)
+	for num := startHeight; num < endHeight; num++ {
+		d := committed[num].Sub(received[num])
+		Expect(d).NotTo(BeNumerically("<", 0), "block %d committed %s before the orderer delivered it", num, -d)
+		durations = append(durations, d)
+	}
+	return durations
+}
+
+// LatencyPercentile returns the nearest-rank percentile, between 0 and 100, of
+// the latencies.
+func LatencyPercentile(latencies []time.Duration, percentile float64) time.Duration {
+	Expect(latencies).NotTo(BeEmpty())
+	Expect(percentile).To(And(BeNumerically(">=", 0), BeNumerically("<=", 100)))
+
+	sorted := append([]time.Duration{}, latencies...)
+	sort.Slice(sorted, func(i, j int) bool { return sorted[i] < sorted[j] })
+
+	rank := int(math.Ceil(percentile / 100 * float64(len(sorted))))
+	if rank < 1 {
+		rank = 1
+	}
+	return sorted[rank-1]
+}
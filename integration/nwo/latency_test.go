@@ -0,0 +1,42 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo_test
+
+import (
+	"time"
+
+	"github.com/hyperledger/fabric/integration/nwo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("LatencyPercentile", func() {
+	latencies := []time.Duration{
+		5 * time.Millisecond,
+		1 * time.Millisecond,
+		4 * time.Millisecond,
+		2 * time.Millisecond,
+		3 * time.Millisecond,
+	}
+
+	DescribeTable("returns the nearest-rank percentile",
+		func(percentile float64, expected time.Duration) {
+			Expect(nwo.LatencyPercentile(latencies, percentile)).To(Equal(expected))
+		},
+		Entry("minimum", 0.0, 1*time.Millisecond),
+		Entry("p20", 20.0, 1*time.Millisecond),
+		Entry("p50", 50.0, 3*time.Millisecond),
+		Entry("p90", 90.0, 5*time.Millisecond),
+		Entry("maximum", 100.0, 5*time.Millisecond),
+	)
+
+	It("does not reorder the latencies", func() {
+		nwo.LatencyPercentile(latencies, 50)
+		Expect(latencies[0]).To(Equal(5 * time.Millisecond))
+	})
+})
@@ -0,0 +1,68 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"encoding/binary"
+	"io/ioutil"
+	"os"
+
+	. "github.com/onsi/gomega"
+)
+
+// CorruptBlockFile flips length bytes of the first block file of the peer's
+// channel ledger, starting at offset. The peer must be stopped; the corruption
+// is detected when the peer reads the affected blocks after it is started.
+func CorruptBlockFile(n *Network, p *Peer, channel string, offset int64, length int) {
+	blockFiles := n.PeerBlockFiles(p, channel)
+	Expect(blockFiles).NotTo(BeEmpty(), "no block files for %s on %s", channel, p.ID())
+
+	f, err := os.OpenFile(blockFiles[0], os.O_RDWR, 0)
+	Expect(err).NotTo(HaveOccurred())
+	defer f.Close()
+
+	buf := make([]byte, length)
+	_, err = f.ReadAt(buf, offset)
+	Expect(err).NotTo(HaveOccurred())
+	for i := range buf {
+		buf[i] ^= 0xff
+	}
+	_, err = f.WriteAt(buf, offset)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// BlockDataHashOffset returns the offset of the data hash in the header of
+// the numbered block within the first block file of the peer's channel
+// ledger. Flipping bytes at the offset changes the hash of the block header,
+// so the peer detects the corruption through the hash chain when it commits
+// the following block.
+func BlockDataHashOffset(n *Network, p *Peer, channel string, blockNum uint64) int64 {
+	blockFiles := n.PeerBlockFiles(p, channel)
+	Expect(blockFiles).NotTo(BeEmpty(), "no block files for %s on %s", channel, p.ID())
+	content, err := ioutil.ReadFile(blockFiles[0])
+	Expect(err).NotTo(HaveOccurred())
+
+	// each block is stored as its length followed by the block number and
+	// the length prefixed data hash of its header
+	offset := 0
+	for offset < len(content) {
+		blockLen, size := binary.Uvarint(content[offset:])
+		Expect(size).To(BeNumerically(">", 0), "malformed block length at offset %d", offset)
+		start := offset + size
+
+		number, size := binary.Uvarint(content[start:])
+		Expect(size).To(BeNumerically(">", 0), "malformed block number at offset %d", start)
+		if number == blockNum {
+			_, hashLenSize := binary.Uvarint(content[start+size:])
+			Expect(hashLenSize).To(BeNumerically(">", 0), "malformed data hash at offset %d", start+size)
+			return int64(start + size + hashLenSize)
+		}
+		offset = start + int(blockLen)
+	}
+	Expect(offset).To(BeNumerically("<", len(content)), "block %d not found in %s", blockNum, blockFiles[0])
+	return 0
+}
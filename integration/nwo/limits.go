@@ -0,0 +1,25 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import "github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+
+// SetPeerConcurrencyLimits sets the maximum number of requests the endorser
+// and deliver services of the peer process concurrently. Requests beyond the
+// limit are rejected rather than queued. A limit of zero removes the limit of
+// the service. The change takes effect the next time the peer starts.
+func (n *Network) SetPeerConcurrencyLimits(p *Peer, endorserService, deliverService int) {
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Limits == nil {
+		core.Peer.Limits = &fabricconfig.Limits{}
+	}
+	core.Peer.Limits.Concurrency = &fabricconfig.Concurrency{
+		EndorserService: endorserService,
+		DeliverService:  deliverService,
+	}
+	n.WritePeerConfig(p, core)
+}
@@ -0,0 +1,222 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"context"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/orderer"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/cmd/common/signer"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/protoutil"
+	"github.com/onsi/ginkgo"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// LoadGenerator submits chaincode invokes from concurrent workers for a fixed
+// duration. Transactions are endorsed and broadcast over gRPC with the
+// identity of User1 of the first endorser's organization instead of through
+// the peer CLI, so the rate is not bounded by the start up time of the CLI.
+type LoadGenerator struct {
+	Network   *Network
+	Orderer   *Orderer
+	Endorsers []*Peer
+	ChannelID string
+	Chaincode string
+	// Ctor returns the arguments of the nth invoke.
+	Ctor func(n int) []string
+	// Concurrency is the number of workers submitting invokes.
+	Concurrency int
+	Duration    time.Duration
+	// TargetTPS limits the rate of submission across all workers. Invokes
+	// are submitted as fast as the workers allow when it is zero.
+	TargetTPS float64
+}
+
+// LoadStats describes the transactions submitted by a LoadGenerator. The
+// latency of a transaction is the time from creating its proposal until the
+// orderer accepts it; use WaitForTransactions to observe the commit.
+type LoadStats struct {
+	TxIDs     []string
+	Latencies []time.Duration
+	Elapsed   time.Duration
+}
+
+// Throughput returns the number of transactions accepted by the orderer per
+// second.
+func (s LoadStats) Throughput() float64 {
+	if s.Elapsed == 0 {
+		return 0
+	}
+	return float64(len(s.TxIDs)) / s.Elapsed.Seconds()
+}
+
+// Run submits invokes until the duration elapses and returns the statistics
+// of the accepted transactions. Every proposal must be endorsed successfully
+// and every transaction must be accepted by the orderer.
+func (l *LoadGenerator) Run() LoadStats {
+	Expect(l.Endorsers).NotTo(BeEmpty())
+	Expect(l.Concurrency).To(BeNumerically(">", 0))
+
+	signer := SignerForUser(l.Network, l.Endorsers[0].Organization, "User1")
+
+	var endorserClients []pb.EndorserClient
+	for _, p := range l.Endorsers {
+		conn := clientConn(l.Network, l.Network.PeerLocalTLSDir(p), l.Network.PeerAddress(p, ListenPort))
+		defer conn.Close()
+		endorserClients = append(endorserClients, pb.NewEndorserClient(conn))
+	}
+	ordererConn := clientConn(l.Network, l.Network.OrdererLocalTLSDir(l.Orderer), l.Network.OrdererAddress(l.Orderer, ListenPort))
+	defer ordererConn.Close()
+
+	var tokens <-chan time.Time
+	if l.TargetTPS > 0 {
+		ticker := time.NewTicker(time.Duration(float64(time.Second) / l.TargetTPS))
+		defer ticker.Stop()
+		tokens = ticker.C
+	}
+
+	var (
+		mutex sync.Mutex
+		stats LoadStats
+		seq   int64
+		wg    sync.WaitGroup
+	)
+
+	start := time.Now()
+	deadline := start.Add(l.Duration)
+	for i := 0; i < l.Concurrency; i++ {
+		wg.Add(1)
+		go func() {
+			defer ginkgo.GinkgoRecover()
+			defer wg.Done()
+
+			ctx, cancel := context.WithTimeout(context.Background(), l.Duration+l.Network.EventuallyTimeout)
+			defer cancel()
+			broadcaster, err := orderer.NewAtomicBroadcastClient(ordererConn).Broadcast(ctx)
+			Expect(err).NotTo(HaveOccurred())
+			defer broadcaster.CloseSend()
+
+			for time.Now().Before(deadline) {
+				if tokens != nil {
+					select {
+					case <-tokens:
+					case <-time.After(time.Until(deadline)):
+						return
+					}
+				}
+
+				submitted := time.Now()
+				env, txID := l.endorse(ctx, signer, endorserClients, int(atomic.AddInt64(&seq, 1)-1))
+				Expect(broadcaster.Send(env)).To(Succeed())
+				resp, err := broadcaster.Recv()
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(common.Status_SUCCESS), "transaction %s rejected: %s", txID, resp.Info)
+				latency := time.Since(submitted)
+
+				mutex.Lock()
+				stats.TxIDs = append(stats.TxIDs, txID)
+				stats.Latencies = append(stats.Latencies, latency)
+				mutex.Unlock()
+			}
+		}()
+	}
+	wg.Wait()
+	stats.Elapsed = time.Since(start)
+
+	return stats
+}
+
+// endorse collects the endorsements of the nth invoke and returns the signed
+// transaction envelope and its ID.
+func (l *LoadGenerator) endorse(ctx context.Context, signer *signer.Signer, endorserClients []pb.EndorserClient, n int) (*common.Envelope, string) {
+	var args [][]byte
+	for _, arg := range l.Ctor(n) {
+		args = append(args, []byte(arg))
+	}
+	cis := &pb.ChaincodeInvocationSpec{
+		ChaincodeSpec: &pb.ChaincodeSpec{
+			Type:        pb.ChaincodeSpec_GOLANG,
+			ChaincodeId: &pb.ChaincodeID{Name: l.Chaincode},
+			Input:       &pb.ChaincodeInput{Args: args},
+		},
+	}
+
+	creator, err := signer.Serialize()
+	Expect(err).NotTo(HaveOccurred())
+	prop, txID, err := protoutil.CreateChaincodeProposal(common.HeaderType_ENDORSER_TRANSACTION, l.ChannelID, cis, creator)
+	Expect(err).NotTo(HaveOccurred())
+	signedProp, err := protoutil.GetSignedProposal(prop, signer)
+	Expect(err).NotTo(HaveOccurred())
+
+	var responses []*pb.ProposalResponse
+	for _, client := range endorserClients {
+		resp, err := client.ProcessProposal(ctx, signedProp)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(resp.Response.Status).To(BeEquivalentTo(200), "endorsement of %s failed: %s", txID, resp.Response.Message)
+		responses = append(responses, resp)
+	}
+
+	env, err := protoutil.CreateSignedTx(prop, signer, responses...)
+	Expect(err).NotTo(HaveOccurred())
+	return env, txID
+}
+
+// WaitForTransactions fetches the channel's blocks from the peer, starting
+// with fromBlock and waiting for each to be committed, until all of the
+// transactions have been committed and returns the validation code the peer
+// assigned to each.
+func WaitForTransactions(n *Network, peer *Peer, channel string, fromBlock uint64, txIDs []string) map[string]pb.TxValidationCode {
+	tempDir, err := ioutil.TempDir(n.RootDir, "waitForTransactions")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	pending := map[string]bool{}
+	for _, txID := range txIDs {
+		pending[txID] = true
+	}
+
+	codes := map[string]pb.TxValidationCode{}
+	for blockNum := fromBlock; len(pending) > 0; blockNum++ {
+		output := filepath.Join(tempDir, "block_"+strconv.FormatUint(blockNum, 10)+".pb")
+		sess, err := n.PeerUserSession(peer, "User1", commands.ChannelFetch{
+			ChannelID:  channel,
+			Block:      strconv.FormatUint(blockNum, 10),
+			OutputFile: output,
+			ClientAuth: n.ClientAuthRequired,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0), "block %d of %s not received; %d transactions pending", blockNum, channel, len(pending))
+
+		block := UnmarshalBlockFromFile(output)
+		flags := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+		for i, envBytes := range block.Data.Data {
+			env, err := protoutil.GetEnvelopeFromBlock(envBytes)
+			Expect(err).NotTo(HaveOccurred())
+			payload, err := protoutil.UnmarshalPayload(env.Payload)
+			Expect(err).NotTo(HaveOccurred())
+			chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+			Expect(err).NotTo(HaveOccurred())
+			if pending[chdr.TxId] {
+				codes[chdr.TxId] = pb.TxValidationCode(flags[i])
+				delete(pending, chdr.TxId)
+			}
+		}
+	}
+
+	return codes
+}
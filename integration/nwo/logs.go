@@ -0,0 +1,36 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"time"
+
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gbytes"
+	"github.com/tedsuo/ifrit/ginkgomon"
+)
+
+// LogSequence matches the log output of a component against lines that are
+// expected to appear in order.
+type LogSequence struct {
+	buffer *gbytes.Buffer
+}
+
+// LogMatcher returns a LogSequence for the log output of the runner. Matching
+// consumes the output up to the matched line, so each assertion only matches
+// lines written after those matched by earlier assertions.
+func LogMatcher(runner *ginkgomon.Runner) *LogSequence {
+	return &LogSequence{buffer: runner.Err()}
+}
+
+// Says waits up to timeout for each of the regular expressions to match a
+// line of the log, in order.
+func (l *LogSequence) Says(timeout time.Duration, patterns ...string) {
+	for i, pattern := range patterns {
+		Eventually(l.buffer, timeout).Should(gbytes.Say(pattern), "expected log line %d of %d to match %q", i+1, len(patterns), pattern)
+	}
+}
@@ -0,0 +1,146 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bufio"
+	"fmt"
+	"net/http"
+	"regexp"
+	"strconv"
+	"strings"
+
+	. "github.com/onsi/gomega"
+)
+
+// PeerMetric returns a function that reports the value of a prometheus series
+// served on the peer's operations endpoint. The series is the metric name
+// followed by its labels as rendered by prometheus, for example
+// go_memstats_heap_alloc_bytes or ledger_blockchain_height{channel="testchannel"}.
+// The network must use the prometheus metrics provider. The function returns
+// -1 until the series is reported.
+func PeerMetric(n *Network, p *Peer, series string) func() float64 {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "metrics are read from prometheus")
+
+	authClient, _ := PeerOperationalClients(n, p)
+	return metricFunc(authClient, n.PeerOperationsURL(p, "metrics"), series)
+}
+
+// OrdererMetric returns a function that reports the value of a prometheus
+// series served on the orderer's operations endpoint. See PeerMetric.
+func OrdererMetric(n *Network, o *Orderer, series string) func() float64 {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "metrics are read from prometheus")
+
+	authClient, _ := OrdererOperationalClients(n, o)
+	return metricFunc(authClient, n.OrdererOperationsURL(o, "metrics"), series)
+}
+
+// ChaincodeMetric returns a function that reports the sum of the samples of a
+// per-chaincode metric, such as chaincode_shim_requests_received, that the
+// peer reports for the chaincode package. Samples are summed across the other
+// labels of the metric. Chaincode does not emit metrics through the shim, so
+// the metrics are those the peer records for the chaincode it runs. The
+// function returns -1 until the metric is reported for the chaincode.
+func ChaincodeMetric(n *Network, p *Peer, chaincode Chaincode, name string) func() float64 {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "metrics are read from prometheus")
+	if chaincode.PackageID == "" {
+		chaincode.SetPackageIDFromPackageFile()
+	}
+
+	authClient, _ := PeerOperationalClients(n, p)
+	metricsURL := n.PeerOperationsURL(p, "metrics")
+	samples := metricSamples(name)
+	label := fmt.Sprintf(`chaincode="%s"`, chaincode.PackageID)
+	return func() float64 {
+		value, ok := sumMetric(authClient, metricsURL, samples, label)
+		if !ok {
+			return -1
+		}
+		return value
+	}
+}
+
+func metricFunc(client *http.Client, metricsURL, series string) func() float64 {
+	return func() float64 {
+		value, ok := scrapeMetric(client, metricsURL, series)
+		if !ok {
+			return -1
+		}
+		return value
+	}
+}
+
+// channelGauge returns the value of the named gauge for the channel from the
+// prometheus metrics served at metricsURL. The returned bool is false when the
+// gauge has not been reported for the channel.
+func channelGauge(client *http.Client, metricsURL, name, channel string) (float64, bool) {
+	return scrapeMetric(client, metricsURL, fmt.Sprintf(`%s{channel="%s"}`, name, channel))
+}
+
+func scrapeMetric(client *http.Client, metricsURL, series string) (float64, bool) {
+	line := regexp.MustCompile(fmt.Sprintf(`^%s (\S+)$`, regexp.QuoteMeta(series)))
+
+	resp, err := client.Get(metricsURL)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		if m := line.FindStringSubmatch(scanner.Text()); m != nil {
+			value, err := strconv.ParseFloat(m[1], 64)
+			Expect(err).NotTo(HaveOccurred())
+			return value, true
+		}
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+
+	return 0, false
+}
+
+// metricSamples returns a regular expression that matches the samples of the
+// named metric in the prometheus text format and captures their labels and
+// value.
+func metricSamples(name string) *regexp.Regexp {
+	return regexp.MustCompile(fmt.Sprintf(`^%s\{(.*)\} (\S+)$`, regexp.QuoteMeta(name)))
+}
+
+// sumMetric returns the sum of the metric samples matched by samples that
+// carry the label from the prometheus metrics served at metricsURL. The
+// returned bool is false when no sample carries the label.
+func sumMetric(client *http.Client, metricsURL string, samples *regexp.Regexp, label string) (float64, bool) {
+	resp, err := client.Get(metricsURL)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	var sum float64
+	var found bool
+	scanner := bufio.NewScanner(resp.Body)
+	for scanner.Scan() {
+		m := samples.FindStringSubmatch(scanner.Text())
+		if m == nil || !containsLabel(m[1], label) {
+			continue
+		}
+		value, err := strconv.ParseFloat(m[2], 64)
+		Expect(err).NotTo(HaveOccurred())
+		sum += value
+		found = true
+	}
+	Expect(scanner.Err()).NotTo(HaveOccurred())
+
+	return sum, found
+}
+
+func containsLabel(labels, label string) bool {
+	for _, l := range strings.Split(labels, ",") {
+		if l == label {
+			return true
+		}
+	}
+	return false
+}
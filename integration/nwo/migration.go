@@ -0,0 +1,100 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	protosorderer "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"github.com/tedsuo/ifrit"
+)
+
+// SetOrdererMaintenanceMode executes a config update that moves the
+// channel's consensus into or out of maintenance mode. While in maintenance
+// mode the orderer rejects normal transactions and only accepts config
+// updates that change the consensus type or its metadata.
+func SetOrdererMaintenanceMode(n *Network, peer *Peer, orderer *Orderer, channel string, on bool) {
+	state := protosorderer.ConsensusType_STATE_NORMAL
+	if on {
+		state = protosorderer.ConsensusType_STATE_MAINTENANCE
+	}
+
+	updateConsensusType(n, peer, orderer, channel, func(consensusType *protosorderer.ConsensusType) {
+		Expect(consensusType.State).NotTo(Equal(state), "channel %s is already in state %s", channel, state)
+		consensusType.State = state
+	})
+}
+
+// SetConsensusType executes a config update that changes the consensus type
+// and metadata of a channel that is in maintenance mode.
+func SetConsensusType(n *Network, peer *Peer, orderer *Orderer, channel, consensusType string, metadata []byte) {
+	updateConsensusType(n, peer, orderer, channel, func(value *protosorderer.ConsensusType) {
+		Expect(value.State).To(Equal(protosorderer.ConsensusType_STATE_MAINTENANCE), "channel %s is not in maintenance mode", channel)
+		value.Type = consensusType
+		value.Metadata = metadata
+	})
+}
+
+func updateConsensusType(n *Network, peer *Peer, orderer *Orderer, channel string, mutate func(*protosorderer.ConsensusType)) {
+	config := GetConfig(n, peer, orderer, channel)
+	updatedConfig := proto.Clone(config).(*common.Config)
+
+	consensusTypeValue := ConsensusTypeFromConfig(updatedConfig)
+	mutate(consensusTypeValue)
+	updatedConfig.ChannelGroup.Groups["Orderer"].Values["ConsensusType"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     protoutil.MarshalOrPanic(consensusTypeValue),
+	}
+
+	UpdateOrdererConfig(n, orderer, channel, config, updatedConfig, peer, orderer)
+}
+
+// RaftMetadata returns etcdraft consensus metadata with the orderers as
+// consenters and the default configtxgen options.
+func RaftMetadata(n *Network, orderers ...*Orderer) *etcdraft.ConfigMetadata {
+	metadata := &etcdraft.ConfigMetadata{
+		Options: &etcdraft.Options{
+			TickInterval:         "500ms",
+			ElectionTick:         10,
+			HeartbeatTick:        1,
+			MaxInflightBlocks:    5,
+			SnapshotIntervalSize: 16 * 1024 * 1024,
+		},
+	}
+	for _, o := range orderers {
+		consenter := n.OrdererConsenter(o)
+		metadata.Consenters = append(metadata.Consenters, &consenter)
+	}
+	return metadata
+}
+
+// MigrateToRaft migrates the channels of a kafka network to etcdraft. Each
+// channel enters maintenance mode and switches its consensus type, the
+// network process is restarted so that the orderers start the etcdraft
+// chains, and each channel then leaves maintenance mode. The system channel
+// must be listed before the application channels. The restarted network
+// process is returned.
+func MigrateToRaft(n *Network, process ifrit.Process, peer *Peer, orderer *Orderer, metadata *etcdraft.ConfigMetadata, channels ...string) ifrit.Process {
+	metadataBytes := protoutil.MarshalOrPanic(metadata)
+	for _, channel := range channels {
+		Expect(ConsensusType(n, peer, orderer, channel)).To(Equal("kafka"))
+		SetOrdererMaintenanceMode(n, peer, orderer, channel, true)
+		SetConsensusType(n, peer, orderer, channel, "etcdraft", metadataBytes)
+	}
+
+	n.Consensus.Type = "etcdraft"
+	process = n.Restart(process)
+
+	for _, channel := range channels {
+		WaitForLeader(n, channel, n.Orderers...)
+		SetOrdererMaintenanceMode(n, peer, orderer, channel, false)
+	}
+	return process
+}
@@ -0,0 +1,49 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+	"strings"
+
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+)
+
+// SetPeerMaxMsgSize sets the maximum size, in bytes, of the gRPC messages the
+// peer's server will receive and send. A size of zero restores the default.
+// The change takes effect the next time the peer starts.
+func (n *Network) SetPeerMaxMsgSize(p *Peer, maxRecvMsgSize, maxSendMsgSize int) {
+	core := n.ReadPeerConfig(p)
+	core.Peer.MaxRecvMsgSize = maxRecvMsgSize
+	core.Peer.MaxSendMsgSize = maxSendMsgSize
+	n.WritePeerConfig(p, core)
+}
+
+// SetOrdererMaxMsgSize sets the maximum size, in bytes, of the gRPC messages
+// the orderer's server will receive and send. A size of zero restores the
+// default. The change takes effect the next time the orderer starts.
+func (n *Network) SetOrdererMaxMsgSize(o *Orderer, maxRecvMsgSize, maxSendMsgSize int) {
+	ordererConfig := n.ReadOrdererConfig(o)
+	ordererConfig.General.MaxRecvMsgSize = maxRecvMsgSize
+	ordererConfig.General.MaxSendMsgSize = maxSendMsgSize
+	n.WriteOrdererConfig(o, ordererConfig)
+}
+
+// ChaincodeInvokeWithPayload returns an invoke of the chaincode function
+// with a single argument carrying a payload of the specified size in bytes.
+// The invoke is endorsed by the specified peers.
+func ChaincodeInvokeWithPayload(n *Network, orderer *Orderer, channel, chaincodeName, function string, payloadSize int, peers ...*Peer) commands.ChaincodeInvoke {
+	return commands.ChaincodeInvoke{
+		ChannelID:     channel,
+		Orderer:       n.OrdererAddress(orderer, ListenPort),
+		Name:          chaincodeName,
+		Ctor:          fmt.Sprintf(`{"Args":["%s","%s"]}`, function, strings.Repeat("x", payloadSize)),
+		PeerAddresses: PeerAddresses(n, ListenPort, peers...),
+		WaitForEvent:  true,
+		ClientAuth:    n.ClientAuthRequired,
+	}
+}
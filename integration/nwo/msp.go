@@ -0,0 +1,128 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"io/ioutil"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/msp"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+)
+
+// ConfigureOrgMSP registers a function that mutates the channel MSP
+// definition of the named organization. The configurers are applied to the
+// system channel genesis block and the channel creation transactions during
+// Bootstrap so they must be registered before the network is bootstrapped.
+//
+// This can be used to enable NodeOUs with custom organizational unit
+// identifiers or to add intermediate certificates to the MSP.
+func ConfigureOrgMSP(n *Network, orgName string, configure func(*msp.FabricMSPConfig)) {
+	Expect(n.Organization(orgName)).NotTo(BeNil(), "organization %s not found", orgName)
+	if n.mspConfigurers == nil {
+		n.mspConfigurers = map[string][]func(*msp.FabricMSPConfig){}
+	}
+	n.mspConfigurers[orgName] = append(n.mspConfigurers[orgName], configure)
+}
+
+// applyMSPConfigurers rewrites the generated system channel genesis block and
+// channel creation transactions with the registered MSP configurers.
+func (n *Network) applyMSPConfigurers() {
+	if len(n.mspConfigurers) == 0 {
+		return
+	}
+
+	n.configureGenesisBlockMSPs(n.OutputBlockPath(n.SystemChannel.Name))
+	for _, c := range n.Channels {
+		n.configureCreateChannelTxMSPs(n.CreateChannelTxPath(c.Name))
+	}
+}
+
+func (n *Network) configureGenesisBlockMSPs(blockPath string) {
+	blockBytes, err := ioutil.ReadFile(blockPath)
+	Expect(err).NotTo(HaveOccurred())
+	block := &common.Block{}
+	err = proto.Unmarshal(blockBytes, block)
+	Expect(err).NotTo(HaveOccurred())
+
+	envelope, err := protoutil.ExtractEnvelope(block, 0)
+	Expect(err).NotTo(HaveOccurred())
+	payload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	Expect(err).NotTo(HaveOccurred())
+	configEnvelope := &common.ConfigEnvelope{}
+	err = proto.Unmarshal(payload.Data, configEnvelope)
+	Expect(err).NotTo(HaveOccurred())
+
+	n.configureGroupMSPs(configEnvelope.Config.ChannelGroup)
+
+	payload.Data = protoutil.MarshalOrPanic(configEnvelope)
+	envelope.Payload = protoutil.MarshalOrPanic(payload)
+	block.Data.Data[0] = protoutil.MarshalOrPanic(envelope)
+	block.Header.DataHash = protoutil.BlockDataHash(block.Data)
+
+	err = ioutil.WriteFile(blockPath, protoutil.MarshalOrPanic(block), 0644)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+func (n *Network) configureCreateChannelTxMSPs(txPath string) {
+	envelopeBytes, err := ioutil.ReadFile(txPath)
+	Expect(err).NotTo(HaveOccurred())
+	envelope, err := protoutil.UnmarshalEnvelope(envelopeBytes)
+	Expect(err).NotTo(HaveOccurred())
+	payload, err := protoutil.UnmarshalPayload(envelope.Payload)
+	Expect(err).NotTo(HaveOccurred())
+	configUpdateEnvelope := &common.ConfigUpdateEnvelope{}
+	err = proto.Unmarshal(payload.Data, configUpdateEnvelope)
+	Expect(err).NotTo(HaveOccurred())
+	configUpdate := &common.ConfigUpdate{}
+	err = proto.Unmarshal(configUpdateEnvelope.ConfigUpdate, configUpdate)
+	Expect(err).NotTo(HaveOccurred())
+
+	n.configureGroupMSPs(configUpdate.WriteSet)
+
+	configUpdateEnvelope.ConfigUpdate = protoutil.MarshalOrPanic(configUpdate)
+	payload.Data = protoutil.MarshalOrPanic(configUpdateEnvelope)
+	envelope.Payload = protoutil.MarshalOrPanic(payload)
+
+	err = ioutil.WriteFile(txPath, protoutil.MarshalOrPanic(envelope), 0644)
+	Expect(err).NotTo(HaveOccurred())
+}
+
+// configureGroupMSPs walks the config group tree and applies the MSP
+// configurers to every organization group that carries an MSP definition.
+func (n *Network) configureGroupMSPs(group *common.ConfigGroup) {
+	if group == nil {
+		return
+	}
+
+	for name, g := range group.Groups {
+		n.configureGroupMSPs(g)
+
+		mspValue, ok := g.Values["MSP"]
+		if !ok || len(n.mspConfigurers[name]) == 0 {
+			continue
+		}
+
+		mspConfig := &msp.MSPConfig{}
+		err := proto.Unmarshal(mspValue.Value, mspConfig)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(mspConfig.Type).To(Equal(int32(0)), "MSP of organization %s is not a fabric MSP", name)
+
+		fabricConfig := &msp.FabricMSPConfig{}
+		err = proto.Unmarshal(mspConfig.Config, fabricConfig)
+		Expect(err).NotTo(HaveOccurred())
+
+		for _, configure := range n.mspConfigurers[name] {
+			configure(fabricConfig)
+		}
+
+		mspConfig.Config = protoutil.MarshalOrPanic(fabricConfig)
+		mspValue.Value = protoutil.MarshalOrPanic(mspConfig)
+	}
+}
@@ -8,6 +8,7 @@ package nwo
 
 import (
 	"bytes"
+	"encoding/json"
 	"fmt"
 	"io"
 	"io/ioutil"
@@ -15,6 +16,7 @@ import (
 	"os"
 	"os/exec"
 	"path/filepath"
+	"regexp"
 	"runtime"
 	"sort"
 	"strconv"
@@ -24,6 +26,7 @@ import (
 	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
+	"github.com/hyperledger/fabric-protos-go/msp"
 	"github.com/hyperledger/fabric/integration/helpers"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
@@ -71,6 +74,20 @@ type Consensus struct {
 	Brokers                     int    `yaml:"brokers,omitempty"`
 	ZooKeepers                  int    `yaml:"zookeepers,omitempty"`
 	ChannelParticipationEnabled bool   `yaml:"channel_participation_enabled,omitempty"`
+
+	EtcdRaftOptions *EtcdRaftOptions `yaml:"etcdraft_options,omitempty"`
+}
+
+// EtcdRaftOptions are the etcdraft consensus options written into the
+// consensus metadata of the genesis block. Zero values fall back to the
+// defaults used by the configtx template or, when the template does not set
+// the option, by configtxgen.
+type EtcdRaftOptions struct {
+	TickInterval         string `yaml:"tick_interval,omitempty"`
+	ElectionTick         uint32 `yaml:"election_tick,omitempty"`
+	HeartbeatTick        uint32 `yaml:"heartbeat_tick,omitempty"`
+	MaxInflightBlocks    uint32 `yaml:"max_inflight_blocks,omitempty"`
+	SnapshotIntervalSize string `yaml:"snapshot_interval_size,omitempty"`
 }
 
 // The SystemChannel declares the name of the network system channel and its
@@ -148,9 +165,26 @@ type Network struct {
 	NetworkID             string
 	EventuallyTimeout     time.Duration
 	SessionCreateInterval time.Duration
+	SessionRetry          *SessionRetry
 	MetricsProvider       string
 	StatsdEndpoint        string
 	ClientAuthRequired    bool
+	// PeerTLSCABundles gives every peer its own bundle of TLS CA
+	// certificates for CLI sessions run in its context, instead of the
+	// bundle shared by the network. The bundle is only passed to the CLI
+	// through --cafile and --tlsRootCertFiles; the peer and orderer
+	// processes keep trusting the TLS CAs of the channel config.
+	PeerTLSCABundles bool
+	// TLSIntermediateCAs issues the TLS certificates of every organization
+	// from an intermediate CA signed by the organization's TLS CA. The
+	// certificates carry the full chain while peers, orderers, and clients
+	// keep trusting only the root TLS CAs.
+	TLSIntermediateCAs bool
+	// TLSCAValidity, when set, reissues the TLS CA certificate of every
+	// organization during bootstrap so that it expires once the validity has
+	// elapsed. The CAs keep their key pairs, so the certificates they issued
+	// remain valid until the CA expires.
+	TLSCAValidity time.Duration
 
 	PortsByBrokerID  map[string]Ports
 	PortsByOrdererID map[string]Ports
@@ -167,6 +201,16 @@ type Network struct {
 
 	colorIndex       uint
 	sessLastExecuted map[string]time.Time
+	mspConfigurers   map[string][]func(*msp.FabricMSPConfig)
+	logSpecs         map[string]string
+	gcPercents       map[string]string
+	binaries         map[string]string
+	clusterProxies   map[string]*clusterProxy
+	deliverProxies   map[string][]*deliverProxy
+	dockerNetworks   []string
+	plaintextOps     map[string]bool
+	operationsHosts  map[string]string
+	propagatedEnv    []string
 }
 
 // New creates a Network from a simple configuration. All generated or managed
@@ -205,6 +249,9 @@ func New(c *Config, rootDir string, client *docker.Client, startPort int, compon
 		Path:                 filepath.Join(cwd, "..", "externalbuilders", "binary"),
 		Name:                 "binary",
 		PropagateEnvironment: []string{"GOPROXY"},
+	}, {
+		Path: filepath.Join(cwd, "..", "externalbuilders", "ccaas"),
+		Name: "ccaas",
 	}}
 
 	if network.Templates == nil {
@@ -213,6 +260,16 @@ func New(c *Config, rootDir string, client *docker.Client, startPort int, compon
 	if network.SessionCreateInterval == 0 {
 		network.SessionCreateInterval = time.Second
 	}
+	if network.SessionRetry == nil {
+		network.SessionRetry = &SessionRetry{
+			Attempts: 3,
+			Backoff:  500 * time.Millisecond,
+			RetryableStderrPatterns: []string{
+				`SERVICE_UNAVAILABLE`,
+				`no Raft leader`,
+			},
+		}
+	}
 
 	for i := 0; i < network.Consensus.Brokers; i++ {
 		ports := Ports{}
@@ -320,6 +377,28 @@ func (n *Network) WriteOrdererConfig(o *Orderer, config *fabricconfig.Orderer) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+// OrdererBootstrapBlockPath returns the path to the bootstrap block placed in
+// the configuration directory of the specified orderer.
+func (n *Network) OrdererBootstrapBlockPath(o *Orderer) string {
+	return filepath.Join(n.OrdererDir(o), "bootstrap.block")
+}
+
+// SetOrdererBootstrapBlock copies the block at blockPath into the orderer's
+// configuration directory and configures the orderer to bootstrap from it.
+// The block replaces the genesis block generated for the system channel and
+// takes effect the next time the orderer starts.
+func (n *Network) SetOrdererBootstrapBlock(o *Orderer, blockPath string) {
+	blockBytes, err := ioutil.ReadFile(blockPath)
+	Expect(err).NotTo(HaveOccurred())
+	err = ioutil.WriteFile(n.OrdererBootstrapBlockPath(o), blockBytes, 0644)
+	Expect(err).NotTo(HaveOccurred())
+
+	ordererConfig := n.ReadOrdererConfig(o)
+	ordererConfig.General.BootstrapMethod = "file"
+	ordererConfig.General.BootstrapFile = n.OrdererBootstrapBlockPath(o)
+	n.WriteOrdererConfig(o, ordererConfig)
+}
+
 // ReadConfigTxConfig  unmarshals the configtx.yaml and returns an
 // object approximating its contents.
 func (n *Network) ReadConfigTxConfig() *fabricconfig.ConfigTx {
@@ -342,6 +421,59 @@ func (n *Network) WriteConfigTxConfig(config *fabricconfig.ConfigTx) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+// MutateProfile reads configtx.yaml, applies the mutation to the named
+// profile, and writes the result back. The profile must exist.
+func MutateProfile(n *Network, profileName string, mutate func(*fabricconfig.Channel)) {
+	config := n.ReadConfigTxConfig()
+	profile, ok := config.Profiles[profileName]
+	Expect(ok).To(BeTrue(), "profile %s not found in %s", profileName, n.ConfigTxConfigPath())
+	mutate(profile)
+	n.WriteConfigTxConfig(config)
+}
+
+// PropagateEnvToExternalBuilders adds the environment variables to the
+// variables propagated to every external builder. Bootstrap fails if any of
+// them is not set in the environment of the test process. The builders must
+// be configured before the config tree is generated.
+func (n *Network) PropagateEnvToExternalBuilders(vars ...string) {
+	for i := range n.ExternalBuilders {
+		n.propagateEnv(&n.ExternalBuilders[i], vars)
+	}
+}
+
+// PropagateEnvToExternalBuilder adds the environment variables to the
+// variables propagated to the named external builder. See
+// PropagateEnvToExternalBuilders.
+func (n *Network) PropagateEnvToExternalBuilder(name string, vars ...string) {
+	for i := range n.ExternalBuilders {
+		if n.ExternalBuilders[i].Name == name {
+			n.propagateEnv(&n.ExternalBuilders[i], vars)
+			return
+		}
+	}
+	ginkgo.Fail(fmt.Sprintf("external builder %s not found", name))
+}
+
+func (n *Network) propagateEnv(builder *fabricconfig.ExternalBuilder, vars []string) {
+	for _, v := range vars {
+		if !containsString(builder.PropagateEnvironment, v) {
+			builder.PropagateEnvironment = append(builder.PropagateEnvironment, v)
+		}
+		if !containsString(n.propagatedEnv, v) {
+			n.propagatedEnv = append(n.propagatedEnv, v)
+		}
+	}
+}
+
+func containsString(list []string, s string) bool {
+	for _, item := range list {
+		if item == s {
+			return true
+		}
+	}
+	return false
+}
+
 // PeerDir returns the path to the configuration directory for the specified
 // Peer.
 func (n *Network) PeerDir(p *Peer) string {
@@ -359,6 +491,12 @@ func (n *Network) PeerLedgerDir(p *Peer) string {
 	return filepath.Join(n.PeerDir(p), "filesystem/ledgersData")
 }
 
+// PeerChaincodeInstallDir returns the path to the directory where the peer
+// stores the chaincode packages installed through _lifecycle.
+func (n *Network) PeerChaincodeInstallDir(p *Peer) string {
+	return filepath.Join(n.PeerDir(p), "filesystem/lifecycle/chaincodes")
+}
+
 // ReadPeerConfig unmarshals a peer's core.yaml and returns an object
 // approximating its contents.
 func (n *Network) ReadPeerConfig(p *Peer) *fabricconfig.Core {
@@ -382,6 +520,102 @@ func (n *Network) WritePeerConfig(p *Peer, config *fabricconfig.Core) {
 	Expect(err).NotTo(HaveOccurred())
 }
 
+// SetBlockFileSize sets the size, in bytes, at which the peer's block store
+// rolls over to a new block file. The peer's configuration must already have
+// been generated and the change takes effect the next time the peer starts.
+func (n *Network) SetBlockFileSize(p *Peer, size int) {
+	core := n.ReadPeerConfig(p)
+	if core.Ledger == nil {
+		core.Ledger = &fabricconfig.Ledger{}
+	}
+	if core.Ledger.Blockchain == nil {
+		core.Ledger.Blockchain = &fabricconfig.BlockchainConfig{}
+	}
+	core.Ledger.Blockchain.MaxBlockfileSize = size
+	n.WritePeerConfig(p, core)
+}
+
+// PeerBlockFiles returns the paths of the block files in the peer's block
+// store for the specified channel.
+func (n *Network) PeerBlockFiles(p *Peer, channel string) []string {
+	files, err := filepath.Glob(filepath.Join(n.PeerLedgerDir(p), "chains", "chains", channel, "blockfile_*"))
+	Expect(err).NotTo(HaveOccurred())
+	return files
+}
+
+// SetDeliveryMode configures how the peer receives blocks from the ordering
+// service. When leaderElection is true, the peers of the organization elect
+// the peer that pulls blocks from the orderer; otherwise orgLeader determines
+// whether the peer statically pulls blocks from the orderer. A peer cannot be
+// a static leader while taking part in leader election.
+func (n *Network) SetDeliveryMode(p *Peer, leaderElection, orgLeader bool) {
+	Expect(leaderElection && orgLeader).To(BeFalse(), "peer %s cannot use leader election and be a static org leader", p.ID())
+
+	core := n.ReadPeerConfig(p)
+	core.Peer.Gossip.UseLeaderElection = leaderElection
+	core.Peer.Gossip.OrgLeader = orgLeader
+	n.WritePeerConfig(p, core)
+}
+
+// SetOrgLeaders disables leader election for the peers of an organization
+// and makes each of the provided peers a static org leader. The remaining
+// peers of the organization receive blocks from the leaders through gossip.
+func (n *Network) SetOrgLeaders(orgName string, leaders ...*Peer) {
+	for _, p := range leaders {
+		Expect(p.Organization).To(Equal(orgName), "peer %s is not in organization %s", p.ID(), orgName)
+	}
+	for _, p := range n.PeersInOrg(orgName) {
+		isLeader := false
+		for _, leader := range leaders {
+			if leader.ID() == p.ID() {
+				isLeader = true
+				break
+			}
+		}
+		n.SetDeliveryMode(p, false, isLeader)
+	}
+}
+
+// DeliveryClientTimeouts holds the timeouts used by a peer's delivery client
+// when connecting to the ordering service. Zero values leave the current
+// setting unchanged.
+type DeliveryClientTimeouts struct {
+	ConnTimeout                 time.Duration
+	ReConnectBackoffThreshold   time.Duration
+	ReconnectTotalTimeThreshold time.Duration
+}
+
+// SetDeliveryClientTimeouts updates the delivery client timeouts of the peer.
+func (n *Network) SetDeliveryClientTimeouts(p *Peer, timeouts DeliveryClientTimeouts) {
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Deliveryclient == nil {
+		core.Peer.Deliveryclient = &fabricconfig.DeliveryClient{}
+	}
+	dc := core.Peer.Deliveryclient
+	if timeouts.ConnTimeout != 0 {
+		dc.ConnTimeout = timeouts.ConnTimeout
+	}
+	if timeouts.ReConnectBackoffThreshold != 0 {
+		dc.ReConnectBackoffThreshold = timeouts.ReConnectBackoffThreshold
+	}
+	if timeouts.ReconnectTotalTimeThreshold != 0 {
+		dc.ReconnectTotalTimeThreshold = timeouts.ReconnectTotalTimeThreshold
+	}
+	n.WritePeerConfig(p, core)
+}
+
+// SetDeliveryClientCompression sets the gRPC compressor the peer uses on
+// deliver streams to the ordering service. Only "gzip" is supported and an
+// empty compression disables it, which is the default.
+func (n *Network) SetDeliveryClientCompression(p *Peer, compression string) {
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Deliveryclient == nil {
+		core.Peer.Deliveryclient = &fabricconfig.DeliveryClient{}
+	}
+	core.Peer.Deliveryclient.Compression = compression
+	n.WritePeerConfig(p, core)
+}
+
 // peerUserCryptoDir returns the path to the directory containing the
 // certificates and keys for the specified user of the peer.
 func (n *Network) peerUserCryptoDir(p *Peer, user, cryptoMaterialType string) string {
@@ -436,6 +670,25 @@ func (n *Network) OrdererOrgCADir(o *Organization) string {
 	)
 }
 
+// OrgTLSCACert returns the path to the certificate of the TLS CA of the
+// organization. Every organization is issued its own TLS CA.
+func (n *Network) OrgTLSCACert(o *Organization) string {
+	nodeOrganizationType := "peerOrganizations"
+	for _, org := range n.OrdererOrgs() {
+		if org.Name == o.Name {
+			nodeOrganizationType = "ordererOrganizations"
+		}
+	}
+
+	return filepath.Join(
+		n.CryptoPath(),
+		nodeOrganizationType,
+		o.Domain,
+		"tlsca",
+		fmt.Sprintf("tlsca.%s-cert.pem", o.Domain),
+	)
+}
+
 // PeerUserMSPDir returns the path to the MSP directory containing the
 // certificates and keys for the specified user of the peer.
 func (n *Network) PeerUserMSPDir(p *Peer, user string) string {
@@ -670,6 +923,22 @@ func (n *Network) CACertsBundlePath() string {
 	)
 }
 
+// PeerTLSCABundlePath returns the path to the bundle of TLS CA certificates
+// used by peer CLI sessions run in the context of the specified peer when
+// PeerTLSCABundles is set.
+func (n *Network) PeerTLSCABundlePath(p *Peer) string {
+	return filepath.Join(n.PeerDir(p), "tls-ca-certs.pem")
+}
+
+// peerTLSCABundle returns the bundle of TLS CA certificates used by peer CLI
+// sessions run in the context of the specified peer.
+func (n *Network) peerTLSCABundle(p *Peer) string {
+	if n.PeerTLSCABundles {
+		return n.PeerTLSCABundlePath(p)
+	}
+	return n.CACertsBundlePath()
+}
+
 // GenerateConfigTree generates the configuration documents required to
 // bootstrap a fabric network. A configuration file will be generated for
 // cryptogen, configtxgen, and for each peer and orderer. The contents of the
@@ -713,6 +982,11 @@ func (n *Network) GenerateConfigTree() {
 // the Network using the channel's Profile attribute. The transactions are
 // written to ${rootDir}/${Channel.Name}_tx.pb.
 func (n *Network) Bootstrap() {
+	for _, v := range n.propagatedEnv {
+		_, ok := os.LookupEnv(v)
+		Expect(ok).To(BeTrue(), "environment variable %s propagated to external builders is not set", v)
+	}
+
 	if n.DockerClient != nil {
 		n.createDockerNetwork()
 	}
@@ -726,6 +1000,14 @@ func (n *Network) Bootstrap() {
 
 	n.bootstrapIdemix()
 
+	if n.TLSCAValidity != 0 {
+		n.reissueTLSCACerts(n.TLSCAValidity)
+	}
+
+	if n.TLSIntermediateCAs {
+		n.issueTLSCertsFromIntermediateCAs()
+	}
+
 	sess, err = n.ConfigTxGen(commands.OutputBlock{
 		ChannelID:   n.SystemChannel.Name,
 		Profile:     n.SystemChannel.Profile,
@@ -747,6 +1029,7 @@ func (n *Network) Bootstrap() {
 		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
 	}
 
+	n.applyMSPConfigurers()
 	n.ConcatenateTLSCACertificates()
 }
 
@@ -863,15 +1146,46 @@ func (n *Network) bootstrapIdemix() {
 }
 
 // ConcatenateTLSCACertificates concatenates all TLS CA certificates into a
-// single file to be used by peer CLI.
+// single file to be used by peer CLI. When PeerTLSCABundles is set, a bundle
+// with the TLS CA certificates of every organization is also written for
+// each peer.
 func (n *Network) ConcatenateTLSCACertificates() {
+	writeTLSCABundle(n.CACertsBundlePath(), n.listTLSCACertificates())
+
+	if n.PeerTLSCABundles {
+		var orgs []*Organization
+		for _, org := range n.Organizations {
+			if org.MSPType != "idemix" {
+				orgs = append(orgs, org)
+			}
+		}
+		for _, p := range n.Peers {
+			n.WritePeerTLSCABundle(p, orgs...)
+		}
+	}
+}
+
+// WritePeerTLSCABundle replaces the bundle of TLS CA certificates used by
+// peer CLI sessions run in the context of the peer with the TLS CA
+// certificates of the provided organizations. Connections the CLI makes to
+// components of organizations that are not included will fail TLS
+// verification. The connections of the peer process are unaffected.
+func (n *Network) WritePeerTLSCABundle(p *Peer, orgs ...*Organization) {
+	var tlsCACertificates []string
+	for _, org := range orgs {
+		tlsCACertificates = append(tlsCACertificates, n.OrgTLSCACert(org))
+	}
+	writeTLSCABundle(n.PeerTLSCABundlePath(p), tlsCACertificates)
+}
+
+func writeTLSCABundle(path string, tlsCACertificates []string) {
 	bundle := &bytes.Buffer{}
-	for _, tlsCertPath := range n.listTLSCACertificates() {
+	for _, tlsCertPath := range tlsCACertificates {
 		certBytes, err := ioutil.ReadFile(tlsCertPath)
 		Expect(err).NotTo(HaveOccurred())
 		bundle.Write(certBytes)
 	}
-	err := ioutil.WriteFile(n.CACertsBundlePath(), bundle.Bytes(), 0660)
+	err := ioutil.WriteFile(path, bundle.Bytes(), 0660)
 	Expect(err).NotTo(HaveOccurred())
 }
 
@@ -897,6 +1211,9 @@ func (n *Network) listTLSCACertificates() []string {
 // Cleanup attempts to cleanup docker related artifacts that may
 // have been created by the network.
 func (n *Network) Cleanup() {
+	n.closeClusterProxies()
+	n.closeDeliverProxies()
+
 	if n.DockerClient == nil {
 		return
 	}
@@ -918,6 +1235,7 @@ func (n *Network) Cleanup() {
 			}
 		}
 	}
+	n.removeOrgDockerNetworks()
 
 	images, err := n.DockerClient.ListImages(docker.ListImagesOptions{All: true})
 	Expect(err).NotTo(HaveOccurred())
@@ -1222,9 +1540,15 @@ func (n *Network) BrokerGroupRunner() ifrit.Runner {
 // OrdererRunner returns an ifrit.Runner for the specified orderer. The runner
 // can be used to start and manage an orderer process.
 func (n *Network) OrdererRunner(o *Orderer, env ...string) *ginkgomon.Runner {
-	cmd := exec.Command(n.Components.Orderer())
+	cmd := exec.Command(n.ordererBinary(o))
 	cmd.Env = os.Environ()
 	cmd.Env = append(cmd.Env, fmt.Sprintf("FABRIC_CFG_PATH=%s", n.OrdererDir(o)))
+	if spec, ok := n.logSpecs[o.ID()]; ok {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("FABRIC_LOGGING_SPEC=%s", spec))
+	}
+	if gogc, ok := n.gcPercents[o.ID()]; ok {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GOGC=%s", gogc))
+	}
 	cmd.Env = append(cmd.Env, env...)
 
 	config := ginkgomon.Config{
@@ -1244,6 +1568,89 @@ func (n *Network) OrdererRunner(o *Orderer, env ...string) *ginkgomon.Runner {
 	return ginkgomon.New(config)
 }
 
+// SetOrdererLogSpec sets the initial logging spec of the orderer. The spec is
+// provided to the process through FABRIC_LOGGING_SPEC when a runner for the
+// orderer is created; environment passed to OrdererRunner takes precedence.
+func (n *Network) SetOrdererLogSpec(o *Orderer, spec string) {
+	n.setLogSpec(o.ID(), spec)
+}
+
+// SetPeerLogSpec sets the initial logging spec of the peer. The spec is
+// provided to the process through FABRIC_LOGGING_SPEC when a runner for the
+// peer is created; environment passed to PeerRunner takes precedence.
+func (n *Network) SetPeerLogSpec(p *Peer, spec string) {
+	n.setLogSpec(p.ID(), spec)
+}
+
+func (n *Network) setLogSpec(id, spec string) {
+	if n.logSpecs == nil {
+		n.logSpecs = map[string]string{}
+	}
+	n.logSpecs[id] = spec
+}
+
+// SetOrdererGOGC sets the garbage collection target percentage of the orderer
+// process. The value is provided to the process through GOGC when a runner
+// for the orderer is created and may be "off" to disable collection;
+// environment passed to OrdererRunner takes precedence.
+func (n *Network) SetOrdererGOGC(o *Orderer, gogc string) {
+	n.setGCPercent(o.ID(), gogc)
+}
+
+// SetPeerGOGC sets the garbage collection target percentage of the peer
+// process. The value is provided to the process through GOGC when a runner
+// for the peer is created and may be "off" to disable collection; environment
+// passed to PeerRunner takes precedence.
+func (n *Network) SetPeerGOGC(p *Peer, gogc string) {
+	n.setGCPercent(p.ID(), gogc)
+}
+
+func (n *Network) setGCPercent(id, gogc string) {
+	if n.gcPercents == nil {
+		n.gcPercents = map[string]string{}
+	}
+	n.gcPercents[id] = gogc
+}
+
+// SetOrdererBinary sets the path of the orderer binary run for the orderer,
+// replacing the one built from the tree. It allows networks that mix releases,
+// for example to upgrade a network one node at a time. The binary is used
+// when a runner for the orderer is created.
+func (n *Network) SetOrdererBinary(o *Orderer, binary string) {
+	n.setBinary(o.ID(), binary)
+}
+
+// SetPeerBinary sets the path of the peer binary run for the peer, replacing
+// the one built from the tree. The binary is used when a runner for the peer
+// is created; peer CLI sessions continue to use the binary built from the
+// tree.
+func (n *Network) SetPeerBinary(p *Peer, binary string) {
+	n.setBinary(p.ID(), binary)
+}
+
+func (n *Network) setBinary(id, binary string) {
+	_, err := os.Stat(binary)
+	Expect(err).NotTo(HaveOccurred())
+	if n.binaries == nil {
+		n.binaries = map[string]string{}
+	}
+	n.binaries[id] = binary
+}
+
+func (n *Network) ordererBinary(o *Orderer) string {
+	if binary, ok := n.binaries[o.ID()]; ok {
+		return binary
+	}
+	return n.Components.Orderer()
+}
+
+func (n *Network) peerBinary(p *Peer) string {
+	if binary, ok := n.binaries[p.ID()]; ok {
+		return binary
+	}
+	return n.Components.Peer()
+}
+
 // OrdererGroupRunner returns a runner that can be used to start and stop all
 // orderers in a network.
 func (n *Network) OrdererGroupRunner() ifrit.Runner {
@@ -1257,13 +1664,18 @@ func (n *Network) OrdererGroupRunner() ifrit.Runner {
 // PeerRunner returns an ifrit.Runner for the specified peer. The runner can be
 // used to start and manage a peer process.
 func (n *Network) PeerRunner(p *Peer, env ...string) *ginkgomon.Runner {
-	cmd := n.peerCommand(
-		commands.NodeStart{PeerID: p.ID()},
-		"",
+	cmd := NewCommand(n.peerBinary(p), commands.NodeStart{PeerID: p.ID()})
+	cmd.Env = append(cmd.Env,
 		fmt.Sprintf("FABRIC_CFG_PATH=%s", n.PeerDir(p)),
 		fmt.Sprintf("CORE_LEDGER_STATE_COUCHDBCONFIG_USERNAME=admin"),
 		fmt.Sprintf("CORE_LEDGER_STATE_COUCHDBCONFIG_PASSWORD=adminpw"),
 	)
+	if spec, ok := n.logSpecs[p.ID()]; ok {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("FABRIC_LOGGING_SPEC=%s", spec))
+	}
+	if gogc, ok := n.gcPercents[p.ID()]; ok {
+		cmd.Env = append(cmd.Env, fmt.Sprintf("GOGC=%s", gogc))
+	}
 	cmd.Env = append(cmd.Env, env...)
 
 	return ginkgomon.New(ginkgomon.Config{
@@ -1275,6 +1687,34 @@ func (n *Network) PeerRunner(p *Peer, env ...string) *ginkgomon.Runner {
 	})
 }
 
+// ChaincodeServerRunner returns a runner for a chaincode server that peers
+// reach through the ccaas external builder. The chaincode path must be the
+// server binary and the package ID must be set; the server listens on the
+// chaincode's ServerAddress.
+func (n *Network) ChaincodeServerRunner(c Chaincode) *ginkgomon.Runner {
+	Expect(c.PackageID).NotTo(BeEmpty())
+	Expect(c.ServerAddress).NotTo(BeEmpty())
+
+	serverDir := filepath.Join(n.RootDir, "chaincode-servers", strings.ReplaceAll(c.PackageID, ":", "-"))
+	err := os.MkdirAll(serverDir, 0755)
+	Expect(err).NotTo(HaveOccurred())
+	config, err := json.Marshal(map[string]string{"listen_address": c.ServerAddress})
+	Expect(err).NotTo(HaveOccurred())
+	err = ioutil.WriteFile(filepath.Join(serverDir, "config.json"), config, 0644)
+	Expect(err).NotTo(HaveOccurred())
+
+	cmd := exec.Command(c.Path, c.PackageID)
+	cmd.Dir = serverDir
+
+	return ginkgomon.New(ginkgomon.Config{
+		AnsiColorCode:     n.nextColor(),
+		Name:              c.PackageID,
+		Command:           cmd,
+		StartCheck:        fmt.Sprintf("Starting chaincode %s at %s", c.PackageID, c.ServerAddress),
+		StartCheckTimeout: 15 * time.Second,
+	})
+}
+
 // PeerGroupRunner returns a runner that can be used to start and stop all
 // peers in a network.
 func (n *Network) PeerGroupRunner() ifrit.Runner {
@@ -1296,13 +1736,26 @@ func (n *Network) NetworkGroupRunner() ifrit.Runner {
 	return grouper.NewOrdered(syscall.SIGTERM, members)
 }
 
-func (n *Network) peerCommand(command Command, tlsDir string, env ...string) *exec.Cmd {
+// Restart stops every process in the network, waits for them to exit, and
+// starts them again with NetworkGroupRunner. The contents of RootDir,
+// including the ledgers of the peers and orderers, are preserved across the
+// restart. The returned process replaces the one that was stopped.
+func (n *Network) Restart(process ifrit.Process) ifrit.Process {
+	process.Signal(syscall.SIGTERM)
+	Eventually(process.Wait(), n.EventuallyTimeout).Should(Receive())
+
+	process = ifrit.Invoke(n.NetworkGroupRunner())
+	Eventually(process.Ready(), n.EventuallyTimeout).Should(BeClosed())
+	return process
+}
+
+func (n *Network) peerCommand(command Command, tlsDir, caBundle string, env ...string) *exec.Cmd {
 	cmd := NewCommand(n.Components.Peer(), command)
 	cmd.Env = append(cmd.Env, env...)
 
 	if connectsToOrderer(command) {
 		cmd.Args = append(cmd.Args, "--tls")
-		cmd.Args = append(cmd.Args, "--cafile", n.CACertsBundlePath())
+		cmd.Args = append(cmd.Args, "--cafile", caBundle)
 	}
 
 	if clientAuthEnabled(command) {
@@ -1320,7 +1773,7 @@ func (n *Network) peerCommand(command Command, tlsDir string, env ...string) *ex
 	requiredPeerAddresses := flagCount("--peerAddresses", cmd.Args)
 	for i := 0; i < requiredPeerAddresses; i++ {
 		cmd.Args = append(cmd.Args, "--tlsRootCertFiles")
-		cmd.Args = append(cmd.Args, n.CACertsBundlePath())
+		cmd.Args = append(cmd.Args, caBundle)
 	}
 	return cmd
 }
@@ -1367,6 +1820,7 @@ func (n *Network) PeerUserSession(p *Peer, user string, command Command) (*gexec
 	cmd := n.peerCommand(
 		command,
 		n.PeerUserTLSDir(p, user),
+		n.peerTLSCABundle(p),
 		fmt.Sprintf("FABRIC_CFG_PATH=%s", n.PeerDir(p)),
 		fmt.Sprintf("CORE_PEER_MSPCONFIGPATH=%s", n.PeerUserMSPDir(p, user)),
 	)
@@ -1380,6 +1834,7 @@ func (n *Network) IdemixUserSession(p *Peer, idemixOrg *Organization, user strin
 	cmd := n.peerCommand(
 		command,
 		n.PeerUserTLSDir(p, user),
+		n.peerTLSCABundle(p),
 		fmt.Sprintf("FABRIC_CFG_PATH=%s", n.PeerDir(p)),
 		fmt.Sprintf("CORE_PEER_MSPCONFIGPATH=%s", n.IdemixUserMSPDir(idemixOrg, user)),
 		fmt.Sprintf("CORE_PEER_LOCALMSPTYPE=%s", "idemix"),
@@ -1394,6 +1849,7 @@ func (n *Network) OrdererAdminSession(o *Orderer, p *Peer, command Command) (*ge
 	cmd := n.peerCommand(
 		command,
 		n.ordererUserCryptoDir(o, "Admin", "tls"),
+		n.peerTLSCABundle(p),
 		fmt.Sprintf("CORE_PEER_LOCALMSPID=%s", n.Organization(o.Organization).MSPID),
 		fmt.Sprintf("FABRIC_CFG_PATH=%s", n.PeerDir(p)),
 		fmt.Sprintf("CORE_PEER_MSPCONFIGPATH=%s", n.OrdererUserMSPDir(o, "Admin")),
@@ -1584,11 +2040,7 @@ func (n *Network) OrgsForOrderers(ordererNames []string) []*Organization {
 		orgName := n.Orderer(name).Organization
 		orgsByName[orgName] = n.Organization(orgName)
 	}
-	orgs := []*Organization{}
-	for _, org := range orgsByName {
-		orgs = append(orgs, org)
-	}
-	return orgs
+	return sortedOrgs(orgsByName)
 }
 
 // OrdererOrgs returns all Organization instances that own at least one
@@ -1599,10 +2051,18 @@ func (n *Network) OrdererOrgs() []*Organization {
 		orgsByName[o.Organization] = n.Organization(o.Organization)
 	}
 
+	return sortedOrgs(orgsByName)
+}
+
+// sortedOrgs returns the organizations of the map ordered by name. This keeps
+// the generated configuration stable when multiple orderer organizations
+// exist.
+func sortedOrgs(orgsByName map[string]*Organization) []*Organization {
 	orgs := []*Organization{}
 	for _, org := range orgsByName {
 		orgs = append(orgs, org)
 	}
+	sort.Slice(orgs, func(i, j int) bool { return orgs[i].Name < orgs[j].Name })
 	return orgs
 }
 
@@ -1654,6 +2114,30 @@ func BrokerPortNames() []PortName {
 	return []PortName{HostPort}
 }
 
+// EtcdRaftOptions returns the etcdraft options for the genesis block with
+// the template defaults applied.
+func (n *Network) EtcdRaftOptions() EtcdRaftOptions {
+	options := EtcdRaftOptions{
+		TickInterval:         "500ms",
+		SnapshotIntervalSize: "1 KB",
+	}
+	if n.Consensus.EtcdRaftOptions == nil {
+		return options
+	}
+
+	configured := n.Consensus.EtcdRaftOptions
+	if configured.TickInterval != "" {
+		options.TickInterval = configured.TickInterval
+	}
+	if configured.SnapshotIntervalSize != "" {
+		options.SnapshotIntervalSize = configured.SnapshotIntervalSize
+	}
+	options.ElectionTick = configured.ElectionTick
+	options.HeartbeatTick = configured.HeartbeatTick
+	options.MaxInflightBlocks = configured.MaxInflightBlocks
+	return options
+}
+
 // BrokerAddresses returns the list of broker addresses for the network.
 func (n *Network) BrokerAddresses(portName PortName) []string {
 	addresses := []string{}
@@ -1690,6 +2174,22 @@ func (n *Network) PeerAddress(p *Peer, portName PortName) string {
 	return fmt.Sprintf("127.0.0.1:%d", n.PeerPort(p, portName))
 }
 
+// PeerAddresses returns the addresses of the named port for each of the
+// provided peers, in order.
+func PeerAddresses(n *Network, portName PortName, peers ...*Peer) []string {
+	addresses := make([]string, 0, len(peers))
+	for _, p := range peers {
+		addresses = append(addresses, n.PeerAddress(p, portName))
+	}
+	return addresses
+}
+
+// AllPeerAddressesForChannel returns the addresses of the named port for
+// every peer that has joined the named channel.
+func AllPeerAddressesForChannel(n *Network, channel string, portName PortName) []string {
+	return PeerAddresses(n, portName, n.PeersWithChannel(channel)...)
+}
+
 // PeerPort returns the named port reserved for the Peer instance.
 func (n *Network) PeerPort(p *Peer, portName PortName) uint16 {
 	peerPorts := n.PortsByPeerID[p.ID()]
@@ -1783,6 +2283,66 @@ func (n *Network) StartSession(cmd *exec.Cmd, name string) (*gexec.Session, erro
 	)
 }
 
+// SessionRetry configures how SessionWithRetry re-runs commands that fail
+// with recoverable errors.
+type SessionRetry struct {
+	// Attempts is the maximum number of times a command is run.
+	Attempts int
+	// Backoff is the delay before the first retry. The delay doubles after
+	// every subsequent failure.
+	Backoff time.Duration
+	// RetryableStderrPatterns are regular expressions matched against the
+	// stderr of a failed command. The command is only re-run when one of
+	// them matches.
+	RetryableStderrPatterns []string
+}
+
+// SessionWithRetry starts a session with the provided function and waits for
+// it to exit. When the session exits with a non-zero status and its stderr
+// matches one of the retryable patterns of n.SessionRetry, the session is
+// started again after a backoff. The last session is returned.
+//
+// For example:
+//   sess, err := n.SessionWithRetry(func() (*gexec.Session, error) {
+//       return n.PeerUserSession(peer, "User1", invokeCommand)
+//   })
+func (n *Network) SessionWithRetry(start func() (*gexec.Session, error)) (*gexec.Session, error) {
+	retry := n.SessionRetry
+	if retry == nil {
+		retry = &SessionRetry{Attempts: 1}
+	}
+
+	var retryable []*regexp.Regexp
+	for _, pattern := range retry.RetryableStderrPatterns {
+		retryable = append(retryable, regexp.MustCompile(pattern))
+	}
+
+	backoff := retry.Backoff
+	for attempt := 1; ; attempt++ {
+		sess, err := start()
+		if err != nil {
+			return nil, err
+		}
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit())
+		if sess.ExitCode() == 0 || attempt >= retry.Attempts || !matchesAny(retryable, sess.Err.Contents()) {
+			return sess, nil
+		}
+
+		fmt.Fprintf(ginkgo.GinkgoWriter, "retrying session after recoverable failure (attempt %d of %d)\n", attempt, retry.Attempts)
+		time.Sleep(backoff)
+		backoff *= 2
+	}
+}
+
+func matchesAny(patterns []*regexp.Regexp, b []byte) bool {
+	for _, pattern := range patterns {
+		if pattern.Match(b) {
+			return true
+		}
+	}
+	return false
+}
+
 func (n *Network) GenerateCryptoConfig() {
 	crypto, err := os.Create(n.CryptoConfigPath())
 	Expect(err).NotTo(HaveOccurred())
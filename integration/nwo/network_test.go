@@ -7,21 +7,36 @@ SPDX-License-Identifier: Apache-2.0
 package nwo_test
 
 import (
+	"bytes"
+	"crypto/x509"
+	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
+	"runtime"
 	"syscall"
+	"time"
 
 	docker "github.com/fsouza/go-dockerclient"
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	protosorderer "github.com/hyperledger/fabric-protos-go/orderer"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/integration/chaincode/simple"
 	"github.com/hyperledger/fabric/integration/nwo"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	"github.com/hyperledger/fabric/protoutil"
 
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
 	"github.com/onsi/gomega/gbytes"
 	"github.com/onsi/gomega/gexec"
 	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/ginkgomon"
+	"github.com/tedsuo/ifrit/grouper"
 	yaml "gopkg.in/yaml.v2"
 )
 
@@ -118,6 +133,1751 @@ var _ = Describe("Network", func() {
 			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
 
 			RunQueryInvokeQuery(network, orderer, peer, 100)
+
+			By("verifying the transaction was endorsed by the targeted peers")
+			endorsers := nwo.InvokeWithEndorsers(network, orderer, peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","10"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, network.Peer("org1", "peer2"), network.Peer("org2", "peer1")),
+				ClientAuth:    network.ClientAuthRequired,
+			})
+			Expect(endorsers).To(ConsistOf(
+				nwo.Endorser{MSPID: "Org1ExampleCom", CommonName: "peer2.org1.example.com"},
+				nwo.Endorser{MSPID: "Org2ExampleCom", CommonName: "peer1.org2.example.com"},
+			))
+
+			By("querying the state of each peer")
+			for _, p := range network.PeersWithChannel("testchannel") {
+				Eventually(func() string {
+					return nwo.QueryChaincodeOnPeer(network, p, "testchannel", "mycc", `{"Args":["query","a"]}`)
+				}, network.EventuallyTimeout).Should(Equal("80"))
+			}
+
+			By("checking that each peer built a single image for the package")
+			images := nwo.ChaincodeImagesForNetwork(client, network)
+			Expect(images).To(HaveLen(len(network.PeersWithChannel("testchannel"))))
+			for _, p := range network.PeersWithChannel("testchannel") {
+				Expect(images).To(ContainElement(HavePrefix(network.NetworkID+"-"+p.ID()+"-my_simple_chaincode")), "no image built by %s", p.ID())
+			}
+
+			By("relaunching the chaincode without rebuilding its image")
+			Expect(nwo.KillChaincodeContainersForPeer(client, network, peer)).NotTo(BeEmpty())
+			Eventually(func() string {
+				return nwo.QueryChaincodeOnPeer(network, peer, "testchannel", "mycc", `{"Args":["query","a"]}`)
+			}, network.EventuallyTimeout).Should(Equal("80"))
+			Expect(nwo.ChaincodeImagesForNetwork(client, network)).To(Equal(images))
+
+			By("measuring the latency of committed invokes")
+			latencies := nwo.TimedInvokes(network, orderer, peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","1"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, network.Peer("org1", "peer2"), network.Peer("org2", "peer1")),
+				ClientAuth:    network.ClientAuthRequired,
+			}, 5)
+			Expect(latencies).To(HaveLen(5))
+			Expect(nwo.LatencyPercentile(latencies, 50)).To(BeNumerically(">", 0))
+			Expect(nwo.LatencyPercentile(latencies, 50)).To(BeNumerically("<=", nwo.LatencyPercentile(latencies, 99)))
+
+			By("measuring the commit time of the blocks of committed invokes")
+			commitTimes := nwo.MeasureCommitTime(network, orderer, peer, "testchannel", func() {
+				nwo.TimedInvokes(network, orderer, peer, commands.ChaincodeInvoke{
+					ChannelID:     "testchannel",
+					Name:          "mycc",
+					Ctor:          `{"Args":["invoke","a","b","1"]}`,
+					PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, network.Peer("org1", "peer2"), network.Peer("org2", "peer1")),
+					ClientAuth:    network.ClientAuthRequired,
+				}, 3)
+			})
+			Expect(commitTimes).To(HaveLen(3), "expected one block per invoke")
+			for _, d := range commitTimes {
+				Expect(d).To(BeNumerically(">", 0))
+			}
+
+			By("querying the chaincode as an enrolled user")
+			nwo.EnrollUser(network, "org1", "Auditor")
+			sess, err := network.PeerUserSession(peer, "Auditor", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("verifying the chaincode observes the identity of the enrolled user")
+			var creator simple.Creator
+			nwo.AssertCreatorMSP(network, peer, "Auditor", "testchannel", "mycc", "Org1ExampleCom", &creator)
+			if network.Organization("org1").EnableNodeOUs {
+				Expect(creator.OrganizationalUnit).To(ContainElement("client"))
+			}
+			nwo.AssertCreatorMSP(network, network.Peer("org2", "peer1"), "User1", "testchannel", "mycc", "Org2ExampleCom", &simple.Creator{})
+		})
+
+		It("invokes chaincode whose definition does not require initialization", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincodeNoInit(network, "testchannel", orderer, chaincode)
+
+			By("invoking the chaincode without initializing it")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["sleep","0s","a","100"]}`,
+				PeerAddresses: []string{network.PeerAddress(peer, nwo.ListenPort)},
+				WaitForEvent:  true,
+				ClientAuth:    network.ClientAuthRequired,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess.Err).To(gbytes.Say("Chaincode invoke successful. result: status:200"))
+
+			sess, err = network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess).To(gbytes.Say("100"))
+
+			By("rejecting an init transaction")
+			sess, err = network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["init","a","100","b","200"]}`,
+				PeerAddresses: []string{network.PeerAddress(peer, nwo.ListenPort)},
+				WaitForEvent:  true,
+				IsInit:        true,
+				ClientAuth:    network.ClientAuthRequired,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say("chaincode 'mycc' does not require initialization but called as init"))
+		})
+
+		It("preserves ledgers across a restart of the network", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			legacyChaincode := nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, legacyChaincode)
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+
+			peers := network.PeersWithChannel("testchannel")
+			height := nwo.GetMaxLedgerHeight(network, "testchannel", peers...)
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", height, peers...)
+
+			By("restarting the network")
+			process = network.Restart(process)
+
+			By("verifying the ledger height is unchanged")
+			for _, p := range peers {
+				Expect(nwo.GetLedgerHeight(network, p, "testchannel")).To(Equal(height))
+			}
+
+			By("verifying the state survived the restart")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess).To(gbytes.Say("90"))
+		})
+
+		It("reads chaincode state directly from the leveldb state database", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			legacyChaincode := nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, legacyChaincode)
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+
+			By("querying the state through the chaincode")
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("reading the state from the leveldb state database of the stopped peer")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			Expect(nwo.ReadStateDB(network, peer, "testchannel", "mycc", "a")).To(Equal(bytes.TrimSpace(sess.Out.Contents())))
+			Expect(nwo.ReadStateDB(network, peer, "testchannel", "mycc", "c")).To(BeNil())
+		})
+
+		It("follows the chain across a restart with a block subscriber", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			network.CreateAndJoinChannels(orderer)
+
+			subscriber := nwo.NewBlockSubscriber(network, peer, "testchannel", 0)
+			defer subscriber.Stop()
+
+			var received []uint64
+			receive := func(count int) {
+				for i := 0; i < count; i++ {
+					var block *common.Block
+					Eventually(subscriber.Blocks(), network.EventuallyTimeout).Should(Receive(&block))
+					received = append(received, block.Header.Number)
+				}
+			}
+
+			By("receiving the blocks committed before the restart")
+			height := nwo.GetLedgerHeight(network, peer, "testchannel")
+			receive(height)
+
+			By("restarting the network")
+			process = network.Restart(process)
+
+			By("receiving the blocks committed after the restart")
+			network.UpdateChannelAnchors(orderer, "testchannel")
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			newHeight := nwo.GetLedgerHeight(network, peer, "testchannel")
+			Expect(newHeight).To(BeNumerically(">", height))
+			receive(newHeight - height)
+
+			By("verifying no blocks were skipped or duplicated")
+			for i, number := range received {
+				Expect(number).To(Equal(uint64(i)))
+			}
+			Consistently(subscriber.Blocks()).ShouldNot(Receive())
+		})
+
+		It("enables capabilities only once and rejects unknown capabilities", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer, org2Peer := network.Peer("org1", "peer1"), network.Peer("org2", "peer1")
+
+			network.CreateAndJoinChannels(orderer)
+
+			By("enabling the V2_0 application capability")
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, org1Peer, org2Peer)
+			Expect(nwo.CurrentCapabilities(network, "testchannel", "Application", orderer, org1Peer)).To(Equal([]string{"V2_0"}))
+
+			By("waiting for all peers to receive the config update")
+			sequence := nwo.GetConfig(network, org1Peer, orderer, "testchannel").Sequence
+			nwo.WaitUntilConfigBlockReceived(network, "testchannel", sequence, network.PeersWithChannel("testchannel")...)
+
+			By("enabling the V2_0 application capability again")
+			configBlockNumber := nwo.CurrentConfigBlockNumber(network, org1Peer, orderer, "testchannel")
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, org1Peer, org2Peer)
+			Expect(nwo.CurrentConfigBlockNumber(network, org1Peer, orderer, "testchannel")).To(Equal(configBlockNumber))
+
+			By("attempting to enable an unknown orderer capability")
+			config := nwo.GetConfig(network, org1Peer, orderer, "testchannel")
+			updatedConfig := proto.Clone(config).(*common.Config)
+			updatedConfig.ChannelGroup.Groups["Orderer"].Values["Capabilities"] = &common.ConfigValue{
+				ModPolicy: "Admins",
+				Value: protoutil.MarshalOrPanic(&common.Capabilities{
+					Capabilities: map[string]*common.Capability{
+						"V2_0": {},
+						"V9_9": {},
+					},
+				}),
+			}
+			sess := nwo.UpdateOrdererConfigSession(network, orderer, "testchannel", config, updatedConfig, org1Peer, orderer)
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`config requires unsupported orderer capabilities: Orderer capability V9_9 is required but not supported`))
+			Expect(nwo.CurrentCapabilities(network, "testchannel", "Orderer", orderer, org1Peer)).To(Equal([]string{"V2_0"}))
+		})
+
+		It("sets implicit meta policies on the channel", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer, org2Peer := network.Peer("org1", "peer1"), network.Peer("org2", "peer1")
+
+			network.CreateAndJoinChannels(orderer)
+
+			By("relaxing the application admins policy to any org admin")
+			nwo.SetChannelPolicy(network, orderer, "testchannel", "Application", "Admins", &common.ImplicitMetaPolicy{
+				SubPolicy: "Admins",
+				Rule:      common.ImplicitMetaPolicy_ANY,
+			}, org1Peer, org2Peer)
+
+			By("requiring all org admins with an update signed by a single org")
+			nwo.SetChannelPolicy(network, orderer, "testchannel", "Application", "Admins", &common.ImplicitMetaPolicy{
+				SubPolicy: "Admins",
+				Rule:      common.ImplicitMetaPolicy_ALL,
+			}, org1Peer)
+			config := nwo.GetConfig(network, org1Peer, orderer, "testchannel")
+			implicitMeta := &common.ImplicitMetaPolicy{}
+			err := proto.Unmarshal(config.ChannelGroup.Groups["Application"].Policies["Admins"].Policy.Value, implicitMeta)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(implicitMeta.Rule).To(Equal(common.ImplicitMetaPolicy_ALL))
+
+			By("rejecting an update signed by a single org")
+			sess := nwo.SetChannelPolicySession(network, orderer, "testchannel", "Application", "Admins", &common.ImplicitMetaPolicy{
+				SubPolicy: "Admins",
+				Rule:      common.ImplicitMetaPolicy_MAJORITY,
+			}, org1Peer)
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`implicit policy evaluation failed - 1 sub-policies were satisfied, but this policy requires 2 of the 'Admins' sub-policies to be satisfied`))
+		})
+
+		It("restricts access to resources with channel ACLs", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer, org2Peer := network.Peer("org1", "peer1"), network.Peer("org2", "peer1")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, org1Peer, org2Peer)
+			Expect(nwo.ChannelACLs(network, org1Peer, orderer, "testchannel")).NotTo(HaveKey("_lifecycle/CommitChaincodeDefinition"))
+
+			By("restricting the commit of chaincode definitions to channel admins")
+			nwo.SetChannelACL(network, orderer, "testchannel", "_lifecycle/CommitChaincodeDefinition", "/Channel/Application/Admins", org1Peer, org2Peer)
+			Expect(nwo.ChannelACLs(network, org1Peer, orderer, "testchannel")).To(HaveKeyWithValue("_lifecycle/CommitChaincodeDefinition", "/Channel/Application/Admins"))
+
+			By("approving the chaincode definition for both organizations")
+			nwo.PackageAndInstallChaincode(network, chaincode, org1Peer, org2Peer)
+			nwo.ApproveChaincodeForMyOrg(network, "testchannel", orderer, chaincode, org1Peer, org2Peer)
+			nwo.CheckCommitReadinessUntilReady(network, "testchannel", chaincode, network.PeerOrgs(), org1Peer, org2Peer)
+
+			By("rejecting a commit submitted by a member that is not an admin")
+			sess, err := network.PeerUserSession(org1Peer, "User1", commands.ChaincodeCommit{
+				ChannelID:       "testchannel",
+				Orderer:         network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:            chaincode.Name,
+				Version:         chaincode.Version,
+				Sequence:        chaincode.Sequence,
+				SignaturePolicy: chaincode.SignaturePolicy,
+				PeerAddresses:   nwo.PeerAddresses(network, nwo.ListenPort, org1Peer, org2Peer),
+				ClientAuth:      network.ClientAuthRequired,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`Failed to authorize invocation due to failed ACL check`))
+		})
+
+		It("updates the anchor peers of an organization", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer1, org1Peer2 := network.Peer("org1", "peer1"), network.Peer("org1", "peer2")
+
+			network.CreateAndJoinChannels(orderer)
+			network.UpdateChannelAnchors(orderer, "testchannel")
+			anchorPeers := nwo.AnchorPeersForChannel(network, org1Peer1, orderer, "testchannel", "org1")
+			Expect(anchorPeers).To(HaveLen(1))
+			Expect(anchorPeers[0].Host).To(Equal("127.0.0.1"))
+			Expect(anchorPeers[0].Port).To(Equal(int32(network.PeerPort(org1Peer1, nwo.ListenPort))))
+
+			By("replacing the anchor peer of org1")
+			nwo.UpdateAnchorPeers(network, orderer, "testchannel", "org1", org1Peer2)
+			anchorPeers = nwo.AnchorPeersForChannel(network, org1Peer1, orderer, "testchannel", "org1")
+			Expect(anchorPeers).To(HaveLen(1))
+			Expect(anchorPeers[0].Host).To(Equal("127.0.0.1"))
+			Expect(anchorPeers[0].Port).To(Equal(int32(network.PeerPort(org1Peer2, nwo.ListenPort))))
+			Expect(network.AnchorsInOrg("org1")).To(ConsistOf(org1Peer2))
+
+			By("verifying cross-organization membership with the new anchor peer")
+			network.VerifyMembership(network.PeersWithChannel("testchannel"), "testchannel")
+
+			By("verifying each peer reports every other peer as a gossip member")
+			peers := network.PeersWithChannel("testchannel")
+			nwo.WaitForGossipMembership(network, "testchannel", len(peers)-1, peers...)
+		})
+
+		It("adds an organization to a running channel", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer := network.Peer("org1", "peer2")
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+
+			By("adding org3 to the channel")
+			org3 := &nwo.Organization{
+				Name:          "org3",
+				MSPID:         "Org3ExampleCom",
+				Domain:        "org3.example.com",
+				EnableNodeOUs: true,
+				Users:         2,
+				CA:            &nwo.CA{Hostname: "ca"},
+			}
+			org3Peer := &nwo.Peer{Name: "peer1", Organization: "org3"}
+			network.AddOrg(org3, org3Peer)
+			nwo.MutateProfile(network, "TwoOrgsChannel", func(profile *fabricconfig.Channel) {
+				profile.Capabilities = map[string]bool{"V2_0": true}
+			})
+			org3Process := nwo.AddOrgToChannel(network, "testchannel", orderer, org3)
+			defer func() {
+				org3Process.Signal(syscall.SIGTERM)
+				Eventually(org3Process.Wait(), network.EventuallyTimeout).Should(Receive())
+			}()
+
+			By("keeping the changes made to configtx.yaml before the organization was added")
+			Expect(network.ReadConfigTxConfig().Profiles["TwoOrgsChannel"].Capabilities).To(Equal(map[string]bool{"V2_0": true}))
+
+			config := nwo.GetConfig(network, org1Peer, orderer, "testchannel")
+			Expect(config.ChannelGroup.Groups["Application"].Groups).To(HaveKey("org3"))
+			Expect(network.PeersWithChannel("testchannel")).To(ContainElement(org3Peer))
+
+			By("deploying chaincode that requires an endorsement from org3")
+			nwo.DeployChaincode(network, "testchannel", orderer, nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `AND ('Org1ExampleCom.member','Org3ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			})
+
+			By("invoking the chaincode with an endorsement from the org3 peer")
+			endorsers := nwo.InvokeWithEndorsers(network, orderer, org3Peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","10"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, org1Peer, org3Peer),
+				ClientAuth:    network.ClientAuthRequired,
+			})
+			Expect(endorsers).To(ContainElement(nwo.Endorser{MSPID: "Org3ExampleCom", CommonName: "peer1.org3.example.com"}))
+			Eventually(func() string {
+				return nwo.QueryChaincodeOnPeer(network, org1Peer, "testchannel", "mycc", `{"Args":["query","a"]}`)
+			}, network.EventuallyTimeout).Should(Equal("90"))
+		})
+
+		It("removes an organization from a channel", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer := network.Peer("org1", "peer2")
+			org2Peer := network.Peer("org2", "peer1")
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), org2Peer)
+			nwo.DeployChaincode(network, "testchannel", orderer, nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			})
+			invoke := commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","10"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, org1Peer, org2Peer),
+				WaitForEvent:  true,
+				ClientAuth:    network.ClientAuthRequired,
+			}
+			sess, err := network.PeerUserSession(org1Peer, "User1", invoke)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+
+			By("removing org2 from the channel")
+			nwo.RemoveOrgFromChannel(network, "testchannel", orderer, network.Organization("org2"))
+			config := nwo.GetConfig(network, org1Peer, orderer, "testchannel")
+			Expect(config.ChannelGroup.Groups["Application"].Groups).NotTo(HaveKey("org2"))
+			Expect(network.PeersWithChannel("testchannel")).NotTo(ContainElement(org2Peer))
+
+			By("checking that the AND(org1, org2) policy can no longer be satisfied")
+			sess, err = network.PeerUserSession(org1Peer, "User1", invoke)
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`\QError: transaction invalidated with status (ENDORSEMENT_POLICY_FAILURE)\E`))
+			Expect(nwo.QueryChaincodeOnPeer(network, org1Peer, "testchannel", "mycc", `{"Args":["query","a"]}`)).To(Equal("90"))
+
+			By("checking that org2 can no longer pull blocks from the orderer")
+			sess, err = network.PeerAdminSession(org2Peer, commands.ChannelFetch{
+				ChannelID:  "testchannel",
+				Block:      "newest",
+				Orderer:    network.OrdererAddress(orderer, nwo.ListenPort),
+				OutputFile: filepath.Join(tempDir, "newest_block.pb"),
+				ClientAuth: network.ClientAuthRequired,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`\Qcan't read the block: &{FORBIDDEN}\E`))
+		})
+
+		It("rolls the block store over to new block files", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			By("restarting the network with a small block file size")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetBlockFileSize(peer, 4096)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateAndJoinChannels(orderer)
+			network.UpdateChannelAnchors(orderer, "testchannel")
+			height := nwo.GetMaxLedgerHeight(network, "testchannel", network.PeersWithChannel("testchannel")...)
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", height, network.PeersWithChannel("testchannel")...)
+
+			By("verifying the peer wrote multiple block files")
+			Expect(len(network.PeerBlockFiles(peer, "testchannel"))).To(BeNumerically(">", 1))
+
+			By("verifying peers with the default size kept a single block file")
+			Expect(network.PeerBlockFiles(network.Peer("org2", "peer1"), "testchannel")).To(HaveLen(1))
+		})
+
+		It("fails transactions that exceed the chaincode execute timeout", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			By("restarting the network with a short execute timeout")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			for _, p := range network.Peers {
+				network.SetChaincodeExecuteTimeout(p, 2*time.Second)
+			}
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+
+			By("invoking chaincode that runs longer than the timeout")
+			height := nwo.GetLedgerHeight(network, peer, "testchannel")
+			nwo.InvokeExpectingError(network, peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "mycc",
+				Ctor:          `{"Args":["sleep","5s","slow","done"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, peer),
+				WaitForEvent:  true,
+				ClientAuth:    network.ClientAuthRequired,
+			}, "timeout expired while executing transaction")
+
+			By("verifying the transaction was not committed")
+			Consistently(func() int { return nwo.GetLedgerHeight(network, peer, "testchannel") }, 5*time.Second).Should(Equal(height))
+			sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","slow"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+
+			By("invoking chaincode that completes within the timeout")
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+		})
+
+		It("aborts the launch of chaincode that does not register within the startup timeout", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			By("restarting the network with a short startup timeout")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			for _, p := range network.Peers {
+				network.SetChaincodeStartupTimeout(p, 5*time.Second)
+			}
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincode(network, "testchannel", orderer, nwo.Chaincode{
+				Name:            "slowcc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/slowstart/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "slowstart.tar.gz"),
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				Label:           "my_slowstart_chaincode",
+			})
+
+			By("invoking the chaincode before it registers")
+			nwo.InvokeExpectingError(network, peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+				Name:          "slowcc",
+				Ctor:          `{"Args":["mspid"]}`,
+				PeerAddresses: nwo.PeerAddresses(network, nwo.ListenPort, peer),
+				ClientAuth:    network.ClientAuthRequired,
+			}, "timeout expired while starting chaincode my_slowstart_chaincode:[0-9a-f]+ for transaction")
+		})
+
+		It("isolates the peer from chaincode that panics", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+
+			By("invoking a transaction that panics in the chaincode")
+			nwo.InvokeExpectingChaincodeError(network, peer, "testchannel", "mycc", `{"Args":["panic","boom"]}`, "chaincode stream terminated")
+
+			By("relaunching the chaincode for the next transaction")
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+			Expect(nwo.ChaincodeContainersForPeer(client, network, peer)).To(HaveLen(1))
+		})
+
+		It("attaches chaincode containers to the docker network of the organization", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			By("restarting the network with a docker network for org1")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.CreateOrgDockerNetwork("org1")
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+
+			By("verifying the chaincode containers joined the expected networks")
+			org1Containers := nwo.ChaincodeContainersForPeer(client, network, peer)
+			Expect(org1Containers).To(HaveLen(1))
+			Expect(org1Containers[0].Networks.Networks).To(HaveKey(network.OrgDockerNetworkName("org1")))
+
+			org2Containers := nwo.ChaincodeContainersForPeer(client, network, network.Peer("org2", "peer2"))
+			Expect(org2Containers).To(HaveLen(1))
+			Expect(org2Containers[0].Networks.Networks).To(HaveKey("host"))
+		})
+
+		It("applies security options to chaincode containers", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			By("restarting the network with a seccomp profile for chaincode")
+			profile := filepath.Join(tempDir, "seccomp.json")
+			err := ioutil.WriteFile(profile, []byte(`{"defaultAction":"SCMP_ACT_ALLOW"}`), 0644)
+			Expect(err).NotTo(HaveOccurred())
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetChaincodeSeccompProfile(profile)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+
+			By("verifying the chaincode container was created with the profile")
+			containers := nwo.ChaincodeContainersForPeer(client, network, peer)
+			Expect(containers).To(HaveLen(1))
+			container, err := client.InspectContainer(containers[0].ID)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(container.HostConfig.SecurityOpt).To(ConsistOf(HavePrefix("seccomp=")))
+		})
+
+		It("detects a corrupted block file when the peer restarts", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+			network.CreateAndJoinChannels(orderer)
+			network.UpdateChannelAnchors(orderer, "testchannel")
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", 3, peer)
+
+			By("corrupting the header of the last block in the peer's block file")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			nwo.CorruptBlockFile(network, peer, "testchannel", nwo.BlockDataHashOffset(network, peer, "testchannel", 2), 4)
+
+			By("restarting the orderers and the corrupted peer")
+			peerRunner := network.PeerRunner(peer)
+			process = ifrit.Invoke(grouper.NewOrdered(syscall.SIGTERM, grouper.Members{
+				{Name: "orderers", Runner: network.OrdererGroupRunner()},
+				{Name: peer.ID(), Runner: peerRunner},
+			}))
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("committing a new block on top of the corrupted block")
+			nwo.UpdateOrdererBatchConfig(network, peer, orderer, "testchannel", func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration) {
+				batchSize.MaxMessageCount = 20
+			})
+			Eventually(peerRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say(`unexpected Previous block hash`))
+		})
+
+		It("collects profiles from the pprof services", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			By("restarting the network with profiling enabled")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.EnablePeerProfiling(peer)
+			network.EnableOrdererProfiling(orderer)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("fetching goroutine, heap, and cpu profiles")
+			for _, profileType := range []string{"goroutine", "heap", "profile"} {
+				nwo.FetchProfile(http.DefaultClient, network.PeerProfileURL(peer), profileType)
+				nwo.FetchProfile(http.DefaultClient, network.OrdererProfileURL(orderer), profileType)
+			}
+
+			By("verifying profiling is disabled on the other peers")
+			_, err := http.Get(network.PeerProfileURL(network.Peer("org2", "peer1")) + "/debug/pprof/goroutine")
+			Expect(err).To(HaveOccurred())
+		})
+
+		It("rejects gRPC messages that exceed the configured size limits", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+			network.CreateAndJoinChannels(orderer)
+
+			By("restarting the network with small message size limits")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetPeerMaxMsgSize(peer, 16*1024, 0)
+			network.SetOrdererMaxMsgSize(orderer, 0, 1024)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("sending the peer a proposal larger than it accepts")
+			sess, err := network.PeerUserSession(peer, "User1", nwo.ChaincodeInvokeWithPayload(network, orderer, "testchannel", "mycc", "invoke", 32*1024, peer))
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`ResourceExhausted desc = grpc: received message larger than max`))
+
+			By("fetching a block larger than the orderer sends")
+			sess, err = network.PeerAdminSession(peer, commands.ChannelFetch{
+				ChannelID:  "testchannel",
+				Block:      "oldest",
+				Orderer:    network.OrdererAddress(orderer, nwo.ListenPort),
+				OutputFile: filepath.Join(tempDir, "oldest.pb"),
+				ClientAuth: network.ClientAuthRequired,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`ResourceExhausted desc = grpc: trying to send message larger than max`))
+		})
+
+		It("rejects proposals that exceed the endorser concurrency limit", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				Label:           "my_simple_chaincode",
+			}
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincodeNoInit(network, "testchannel", orderer, chaincode)
+
+			By("restarting the network with an endorser concurrency limit of one")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetPeerConcurrencyLimits(peer, 1, 0)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("sending proposals to the peer concurrently")
+			var sessions []*gexec.Session
+			for i := 0; i < 3; i++ {
+				sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+					ChannelID:     "testchannel",
+					Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+					Name:          "mycc",
+					Ctor:          fmt.Sprintf(`{"Args":["sleep","5s","key%d","value"]}`, i),
+					PeerAddresses: []string{network.PeerAddress(peer, nwo.ListenPort)},
+					ClientAuth:    network.ClientAuthRequired,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				sessions = append(sessions, sess)
+			}
+
+			var succeeded, rejected int
+			for _, sess := range sessions {
+				Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit())
+				if sess.ExitCode() == 0 {
+					succeeded++
+					continue
+				}
+				Expect(sess.Err).To(gbytes.Say(`too many requests for /protos.Endorser, exceeding concurrency limit \(1\)`))
+				rejected++
+			}
+			Expect(succeeded).To(BeNumerically(">=", 1))
+			Expect(rejected).To(BeNumerically(">=", 1))
+		})
+
+		It("reports the version of the running components", func() {
+			peerVersion := nwo.ComponentVersion(network, components.Peer())
+			Expect(peerVersion.Version).NotTo(BeEmpty())
+			Expect(peerVersion.CommitSHA).NotTo(BeEmpty())
+			ordererVersion := nwo.ComponentVersion(network, components.Orderer())
+			Expect(ordererVersion).To(Equal(peerVersion))
+
+			for _, p := range network.Peers {
+				Expect(nwo.PeerVersion(network, p)).To(Equal(peerVersion), "peer %s", p.ID())
+			}
+			Expect(nwo.OrdererVersion(network, network.Orderer("orderer0"))).To(Equal(ordererVersion))
+		})
+
+		It("runs peers and orderers from the binaries set for them", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org2", "peer1")
+
+			By("copying the peer and orderer binaries built from the tree")
+			copyBinary := func(src string) string {
+				dst := filepath.Join(tempDir, "bin", filepath.Base(src))
+				Expect(os.MkdirAll(filepath.Dir(dst), 0755)).To(Succeed())
+				contents, err := ioutil.ReadFile(src)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ioutil.WriteFile(dst, contents, 0755)).To(Succeed())
+				return dst
+			}
+			peerBinary := copyBinary(components.Peer())
+			ordererBinary := copyBinary(components.Orderer())
+
+			By("running a peer and the orderer from the copies")
+			network.SetPeerBinary(peer, peerBinary)
+			network.SetOrdererBinary(orderer, ordererBinary)
+			Expect(network.PeerRunner(peer).Command.Path).To(Equal(peerBinary))
+			Expect(network.OrdererRunner(orderer).Command.Path).To(Equal(ordererBinary))
+			Expect(network.PeerRunner(network.Peer("org1", "peer1")).Command.Path).To(Equal(components.Peer()))
+			process = network.Restart(process)
+
+			By("transacting with the peer and orderer run from the copies")
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+		})
+
+		It("runs peers from a previous release with a current orderer", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+			current := nwo.ComponentVersion(network, components.Peer())
+
+			// Orderers are upgraded before peers, so peers of the previous
+			// release must work with current orderers. PREVIOUS_RELEASE_PEER
+			// holds the path of a peer binary built from the previous release;
+			// see integration/README.rst.
+			previousPeer := os.Getenv("PREVIOUS_RELEASE_PEER")
+			if previousPeer == "" {
+				Skip("PREVIOUS_RELEASE_PEER is not set to the peer binary of the previous release")
+			}
+			Expect(nwo.ComponentVersion(network, previousPeer).Version).NotTo(Equal(current.Version))
+
+			By("restarting the org2 peers with the previous release")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			for _, p := range network.PeersInOrg("org2") {
+				network.SetPeerBinary(p, previousPeer)
+				Expect(network.PeerRunner(p).Command.Path).To(Equal(previousPeer))
+			}
+			Expect(network.PeerRunner(peer).Command.Path).To(Equal(components.Peer()))
+			Expect(network.OrdererRunner(orderer).Command.Path).To(Equal(components.Orderer()))
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			for _, p := range network.PeersInOrg("org2") {
+				Expect(nwo.PeerVersion(network, p)).To(Equal(nwo.ComponentVersion(network, previousPeer)))
+			}
+			Expect(nwo.OrdererVersion(network, orderer)).To(Equal(nwo.ComponentVersion(network, components.Orderer())))
+
+			By("transacting across releases")
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			RunQueryInvokeQuery(network, orderer, peer, 100)
+		})
+
+		It("broadcasts crafted envelopes directly to the orderer", func() {
+			orderer := network.Orderer("orderer0")
+			network.CreateAndJoinChannels(orderer)
+
+			signer := nwo.SignerForUser(network, "org1", "User1")
+
+			By("submitting a properly signed envelope")
+			env, err := protoutil.CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, "testchannel", signer, &common.ConfigValue{}, 0, 0)
+			Expect(err).NotTo(HaveOccurred())
+			resp := nwo.Broadcast(network, orderer, env)
+			Expect(resp.Status).To(Equal(common.Status_SUCCESS))
+
+			By("submitting an envelope with a bad signature")
+			env, err = protoutil.CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, "testchannel", signer, &common.ConfigValue{}, 0, 0)
+			Expect(err).NotTo(HaveOccurred())
+			env.Signature[len(env.Signature)-1] ^= 0xff
+			resp = nwo.Broadcast(network, orderer, env)
+			Expect(resp.Status).To(Equal(common.Status_FORBIDDEN))
+
+			By("submitting an envelope with a malformed payload")
+			resp = nwo.Broadcast(network, orderer, &common.Envelope{Payload: []byte("garbage"), Signature: env.Signature})
+			Expect(resp.Status).To(Equal(common.Status_BAD_REQUEST))
+		})
+
+		It("verifies CLI connections against the TLS CA bundle of each peer", func() {
+			orderer := network.Orderer("orderer0")
+			org1Peer1, org1Peer2 := network.Peer("org1", "peer1"), network.Peer("org1", "peer2")
+			org2Peer1 := network.Peer("org2", "peer1")
+
+			By("verifying each organization has its own TLS CA")
+			org1TLSCA, err := ioutil.ReadFile(network.OrgTLSCACert(network.Organization("org1")))
+			Expect(err).NotTo(HaveOccurred())
+			org2TLSCA, err := ioutil.ReadFile(network.OrgTLSCACert(network.Organization("org2")))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(org1TLSCA).NotTo(Equal(org2TLSCA))
+
+			By("giving the CLI sessions of each peer their own TLS CA bundle")
+			network.PeerTLSCABundles = true
+			network.ConcatenateTLSCACertificates()
+			for _, p := range network.Peers {
+				Expect(network.PeerTLSCABundlePath(p)).To(BeARegularFile())
+			}
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, org1Peer1, org2Peer1)
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+			invoke := func(target *nwo.Peer) *gexec.Session {
+				sess, err := network.PeerUserSession(org1Peer2, "User1", commands.ChaincodeInvoke{
+					ChannelID:     "testchannel",
+					Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+					Name:          "mycc",
+					Ctor:          `{"Args":["invoke","a","b","10"]}`,
+					PeerAddresses: []string{network.PeerAddress(target, nwo.ListenPort)},
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit())
+				return sess
+			}
+
+			By("connecting the CLI to peers of both organizations")
+			Expect(invoke(org1Peer1).ExitCode()).To(Equal(0))
+			Expect(invoke(org2Peer1).ExitCode()).To(Equal(0))
+
+			By("removing the TLS CA of org2 from the CLI bundle of org1's peer")
+			network.WritePeerTLSCABundle(org1Peer2, network.Organization("orderer-org"), network.Organization("org1"))
+			Expect(invoke(org1Peer1).ExitCode()).To(Equal(0))
+			sess := invoke(org2Peer1)
+			Expect(sess.ExitCode()).To(Equal(1))
+			Expect(sess.Err).To(gbytes.Say("endorser client failed to connect to " + network.PeerAddress(org2Peer1, nwo.ListenPort)))
+		})
+
+		It("presents TLS certificates with the expected subject alternative names", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+
+			By("checking the SANs of the peer listener")
+			peerFQDN := fmt.Sprintf("%s.%s", peer.Name, network.Organization("org1").Domain)
+			nwo.AssertPeerListenerCertSANs(network, peer, nwo.ListenPort, peerFQDN, "localhost", "127.0.0.1", "::1")
+
+			By("checking the SANs of the orderer listener")
+			ordererFQDN := fmt.Sprintf("%s.%s", orderer.Name, network.Organization(orderer.Organization).Domain)
+			nwo.AssertOrdererListenerCertSANs(network, orderer, nwo.ListenPort, ordererFQDN, "localhost", "127.0.0.1", "::1")
+
+			By("reading the certificate of a listener")
+			cert := nwo.ListenerCertificate(network.PeerAddress(peer, nwo.ListenPort))
+			Expect(cert.Subject.CommonName).To(Equal(peerFQDN))
+		})
+
+		It("delivers blocks to peers that compress their deliver streams", func() {
+			orderer := network.Orderer("orderer0")
+
+			compressed := network.Peer("org1", "peer1")
+			uncompressed := network.Peer("org2", "peer1")
+
+			By("enabling gzip compression on the delivery client of an org1 peer")
+			network.SetDeliveryClientCompression(compressed, "gzip")
+			Expect(network.ReadPeerConfig(compressed).Peer.Deliveryclient.Compression).To(Equal("gzip"))
+			Expect(network.ReadPeerConfig(uncompressed).Peer.Deliveryclient.Compression).To(BeEmpty())
+
+			By("measuring the deliver streams of a compressing and a non-compressing peer")
+			network.EnableDeliverProxy(compressed)
+			network.EnableDeliverProxy(uncompressed)
+			process = network.Restart(process)
+
+			By("committing transactions delivered over the compressed streams")
+			legacyChaincode := nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			}
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, legacyChaincode)
+			RunQueryInvokeQuery(network, orderer, network.Peer("org1", "peer2"), 100)
+
+			peers := network.PeersWithChannel("testchannel")
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", nwo.GetMaxLedgerHeight(network, "testchannel", peers...), peers...)
+
+			By("receiving fewer bytes on the compressed stream for the same blocks")
+			Expect(nwo.DeliveredBytes(network, uncompressed)).To(BeNumerically(">", 0))
+			Expect(nwo.DeliveredBytes(network, compressed)).To(BeNumerically("<", nwo.DeliveredBytes(network, uncompressed)))
+		})
+
+		It("verifies the orderer signatures of delivered blocks", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+			network.CreateAndJoinChannels(orderer)
+
+			network.UpdateChannelAnchors(orderer, "testchannel")
+
+			fetch := func(block string) *common.Block {
+				output := filepath.Join(tempDir, "block_"+block+".pb")
+				sess, err := network.PeerUserSession(peer, "User1", commands.ChannelFetch{
+					ChannelID:  "testchannel",
+					Block:      block,
+					OutputFile: output,
+					ClientAuth: network.ClientAuthRequired,
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+				return nwo.UnmarshalBlockFromFile(output)
+			}
+
+			By("rejecting the unsigned genesis block")
+			genesis := fetch("oldest")
+			Expect(nwo.VerifyBlockSignatures(network, peer, orderer, "testchannel", genesis)).To(MatchError("the genesis block of a channel is not signed"))
+
+			By("verifying the signatures of a config block")
+			configBlock := nwo.GetConfigBlock(network, peer, orderer, "testchannel")
+			Expect(configBlock.Header.Number).NotTo(BeZero())
+			Expect(nwo.VerifyBlockSignatures(network, peer, orderer, "testchannel", configBlock)).To(Succeed())
+
+			By("verifying the signatures of a data block")
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			block := fetch("newest")
+			Expect(block.Header.Number).To(BeNumerically(">", configBlock.Header.Number))
+			Expect(nwo.VerifyBlockSignatures(network, peer, orderer, "testchannel", block)).To(Succeed())
+
+			By("tampering with the data of the block")
+			tampered := proto.Clone(block).(*common.Block)
+			tampered.Data.Data[0] = append([]byte{}, tampered.Data.Data[0]...)
+			tampered.Data.Data[0][len(tampered.Data.Data[0])-1] ^= 0xff
+			Expect(nwo.VerifyBlockSignatures(network, peer, orderer, "testchannel", tampered)).To(MatchError(ContainSubstring("does not match its data")))
+
+			By("updating the data hash of the tampered block")
+			tampered.Header.DataHash = protoutil.BlockDataHash(tampered.Data)
+			Expect(nwo.VerifyBlockSignatures(network, peer, orderer, "testchannel", tampered)).To(MatchError(ContainSubstring("0 sub-policies were satisfied")))
+		})
+
+		It("rejects connections to a peer whose TLS certificate has expired", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+			network.CreateAndJoinChannels(orderer)
+
+			By("reissuing the TLS certificate of the peer with a short validity")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.SetPeerTLSCertValidity(peer, 30*time.Second)
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			cert := nwo.ListenerCertificate(network.PeerAddress(peer, nwo.ListenPort))
+			Expect(cert.NotAfter).To(BeTemporally("<", time.Now().Add(30*time.Second)))
+
+			By("waiting for the certificate to expire")
+			Eventually(time.Now, time.Minute, time.Second).Should(BeTemporally(">", cert.NotAfter))
+
+			By("failing to connect to the peer")
+			sess, err := network.PeerAdminSession(peer, commands.ChannelList{ClientAuth: network.ClientAuthRequired})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`certificate has expired or is not yet valid`))
+		})
+
+		It("cuts blocks according to the orderer batch config", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+			By("raising the max message count and batch timeout")
+			nwo.UpdateOrdererBatchConfig(network, peer, orderer, "testchannel", func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration) {
+				batchSize.MaxMessageCount = 10
+				*batchTimeout = 5 * time.Second
+			})
+
+			By("lowering the max message count to 1")
+			nwo.UpdateOrdererBatchConfig(network, peer, orderer, "testchannel", func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration) {
+				batchSize.MaxMessageCount = 1
+			})
+			ordererValues := nwo.GetConfig(network, peer, orderer, "testchannel").ChannelGroup.Groups["Orderer"].Values
+			batchSize := &protosorderer.BatchSize{}
+			err := proto.Unmarshal(ordererValues["BatchSize"].Value, batchSize)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(batchSize.MaxMessageCount).To(Equal(uint32(1)))
+			batchTimeout := &protosorderer.BatchTimeout{}
+			err = proto.Unmarshal(ordererValues["BatchTimeout"].Value, batchTimeout)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(batchTimeout.Timeout).To(Equal("5s"))
+
+			By("verifying that the config update landed alone in its own block")
+			configBlockNumber := nwo.CurrentConfigBlockNumber(network, peer, orderer, "testchannel")
+			Expect(nwo.BlockTxCounts(network, peer, orderer, "testchannel", configBlockNumber, configBlockNumber)).To(Equal([]int{1}))
+
+			By("submitting transactions without waiting for them to commit")
+			startHeight := nwo.GetLedgerHeight(network, peer, "testchannel")
+			for i := 0; i < 3; i++ {
+				sess, err := network.PeerUserSession(peer, "User1", commands.ChaincodeInvoke{
+					ChannelID:     "testchannel",
+					Orderer:       network.OrdererAddress(orderer, nwo.ListenPort),
+					Name:          "mycc",
+					Ctor:          `{"Args":["invoke","a","b","10"]}`,
+					PeerAddresses: nwo.AllPeerAddressesForChannel(network, "testchannel", nwo.ListenPort),
+				})
+				Expect(err).NotTo(HaveOccurred())
+				Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			}
+
+			By("verifying that each transaction was cut into its own block")
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", startHeight+3, peer)
+			Expect(nwo.BlockTxCounts(network, peer, orderer, "testchannel", uint64(startHeight), uint64(startHeight+2))).To(Equal([]int{1, 1, 1}))
+
+			By("lowering the absolute max bytes to 1 MB")
+			nwo.UpdateOrdererBatchConfig(network, peer, orderer, "testchannel", func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration) {
+				batchSize.AbsoluteMaxBytes = 1024 * 1024
+				batchSize.PreferredMaxBytes = 512 * 1024
+			})
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", startHeight+4, peer)
+
+			By("broadcasting a transaction larger than the absolute max bytes")
+			resp := nwo.BroadcastOversized(network, orderer, "testchannel", 2*1024*1024)
+			Expect(resp.Info).To(ContainSubstring("exceeds maximum allowed 1048576 bytes"))
+
+			By("verifying that no block was cut for the rejected transaction")
+			Consistently(func() int {
+				return nwo.GetLedgerHeight(network, peer, "testchannel")
+			}, 10*time.Second, time.Second).Should(Equal(startHeight + 4))
+			Expect(nwo.BlockTxCounts(network, peer, orderer, "testchannel", uint64(startHeight+3), uint64(startHeight+3))).To(Equal([]int{1}))
+		})
+
+		It("sustains a target transaction rate from concurrent clients", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+			By("generating load at 20 transactions per second")
+			startHeight := nwo.GetLedgerHeight(network, peer, "testchannel")
+			loadGenerator := &nwo.LoadGenerator{
+				Network:   network,
+				Orderer:   orderer,
+				Endorsers: []*nwo.Peer{peer, network.Peer("org2", "peer1")},
+				ChannelID: "testchannel",
+				Chaincode: "mycc",
+				Ctor: func(n int) []string {
+					return []string{"respond", "200", "ok", fmt.Sprintf("tx-%d", n)}
+				},
+				Concurrency: 4,
+				Duration:    5 * time.Second,
+				TargetTPS:   20,
+			}
+			stats := loadGenerator.Run()
+			Expect(stats.TxIDs).To(HaveLen(len(stats.Latencies)))
+			Expect(stats.Throughput()).To(BeNumerically("~", 20, 5))
+			Expect(nwo.LatencyPercentile(stats.Latencies, 50)).To(BeNumerically("<=", nwo.LatencyPercentile(stats.Latencies, 99)))
+
+			By("verifying every transaction committed as valid")
+			codes := nwo.WaitForTransactions(network, peer, "testchannel", uint64(startHeight), stats.TxIDs)
+			Expect(codes).To(HaveLen(len(stats.TxIDs)))
+			for txID, code := range codes {
+				Expect(code).To(Equal(pb.TxValidationCode_VALID), "transaction %s", txID)
+			}
+		})
+
+		It("records the read/write sets of transactions invalidated by read conflicts", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+			By("submitting concurrent transfers between the same keys")
+			startHeight := nwo.GetLedgerHeight(network, peer, "testchannel")
+			loadGenerator := &nwo.LoadGenerator{
+				Network:     network,
+				Orderer:     orderer,
+				Endorsers:   []*nwo.Peer{peer},
+				ChannelID:   "testchannel",
+				Chaincode:   "mycc",
+				Ctor:        func(int) []string { return []string{"invoke", "a", "b", "1"} },
+				Concurrency: 4,
+				Duration:    2 * time.Second,
+			}
+			stats := loadGenerator.Run()
+			codes := nwo.WaitForTransactions(network, peer, "testchannel", uint64(startHeight), stats.TxIDs)
+
+			conflicted := -1
+			for i, txID := range stats.TxIDs {
+				if codes[txID] == pb.TxValidationCode_MVCC_READ_CONFLICT {
+					conflicted = i
+					break
+				}
+			}
+			Expect(conflicted).NotTo(Equal(-1), "no transaction was invalidated by a read conflict")
+
+			readOf := func(rws nwo.ReadWriteSet, key string) nwo.KVRead {
+				for _, read := range rws.Reads {
+					if read.Namespace == "mycc" && read.Key == key {
+						return read
+					}
+				}
+				Fail(fmt.Sprintf("key %s was not read by the transaction", key))
+				return nwo.KVRead{}
+			}
+
+			writesKey := func(rws nwo.ReadWriteSet, key string) bool {
+				for _, write := range rws.Writes {
+					if write.Namespace == "mycc" && write.Key == key {
+						return true
+					}
+				}
+				return false
+			}
+
+			By("reading the read/write set of the invalidated transaction")
+			loser := nwo.TxReadWriteSet(network, peer, "testchannel", stats.TxIDs[conflicted])
+			Expect(loser.ValidationCode).To(Equal(pb.TxValidationCode_MVCC_READ_CONFLICT))
+			Expect(writesKey(loser, "a")).To(BeTrue())
+			loserRead := readOf(loser, "a")
+			Expect(loserRead.Version).NotTo(BeNil())
+
+			By("finding the valid transaction that read the same version first")
+			var winner *nwo.ReadWriteSet
+			for d := 1; winner == nil && (conflicted-d >= 0 || conflicted+d < len(stats.TxIDs)); d++ {
+				for _, i := range []int{conflicted - d, conflicted + d} {
+					if i < 0 || i >= len(stats.TxIDs) || codes[stats.TxIDs[i]] != pb.TxValidationCode_VALID {
+						continue
+					}
+					rws := nwo.TxReadWriteSet(network, peer, "testchannel", stats.TxIDs[i])
+					if proto.Equal(readOf(rws, "a").Version, loserRead.Version) {
+						winner = &rws
+						break
+					}
+				}
+			}
+			Expect(winner).NotTo(BeNil(), "no valid transaction read version %v of key a", loserRead.Version)
+			Expect(writesKey(*winner, "a")).To(BeTrue())
+			Expect(winner.BlockNum < loser.BlockNum || (winner.BlockNum == loser.BlockNum && winner.TxNum < loser.TxNum)).To(BeTrue(),
+				"valid transaction at %d/%d did not commit before the invalidated transaction at %d/%d", winner.BlockNum, winner.TxNum, loser.BlockNum, loser.TxNum)
+		})
+
+		It("validates transactions faster with a larger validator pool", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer2")
+
+			chaincode := nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(tempDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			}
+
+			network.CreateAndJoinChannels(orderer)
+			nwo.EnableCapabilities(network, "testchannel", "Application", "V2_0", orderer, network.Peer("org1", "peer1"), network.Peer("org2", "peer1"))
+			nwo.DeployChaincode(network, "testchannel", orderer, chaincode)
+
+			By("cutting blocks that hold many transactions")
+			nwo.UpdateOrdererBatchConfig(network, peer, orderer, "testchannel", func(batchSize *protosorderer.BatchSize, batchTimeout *time.Duration) {
+				batchSize.MaxMessageCount = 100
+				*batchTimeout = time.Second
+			})
+
+			// measure returns the time, in seconds, the peer spends validating
+			// a transaction with the given pool size under a fixed load
+			measure := func(poolSize int) float64 {
+				network.SetPeerValidatorPoolSize(peer, poolSize)
+				Expect(network.ReadPeerConfig(peer).Peer.ValidatorPoolSize).To(Equal(poolSize))
+				process = network.Restart(process)
+
+				validationDuration := nwo.BlockValidationDuration(network, peer, "testchannel")
+				startDuration := validationDuration()
+				if startDuration < 0 {
+					startDuration = 0
+				}
+				startHeight := nwo.GetLedgerHeight(network, peer, "testchannel")
+
+				loadGenerator := &nwo.LoadGenerator{
+					Network:   network,
+					Orderer:   orderer,
+					Endorsers: []*nwo.Peer{peer, network.Peer("org2", "peer1")},
+					ChannelID: "testchannel",
+					Chaincode: "mycc",
+					Ctor: func(n int) []string {
+						return []string{"respond", "200", "ok", fmt.Sprintf("tx-%d-%d", poolSize, n)}
+					},
+					Concurrency: 8,
+					Duration:    10 * time.Second,
+					TargetTPS:   50,
+				}
+				stats := loadGenerator.Run()
+				Expect(stats.TxIDs).NotTo(BeEmpty())
+				codes := nwo.WaitForTransactions(network, peer, "testchannel", uint64(startHeight), stats.TxIDs)
+				Expect(codes).To(HaveLen(len(stats.TxIDs)))
+
+				validated := validationDuration() - startDuration
+				Expect(validated).To(BeNumerically(">", 0))
+				return validated / float64(len(stats.TxIDs))
+			}
+
+			By("measuring the validation time per transaction with a single validator")
+			serial := measure(1)
+
+			By("measuring the validation time per transaction with a validator per CPU")
+			parallel := measure(runtime.NumCPU())
+
+			if runtime.NumCPU() == 1 {
+				Skip("a single CPU cannot validate transactions in parallel")
+			}
+			// allow for noise in the measurements, but a validator per CPU must
+			// still take at least 10% less time per transaction than one
+			Expect(parallel).To(BeNumerically("<", 0.9*serial), "%d validators took %.6fs per transaction, one validator %.6fs", runtime.NumCPU(), parallel, serial)
+		})
+
+		It("catches up a late peer through gossip state transfer", func() {
+			orderer := network.Orderer("orderer0")
+			leaders := []*nwo.Peer{network.Peer("org1", "peer1"), network.Peer("org2", "peer2")}
+			latePeers := []*nwo.Peer{network.Peer("org1", "peer2"), network.Peer("org2", "peer1")}
+			batchSizes := []int{2, 20}
+
+			By("configuring the late peers to fetch missing blocks from their org leaders")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			for i, p := range latePeers {
+				network.SetOrgLeaders(p.Organization, leaders[i])
+				network.SetGossipStateTransfer(p, nwo.GossipStateTransfer{
+					BatchSize:            batchSizes[i],
+					BlockBufferSize:      100,
+					MaxBlockCountToStore: 100,
+				})
+				network.SetPeerLogSpec(p, "gossip.state=debug:info")
+
+				// the channel config the late peer joins with has no anchor peers
+				core := network.ReadPeerConfig(p)
+				core.Peer.Gossip.Bootstrap = network.PeerAddress(leaders[i], nwo.ListenPort)
+				network.WritePeerConfig(p, core)
+
+				state := network.ReadPeerConfig(p).Peer.Gossip.State
+				Expect(state.Enabled).To(BeTrue())
+				Expect(state.BatchSize).To(Equal(batchSizes[i]))
+			}
+
+			By("starting the network without the late peers")
+			leaderMembers := grouper.Members{}
+			for _, p := range leaders {
+				leaderMembers = append(leaderMembers, grouper.Member{Name: p.ID(), Runner: network.PeerRunner(p)})
+			}
+			process = ifrit.Invoke(grouper.NewOrdered(syscall.SIGTERM, grouper.Members{
+				{Name: "orderers", Runner: network.OrdererGroupRunner()},
+				{Name: "peers", Runner: grouper.NewParallel(syscall.SIGTERM, leaderMembers)},
+			}))
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			network.CreateChannel("testchannel", orderer, leaders[0])
+			network.JoinChannel("testchannel", orderer, leaders...)
+			network.UpdateChannelAnchors(orderer, "testchannel")
+
+			By("committing blocks the late peers will miss")
+			chaincode := nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			}
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, chaincode, leaders...)
+			nwo.TimedInvokes(network, orderer, leaders[0], commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","1"]}`,
+				PeerAddresses: []string{network.PeerAddress(leaders[0], nwo.ListenPort)},
+				ClientAuth:    network.ClientAuthRequired,
+			}, 40)
+			height := nwo.GetLedgerHeight(network, leaders[0], "testchannel")
+			Expect(height).To(BeNumerically(">", 40))
+
+			By("joining the late peers to the channel")
+			lateRunners := make([]*ginkgomon.Runner, len(latePeers))
+			for i, p := range latePeers {
+				lateRunners[i] = network.PeerRunner(p)
+				lateProcess := ifrit.Invoke(lateRunners[i])
+				Eventually(lateProcess.Ready(), network.EventuallyTimeout).Should(BeClosed())
+				defer func() {
+					lateProcess.Signal(syscall.SIGTERM)
+					Eventually(lateProcess.Wait(), network.EventuallyTimeout).Should(Receive())
+				}()
+			}
+			network.JoinChannel("testchannel", orderer, latePeers...)
+
+			By("waiting for the late peers to fetch the missing blocks")
+			for _, p := range latePeers {
+				Eventually(nwo.GossipStateHeight(network, p, "testchannel"), network.EventuallyTimeout).Should(BeNumerically(">=", height))
+			}
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", height, latePeers...)
+
+			By("comparing the number of state requests of each batch size")
+			smallBatchRequests := nwo.StateTransferRequests(lateRunners[0].Err().Contents(), "testchannel")
+			largeBatchRequests := nwo.StateTransferRequests(lateRunners[1].Err().Contents(), "testchannel")
+			Expect(largeBatchRequests).To(BeNumerically(">", 0))
+			Expect(smallBatchRequests).To(BeNumerically(">", largeBatchRequests))
+		})
+
+		It("recovers an organization whose leader stopped receiving blocks", func() {
+			orderer := network.Orderer("orderer0")
+			lagging := network.Peer("org1", "peer1")
+			follower := network.Peer("org1", "peer2")
+			endorser := network.Peer("org2", "peer1")
+
+			By("routing the blocks of org1 through a deliver proxy of its leader")
+			network.SetOrgLeaders("org1", lagging)
+			for _, p := range network.PeersInOrg("org1") {
+				network.SetGossipStateTransfer(p, nwo.GossipStateTransfer{BatchSize: 5})
+			}
+			core := network.ReadPeerConfig(follower)
+			core.Peer.Gossip.Bootstrap = network.PeerAddress(lagging, nwo.ListenPort)
+			network.WritePeerConfig(follower, core)
+			network.EnableDeliverProxy(lagging)
+			overrides := network.ReadPeerConfig(lagging).Peer.Deliveryclient.AddressOverrides
+			Expect(overrides).To(HaveLen(1))
+			Expect(overrides[0].From).To(Equal(network.OrdererAddress(orderer, nwo.ListenPort)))
+			network.SetPeerLogSpec(follower, "gossip.state=debug:info")
+
+			By("restarting the network with the logs of the follower captured")
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			var followerRunner *ginkgomon.Runner
+			peerMembers := grouper.Members{}
+			for _, p := range network.Peers {
+				runner := network.PeerRunner(p)
+				if p == follower {
+					followerRunner = runner
+				}
+				peerMembers = append(peerMembers, grouper.Member{Name: p.ID(), Runner: runner})
+			}
+			process = ifrit.Invoke(grouper.NewOrdered(syscall.SIGTERM, grouper.Members{
+				{Name: "orderers", Runner: network.OrdererGroupRunner()},
+				{Name: "peers", Runner: grouper.NewParallel(syscall.SIGTERM, peerMembers)},
+			}))
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			// without anchor peers the org1 peers only know each other through
+			// the bootstrap peer and never learn the ledger height of org2
+			// peers, so they cannot fetch the blocks they miss from org2
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			})
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", nwo.GetLedgerHeight(network, endorser, "testchannel"), lagging, follower)
+
+			By("advancing the chain while the org1 leader receives no blocks")
+			nwo.PauseBlockDelivery(network, lagging)
+			nwo.TimedInvokes(network, orderer, endorser, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","1"]}`,
+				PeerAddresses: []string{network.PeerAddress(endorser, nwo.ListenPort)},
+				ClientAuth:    network.ClientAuthRequired,
+			}, 10)
+			tip := nwo.GetLedgerHeight(network, endorser, "testchannel")
+			for _, p := range []*nwo.Peer{lagging, follower} {
+				height := func() int { return nwo.GetLedgerHeight(network, p, "testchannel") }
+				Consistently(height, 5*time.Second, time.Second).Should(BeNumerically("<", tip), "ledger height of %s", p.ID())
+			}
+
+			By("resuming block delivery and waiting for org1 to reach the tip")
+			nwo.ResumeBlockDelivery(network, lagging)
+			for _, p := range []*nwo.Peer{lagging, follower} {
+				Eventually(nwo.GossipStateHeight(network, p, "testchannel"), network.EventuallyTimeout).Should(BeNumerically(">=", tip))
+			}
+			nwo.WaitUntilEqualLedgerHeight(network, "testchannel", tip, network.PeersWithChannel("testchannel")...)
+
+			By("checking the follower fetched the missing blocks through state transfer")
+			Expect(nwo.StateTransferRequests(followerRunner.Err().Contents(), "testchannel")).To(BeNumerically(">", 0))
+
+			sess, err := network.PeerUserSession(follower, "User1", commands.ChaincodeQuery{
+				ChannelID: "testchannel",
+				Name:      "mycc",
+				Ctor:      `{"Args":["query","a"]}`,
+			})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(0))
+			Expect(sess).To(gbytes.Say("90"))
+		})
+	})
+
+	Describe("solo network with intermediate TLS CAs", func() {
+		var network *nwo.Network
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			soloBytes, err := ioutil.ReadFile("solo.yaml")
+			Expect(err).NotTo(HaveOccurred())
+
+			var config *nwo.Config
+			err = yaml.Unmarshal(soloBytes, &config)
+			Expect(err).NotTo(HaveOccurred())
+
+			network = nwo.New(config, tempDir, client, StartPort(), components)
+			network.TLSIntermediateCAs = true
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.Cleanup()
+		})
+
+		It("presents the full chain and validates it against the root TLS CA", func() {
+			orderer := network.Orderer("orderer0")
+			peer := network.Peer("org1", "peer1")
+			org1 := network.Organization("org1")
+
+			By("checking that the peer trusts only the root TLS CA")
+			rootPEM, err := ioutil.ReadFile(network.OrgTLSCACert(org1))
+			Expect(err).NotTo(HaveOccurred())
+			trustedPEM, err := ioutil.ReadFile(filepath.Join(network.PeerLocalTLSDir(peer), "ca.crt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trustedPEM).To(Equal(rootPEM))
+
+			By("checking that the peer listener presents the leaf and the intermediate")
+			chain := nwo.ListenerCertificateChain(network.PeerAddress(peer, nwo.ListenPort))
+			Expect(chain).To(HaveLen(2))
+			Expect(chain[0].Subject.CommonName).To(Equal("peer1." + org1.Domain))
+			Expect(chain[1].Subject.CommonName).To(Equal("tlsica." + org1.Domain))
+			Expect(chain[1].IsCA).To(BeTrue())
+
+			By("verifying the chain against the root only")
+			roots := x509.NewCertPool()
+			Expect(roots.AppendCertsFromPEM(rootPEM)).To(BeTrue())
+			intermediates := x509.NewCertPool()
+			intermediates.AddCert(chain[1])
+			_, err = chain[0].Verify(x509.VerifyOptions{Roots: roots, Intermediates: intermediates})
+			Expect(err).NotTo(HaveOccurred())
+			_, err = chain[0].Verify(x509.VerifyOptions{Roots: roots})
+			Expect(err).To(HaveOccurred())
+
+			By("checking that the orderer listener presents the full chain")
+			Expect(nwo.ListenerCertificateChain(network.OrdererAddress(orderer, nwo.ListenPort))).To(HaveLen(2))
+
+			By("transacting across organizations that trust only the root TLS CAs")
+			legacyChaincode := nwo.Chaincode{
+				Name:    "mycc",
+				Version: "0.0",
+				Path:    "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Ctor:    `{"Args":["init","a","100","b","200"]}`,
+				Policy:  `AND ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+			}
+			network.CreateAndJoinChannels(orderer)
+			nwo.DeployChaincodeLegacy(network, "testchannel", orderer, legacyChaincode)
+			RunQueryInvokeQuery(network, orderer, network.Peer("org1", "peer2"), 100)
+		})
+	})
+
+	Describe("solo network with short-lived TLS CAs", func() {
+		var network *nwo.Network
+		var process ifrit.Process
+
+		BeforeEach(func() {
+			soloBytes, err := ioutil.ReadFile("solo.yaml")
+			Expect(err).NotTo(HaveOccurred())
+
+			var config *nwo.Config
+			err = yaml.Unmarshal(soloBytes, &config)
+			Expect(err).NotTo(HaveOccurred())
+
+			network = nwo.New(config, tempDir, client, StartPort(), components)
+			network.TLSCAValidity = time.Minute
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		AfterEach(func() {
+			process.Signal(syscall.SIGTERM)
+			Eventually(process.Wait(), network.EventuallyTimeout).Should(Receive())
+			network.Cleanup()
+		})
+
+		It("rejects connections to a peer once its TLS CA has expired", func() {
+			peer := network.Peer("org1", "peer1")
+			org1 := network.Organization("org1")
+
+			By("checking that the peer trusts the reissued TLS CA")
+			rootPEM, err := ioutil.ReadFile(network.OrgTLSCACert(org1))
+			Expect(err).NotTo(HaveOccurred())
+			trustedPEM, err := ioutil.ReadFile(filepath.Join(network.PeerLocalTLSDir(peer), "ca.crt"))
+			Expect(err).NotTo(HaveOccurred())
+			Expect(trustedPEM).To(Equal(rootPEM))
+
+			block, _ := pem.Decode(rootPEM)
+			Expect(block).NotTo(BeNil())
+			root, err := x509.ParseCertificate(block.Bytes)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(root.NotAfter).To(BeTemporally("<", time.Now().Add(time.Minute)))
+
+			By("waiting for the TLS CA to expire")
+			Eventually(time.Now, 2*time.Minute, time.Second).Should(BeTemporally(">", root.NotAfter))
+
+			By("failing to connect to the peer")
+			sess, err := network.PeerAdminSession(peer, commands.ChannelList{ClientAuth: network.ClientAuthRequired})
+			Expect(err).NotTo(HaveOccurred())
+			Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+			Expect(sess.Err).To(gbytes.Say(`certificate has expired or is not yet valid`))
 		})
 	})
 
@@ -299,10 +2059,10 @@ func RunQueryInvokeQuery(n *nwo.Network, orderer *nwo.Orderer, peer *nwo.Peer, i
 		Orderer:   n.OrdererAddress(orderer, nwo.ListenPort),
 		Name:      "mycc",
 		Ctor:      `{"Args":["invoke","a","b","10"]}`,
-		PeerAddresses: []string{
-			n.PeerAddress(n.Peer("org1", "peer1"), nwo.ListenPort),
-			n.PeerAddress(n.Peer("org2", "peer2"), nwo.ListenPort),
-		},
+		PeerAddresses: nwo.PeerAddresses(n, nwo.ListenPort,
+			n.Peer("org1", "peer1"),
+			n.Peer("org2", "peer2"),
+		),
 		WaitForEvent: true,
 	})
 	Expect(err).NotTo(HaveOccurred())
@@ -9,13 +9,113 @@ package nwo
 import (
 	"crypto/tls"
 	"crypto/x509"
+	"fmt"
 	"io/ioutil"
+	"net"
 	"net/http"
 	"path/filepath"
+	"strconv"
+	"strings"
 
 	. "github.com/onsi/gomega"
 )
 
+// SetPeerOperationsTLS enables or disables TLS on the operations endpoint of
+// the peer. TLS is enabled by default. It must be called before the
+// configuration tree is generated.
+func (n *Network) SetPeerOperationsTLS(p *Peer, enabled bool) {
+	n.setOperationsTLS(p.ID(), enabled)
+}
+
+// SetOrdererOperationsTLS enables or disables TLS on the operations endpoint
+// of the orderer. TLS is enabled by default. It must be called before the
+// configuration tree is generated.
+func (n *Network) SetOrdererOperationsTLS(o *Orderer, enabled bool) {
+	n.setOperationsTLS(o.ID(), enabled)
+}
+
+func (n *Network) setOperationsTLS(id string, enabled bool) {
+	if n.plaintextOps == nil {
+		n.plaintextOps = map[string]bool{}
+	}
+	n.plaintextOps[id] = !enabled
+}
+
+// PeerOperationsTLS returns whether the operations endpoint of the peer is
+// served over TLS.
+func (n *Network) PeerOperationsTLS(p *Peer) bool {
+	return !n.plaintextOps[p.ID()]
+}
+
+// OrdererOperationsTLS returns whether the operations endpoint of the
+// orderer is served over TLS.
+func (n *Network) OrdererOperationsTLS(o *Orderer) bool {
+	return !n.plaintextOps[o.ID()]
+}
+
+// SetPeerOperationsListenAddress sets the host address the operations
+// endpoint of the peer listens on. The endpoint listens on the loopback
+// address by default. It must be called before the configuration tree is
+// generated.
+func (n *Network) SetPeerOperationsListenAddress(p *Peer, host string) {
+	n.setOperationsHost(p.ID(), host)
+}
+
+// SetOrdererOperationsListenAddress sets the host address the operations
+// endpoint of the orderer listens on. The endpoint listens on the loopback
+// address by default. It must be called before the configuration tree is
+// generated.
+func (n *Network) SetOrdererOperationsListenAddress(o *Orderer, host string) {
+	n.setOperationsHost(o.ID(), host)
+}
+
+func (n *Network) setOperationsHost(id, host string) {
+	if n.operationsHosts == nil {
+		n.operationsHosts = map[string]string{}
+	}
+	n.operationsHosts[id] = host
+}
+
+// PeerOperationsAddress returns the address the operations endpoint of the
+// peer listens on.
+func (n *Network) PeerOperationsAddress(p *Peer) string {
+	return n.operationsAddress(p.ID(), n.PeerPort(p, OperationsPort))
+}
+
+// OrdererOperationsAddress returns the address the operations endpoint of
+// the orderer listens on.
+func (n *Network) OrdererOperationsAddress(o *Orderer) string {
+	return n.operationsAddress(o.ID(), n.OrdererPort(o, OperationsPort))
+}
+
+func (n *Network) operationsAddress(id string, port uint16) string {
+	host, ok := n.operationsHosts[id]
+	if !ok {
+		host = "127.0.0.1"
+	}
+	return net.JoinHostPort(host, strconv.Itoa(int(port)))
+}
+
+// PeerOperationsURL returns the URL of the path on the operations endpoint of
+// the peer, using the scheme the endpoint is served with.
+func (n *Network) PeerOperationsURL(p *Peer, path string) string {
+	return operationsURL(n.PeerOperationsTLS(p), n.PeerOperationsAddress(p), path)
+}
+
+// OrdererOperationsURL returns the URL of the path on the operations
+// endpoint of the orderer, using the scheme the endpoint is served with.
+func (n *Network) OrdererOperationsURL(o *Orderer, path string) string {
+	return operationsURL(n.OrdererOperationsTLS(o), n.OrdererOperationsAddress(o), path)
+}
+
+func operationsURL(tlsEnabled bool, address, path string) string {
+	scheme := "https"
+	if !tlsEnabled {
+		scheme = "http"
+	}
+	return fmt.Sprintf("%s://%s/%s", scheme, address, strings.TrimPrefix(path, "/"))
+}
+
 func OrdererOperationalClients(n *Network, o *Orderer) (authClient, unauthClient *http.Client) {
 	return operationalClients(n.OrdererLocalTLSDir(o))
 }
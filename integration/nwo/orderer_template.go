@@ -93,9 +93,9 @@ Consensus:
   SnapDir: {{ .OrdererDir Orderer }}/etcdraft/snapshot
   EvictionSuspicion: 10s
 Operations:
-  ListenAddress: 127.0.0.1:{{ .OrdererPort Orderer "Operations" }}
+  ListenAddress: {{ $w.OrdererOperationsAddress Orderer }}
   TLS:
-    Enabled: true
+    Enabled: {{ $w.OrdererOperationsTLS Orderer }}
     PrivateKey: {{ $w.OrdererLocalTLSDir Orderer }}/server.key
     Certificate: {{ $w.OrdererLocalTLSDir Orderer }}/server.crt
     RootCAs:
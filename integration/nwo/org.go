@@ -0,0 +1,187 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bytes"
+	"io/ioutil"
+	"syscall"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-config/protolator"
+	"github.com/hyperledger/fabric-config/protolator/protoext/peerext"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit"
+	"github.com/tedsuo/ifrit/grouper"
+)
+
+// AddOrgToChannel adds an organization to a running network and to the
+// application group of the channel. The organization and its peers must have
+// been added to the network with AddOrg. The helper generates the crypto
+// material and core config of the organization's peers, starts them, submits
+// a config update signed by an admin of each organization already in the
+// channel, and joins the peers to the channel. The returned process runs the
+// organization's peers and must be stopped by the caller.
+func AddOrgToChannel(n *Network, channel string, orderer *Orderer, newOrg *Organization) ifrit.Process {
+	peers := n.PeersInOrg(newOrg.Name)
+	Expect(peers).NotTo(BeEmpty(), "organization %s has no peers", newOrg.Name)
+
+	config := GetConfig(n, n.PeersWithChannel(channel)[0], orderer, channel)
+	Expect(config.ChannelGroup.Groups["Application"].Groups).NotTo(HaveKey(newOrg.Name), "organization %s is already a member of channel %s", newOrg.Name, channel)
+
+	// collect the signers before the new peers are registered with the channel
+	signers := n.channelOrgSigners(channel, config)
+
+	orgGroup := n.generateOrgMaterials(newOrg, peers...)
+
+	members := grouper.Members{}
+	for _, p := range peers {
+		if !n.peerHasChannel(p, channel) {
+			p.Channels = append(p.Channels, &PeerChannel{Name: channel})
+		}
+		members = append(members, grouper.Member{Name: p.ID(), Runner: n.PeerRunner(p)})
+	}
+	process := ifrit.Invoke(grouper.NewParallel(syscall.SIGTERM, members))
+	Eventually(process.Ready(), n.EventuallyTimeout).Should(BeClosed())
+
+	updatedConfig := proto.Clone(config).(*common.Config)
+	updatedConfig.ChannelGroup.Groups["Application"].Groups[newOrg.Name] = orgGroup
+	UpdateConfig(n, orderer, channel, config, updatedConfig, true, signers[0], signers[1:]...)
+
+	n.JoinChannel(channel, orderer, peers...)
+	channelPeers := n.PeersWithChannel(channel)
+	WaitUntilEqualLedgerHeight(n, channel, GetMaxLedgerHeight(n, channel, channelPeers...), channelPeers...)
+
+	return process
+}
+
+// RemoveOrgFromChannel removes an organization from the application group of
+// the channel. The config update is signed by an admin of each organization
+// in the channel, including the one being removed, so it satisfies the
+// default MAJORITY Admins policy of the application group. Once the update
+// commits, the organization's members can no longer read from or write to
+// the channel and endorsements from its peers no longer satisfy the
+// channel's policies. The channel is removed from the channels of the
+// organization's peers in the network so other helpers no longer target them.
+func RemoveOrgFromChannel(n *Network, channel string, orderer *Orderer, org *Organization) {
+	var submitter *Peer
+	for _, p := range n.PeersWithChannel(channel) {
+		if p.Organization != org.Name {
+			submitter = p
+			break
+		}
+	}
+	Expect(submitter).NotTo(BeNil(), "no peers of channel %s remain once organization %s is removed", channel, org.Name)
+
+	config := GetConfig(n, submitter, orderer, channel)
+	Expect(config.ChannelGroup.Groups["Application"].Groups).To(HaveKey(org.Name), "organization %s is not a member of channel %s", org.Name, channel)
+
+	// the submitter signs the update itself, so an admin of each of the other
+	// organizations signs in addition
+	var additionalSigners []*Peer
+	for _, p := range n.channelOrgSigners(channel, config) {
+		if p.Organization != submitter.Organization {
+			additionalSigners = append(additionalSigners, p)
+		}
+	}
+
+	updatedConfig := proto.Clone(config).(*common.Config)
+	delete(updatedConfig.ChannelGroup.Groups["Application"].Groups, org.Name)
+	UpdateConfig(n, orderer, channel, config, updatedConfig, true, submitter, additionalSigners...)
+
+	for _, p := range n.PeersInOrg(org.Name) {
+		var channels []*PeerChannel
+		for _, c := range p.Channels {
+			if c.Name != channel {
+				channels = append(channels, c)
+			}
+		}
+		p.Channels = channels
+	}
+}
+
+// generateOrgMaterials generates the crypto material and core config of the
+// peers of an organization added to the network after it was bootstrapped and
+// returns the organization's application group definition as generated by
+// configtxgen. The crypto and configtx configuration files of the network are
+// restored afterwards, including any changes made to them after they were
+// generated.
+func (n *Network) generateOrgMaterials(org *Organization, peers ...*Peer) *common.ConfigGroup {
+	cryptoConfig, err := ioutil.ReadFile(n.CryptoConfigPath())
+	Expect(err).NotTo(HaveOccurred())
+	configTxConfig, err := ioutil.ReadFile(n.ConfigTxConfigPath())
+	Expect(err).NotTo(HaveOccurred())
+	defer func() {
+		err := ioutil.WriteFile(n.CryptoConfigPath(), cryptoConfig, 0644)
+		Expect(err).NotTo(HaveOccurred())
+		err = ioutil.WriteFile(n.ConfigTxConfigPath(), configTxConfig, 0644)
+		Expect(err).NotTo(HaveOccurred())
+	}()
+
+	orgNetwork := *n
+	orgNetwork.Peers = peers
+	orgNetwork.Templates = &Templates{
+		ConfigTx: OrgUpdateConfigTxTemplate,
+		Crypto:   OrgUpdateCryptoTemplate,
+		Core:     n.Templates.CoreTemplate(),
+	}
+
+	orgNetwork.GenerateCryptoConfig()
+	sess, err := orgNetwork.Cryptogen(commands.Generate{
+		Config: orgNetwork.CryptoConfigPath(),
+		Output: orgNetwork.CryptoPath(),
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+
+	for _, p := range peers {
+		orgNetwork.GenerateCoreConfig(p)
+	}
+	n.ConcatenateTLSCACertificates()
+
+	orgNetwork.GenerateConfigTxConfig()
+	sess, err = n.ConfigTxGen(commands.PrintOrg{
+		ConfigPath: n.RootDir,
+		PrintOrg:   org.Name,
+	})
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+	orgGroup := &peerext.DynamicApplicationOrgGroup{ConfigGroup: &common.ConfigGroup{}}
+	err = protolator.DeepUnmarshalJSON(bytes.NewBuffer(sess.Out.Contents()), orgGroup)
+	Expect(err).NotTo(HaveOccurred())
+
+	return orgGroup.ConfigGroup
+}
+
+// channelOrgSigners returns a peer of each application organization of the
+// channel config that has joined the channel, to sign config updates with the
+// admin of its organization.
+func (n *Network) channelOrgSigners(channel string, config *common.Config) []*Peer {
+	var signers []*Peer
+	for orgName := range config.ChannelGroup.Groups["Application"].Groups {
+		for _, p := range n.PeersInOrg(orgName) {
+			if n.peerHasChannel(p, channel) {
+				signers = append(signers, p)
+				break
+			}
+		}
+	}
+	Expect(signers).NotTo(BeEmpty(), "no peers of channel %s found in the network", channel)
+	return signers
+}
+
+func (n *Network) peerHasChannel(p *Peer, channel string) bool {
+	for _, c := range p.Channels {
+		if c.Name == channel {
+			return true
+		}
+	}
+	return false
+}
@@ -13,7 +13,10 @@ import (
 	"io"
 	"io/ioutil"
 	"os"
+	"path/filepath"
+	"time"
 
+	"github.com/hyperledger/fabric/core/container/externalbuilder"
 	. "github.com/onsi/gomega"
 )
 
@@ -27,6 +30,31 @@ func PackageChaincodeBinary(c Chaincode) {
 	writeTarGz(c, file)
 }
 
+// PackageChaincodeServer is a helper function to package a chaincode that
+// runs as a service. The package contains a connection.json that points the
+// peer at Chaincode.ServerAddress and is written to the location specified by
+// Chaincode.PackageFile.
+func PackageChaincodeServer(c Chaincode) {
+	Expect(c.ServerAddress).NotTo(BeEmpty())
+
+	connection, err := json.Marshal(externalbuilder.ChaincodeServerUserData{
+		Address:     c.ServerAddress,
+		DialTimeout: externalbuilder.Duration(10 * time.Second),
+	})
+	Expect(err).NotTo(HaveOccurred())
+
+	tempDir, err := ioutil.TempDir("", "ccaas")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+	connectionFile := filepath.Join(tempDir, "connection.json")
+	err = ioutil.WriteFile(connectionFile, connection, 0644)
+	Expect(err).NotTo(HaveOccurred())
+
+	c.Lang = "ccaas"
+	c.CodeFiles = map[string]string{connectionFile: "connection.json"}
+	PackageChaincodeBinary(c)
+}
+
 func writeTarGz(c Chaincode, w io.Writer) {
 	gw := gzip.NewWriter(w)
 	tw := tar.NewWriter(gw)
@@ -0,0 +1,231 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+	"regexp"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/pkg/errors"
+)
+
+// PolicyRole is the role an identity must have to satisfy a principal of a
+// signature policy.
+type PolicyRole string
+
+const (
+	RoleMember  PolicyRole = "member"
+	RolePeer    PolicyRole = "peer"
+	RoleAdmin   PolicyRole = "admin"
+	RoleClient  PolicyRole = "client"
+	RoleOrderer PolicyRole = "orderer"
+)
+
+var mspIDRegexp = regexp.MustCompile(`^[[:alnum:].-]+$`)
+
+// SignaturePolicyBuilder is a node of a signature policy expression. A node
+// is either a principal created with SignedBy or a rule created with AllOf,
+// AnyOf, or OutOf that combines other nodes. The expression is rendered in the
+// syntax accepted by the peer CLI and configtxgen with Build.
+type SignaturePolicyBuilder struct {
+	principal string
+	n         int
+	rules     []*SignaturePolicyBuilder
+}
+
+// SignedBy returns a principal satisfied by an identity of the MSP with the
+// specified role.
+func SignedBy(mspID string, role PolicyRole) *SignaturePolicyBuilder {
+	return &SignaturePolicyBuilder{principal: fmt.Sprintf("%s.%s", mspID, role)}
+}
+
+// SignedByEach returns a principal with the specified role for each MSP.
+func SignedByEach(role PolicyRole, mspIDs ...string) []*SignaturePolicyBuilder {
+	var principals []*SignaturePolicyBuilder
+	for _, mspID := range mspIDs {
+		principals = append(principals, SignedBy(mspID, role))
+	}
+	return principals
+}
+
+// AllOf returns a rule satisfied when all of the rules are satisfied.
+func AllOf(rules ...*SignaturePolicyBuilder) *SignaturePolicyBuilder {
+	return &SignaturePolicyBuilder{n: len(rules), rules: rules}
+}
+
+// AnyOf returns a rule satisfied when at least one of the rules is satisfied.
+func AnyOf(rules ...*SignaturePolicyBuilder) *SignaturePolicyBuilder {
+	return &SignaturePolicyBuilder{n: 1, rules: rules}
+}
+
+// OutOf returns a rule satisfied when at least n of the rules are satisfied.
+func OutOf(n int, rules ...*SignaturePolicyBuilder) *SignaturePolicyBuilder {
+	return &SignaturePolicyBuilder{n: n, rules: rules}
+}
+
+// Validate checks that every principal names a valid MSP ID and role and that
+// every rule combines at least one nested node and requires between one and
+// all of them.
+func (s *SignaturePolicyBuilder) Validate() error {
+	if s == nil {
+		return errors.New("nil policy")
+	}
+
+	if s.principal != "" {
+		i := strings.LastIndex(s.principal, ".")
+		mspID, role := s.principal[:i], PolicyRole(s.principal[i+1:])
+		if !mspIDRegexp.MatchString(mspID) {
+			return errors.Errorf("invalid MSP ID in principal '%s'", s.principal)
+		}
+		switch role {
+		case RoleMember, RolePeer, RoleAdmin, RoleClient, RoleOrderer:
+		default:
+			return errors.Errorf("invalid role in principal '%s'", s.principal)
+		}
+		return nil
+	}
+
+	if len(s.rules) == 0 {
+		return errors.New("rule has no nested policies")
+	}
+	if s.n < 1 || s.n > len(s.rules) {
+		return errors.Errorf("rule requires %d of %d nested policies", s.n, len(s.rules))
+	}
+	for _, r := range s.rules {
+		if err := r.Validate(); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// String renders the expression without validating it.
+func (s *SignaturePolicyBuilder) String() string {
+	if s.principal != "" {
+		return fmt.Sprintf("'%s'", s.principal)
+	}
+
+	var rules []string
+	for _, r := range s.rules {
+		rules = append(rules, r.String())
+	}
+	switch s.n {
+	case len(s.rules):
+		return fmt.Sprintf("AND(%s)", strings.Join(rules, ","))
+	case 1:
+		return fmt.Sprintf("OR(%s)", strings.Join(rules, ","))
+	default:
+		return fmt.Sprintf("OutOf(%d,%s)", s.n, strings.Join(rules, ","))
+	}
+}
+
+// Build validates the expression and renders it for use as the
+// SignaturePolicy of a Chaincode, the Policy of a legacy Chaincode, or a
+// collection member policy.
+func (s *SignaturePolicyBuilder) Build() string {
+	Expect(s.Validate()).To(Succeed())
+	return s.String()
+}
+
+// SetChannelPolicy executes a config update that sets a policy of a config
+// group of the channel to the implicit meta policy. The group is a path of
+// group names below the channel group, such as "Application" or
+// "Application/Org1"; an empty path names the channel group itself. The
+// update is signed by the admins of the submitter and additional signers and
+// must satisfy the current modification policy.
+func SetChannelPolicy(n *Network, orderer *Orderer, channel, group, policyName string, policy *common.ImplicitMetaPolicy, submitter *Peer, additionalSigners ...*Peer) {
+	current, updated := channelPolicyUpdate(n, orderer, channel, group, policyName, policy, submitter)
+	UpdateConfig(n, orderer, channel, current, updated, false, submitter, additionalSigners...)
+}
+
+// SetChannelPolicySession submits the config update of SetChannelPolicy
+// without waiting for it to complete. The caller should wait on the returned
+// session to retrieve the exit code.
+func SetChannelPolicySession(n *Network, orderer *Orderer, channel, group, policyName string, policy *common.ImplicitMetaPolicy, submitter *Peer, additionalSigners ...*Peer) *gexec.Session {
+	current, updated := channelPolicyUpdate(n, orderer, channel, group, policyName, policy, submitter)
+	return UpdateConfigSession(n, orderer, channel, current, updated, submitter, additionalSigners...)
+}
+
+func channelPolicyUpdate(n *Network, orderer *Orderer, channel, group, policyName string, policy *common.ImplicitMetaPolicy, peer *Peer) (current, updated *common.Config) {
+	current = GetConfig(n, peer, orderer, channel)
+	updated = proto.Clone(current).(*common.Config)
+
+	configGroup := updated.ChannelGroup
+	if group != "" {
+		for _, name := range strings.Split(group, "/") {
+			configGroup = configGroup.Groups[name]
+			Expect(configGroup).NotTo(BeNil(), "config group %s not found in channel %s", group, channel)
+		}
+	}
+
+	modPolicy := "Admins"
+	if existing, ok := configGroup.Policies[policyName]; ok {
+		modPolicy = existing.ModPolicy
+	}
+	configGroup.Policies[policyName] = &common.ConfigPolicy{
+		ModPolicy: modPolicy,
+		Policy: &common.Policy{
+			Type:  int32(common.Policy_IMPLICIT_META),
+			Value: protoutil.MarshalOrPanic(policy),
+		},
+	}
+
+	return current, updated
+}
+
+// ChannelACLs returns the ACLs defined in the application group of the
+// channel config, mapping each resource to the policy reference that governs
+// it. Resources without an entry are governed by the peer's default ACLs.
+func ChannelACLs(n *Network, peer *Peer, orderer *Orderer, channel string) map[string]string {
+	config := GetConfig(n, peer, orderer, channel)
+	return channelACLs(config)
+}
+
+// SetChannelACL executes a config update that sets the policy reference
+// governing a resource, such as _lifecycle/CommitChaincodeDefinition, in the
+// ACLs of the application group of the channel. The update is signed by the
+// admins of the submitter and additional signers.
+func SetChannelACL(n *Network, orderer *Orderer, channel, resource, policyRef string, submitter *Peer, additionalSigners ...*Peer) {
+	current := GetConfig(n, submitter, orderer, channel)
+	updated := proto.Clone(current).(*common.Config)
+
+	acls := &pb.ACLs{Acls: map[string]*pb.APIResource{}}
+	for r, ref := range channelACLs(current) {
+		acls.Acls[r] = &pb.APIResource{PolicyRef: ref}
+	}
+	acls.Acls[resource] = &pb.APIResource{PolicyRef: policyRef}
+
+	updated.ChannelGroup.Groups["Application"].Values["ACLs"] = &common.ConfigValue{
+		ModPolicy: "Admins",
+		Value:     protoutil.MarshalOrPanic(acls),
+	}
+
+	UpdateConfig(n, orderer, channel, current, updated, false, submitter, additionalSigners...)
+}
+
+func channelACLs(config *common.Config) map[string]string {
+	result := map[string]string{}
+	value, ok := config.ChannelGroup.Groups["Application"].Values["ACLs"]
+	if !ok {
+		return result
+	}
+
+	acls := &pb.ACLs{}
+	err := proto.Unmarshal(value.Value, acls)
+	Expect(err).NotTo(HaveOccurred())
+	for resource, apiResource := range acls.Acls {
+		result[resource] = apiResource.PolicyRef
+	}
+	return result
+}
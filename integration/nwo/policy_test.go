@@ -0,0 +1,62 @@
+/*
+Copyright IBM Corp. All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo_test
+
+import (
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric/common/policydsl"
+	"github.com/hyperledger/fabric/integration/nwo"
+	. "github.com/onsi/ginkgo"
+	. "github.com/onsi/ginkgo/extensions/table"
+	. "github.com/onsi/gomega"
+)
+
+var _ = Describe("SignaturePolicyBuilder", func() {
+	It("renders principals with their roles", func() {
+		Expect(nwo.SignedBy("Org1MSP", nwo.RolePeer).Build()).To(Equal(`'Org1MSP.peer'`))
+		Expect(nwo.SignedBy("Org1MSP", nwo.RoleAdmin).Build()).To(Equal(`'Org1MSP.admin'`))
+	})
+
+	It("renders nested rules that the policy parser accepts", func() {
+		policy := nwo.OutOf(2,
+			nwo.AllOf(nwo.SignedBy("Org1MSP", nwo.RolePeer), nwo.SignedBy("Org1MSP", nwo.RoleClient)),
+			nwo.AnyOf(nwo.SignedByEach(nwo.RoleMember, "Org2MSP", "Org3MSP")...),
+			nwo.SignedBy("Org4MSP", nwo.RoleAdmin),
+		)
+		Expect(policy.Build()).To(Equal(`OutOf(2,AND('Org1MSP.peer','Org1MSP.client'),OR('Org2MSP.member','Org3MSP.member'),'Org4MSP.admin')`))
+
+		expected, err := policydsl.FromString(`OutOf(2, AND('Org1MSP.peer', 'Org1MSP.client'), OR('Org2MSP.member', 'Org3MSP.member'), 'Org4MSP.admin')`)
+		Expect(err).NotTo(HaveOccurred())
+		actual, err := policydsl.FromString(policy.Build())
+		Expect(err).NotTo(HaveOccurred())
+		Expect(proto.Equal(actual, expected)).To(BeTrue())
+	})
+
+	It("generates policies across a variable number of organizations", func() {
+		mspIDs := []string{"Org1MSP", "Org2MSP", "Org3MSP", "Org4MSP", "Org5MSP"}
+		for i := 1; i <= len(mspIDs); i++ {
+			policy := nwo.OutOf(i, nwo.SignedByEach(nwo.RoleMember, mspIDs...)...)
+			envelope, err := policydsl.FromString(policy.Build())
+			Expect(err).NotTo(HaveOccurred())
+			Expect(envelope.Identities).To(HaveLen(len(mspIDs)))
+			Expect(envelope.Rule.GetNOutOf().N).To(Equal(int32(i)))
+		}
+	})
+
+	DescribeTable("rejects invalid expressions",
+		func(policy *nwo.SignaturePolicyBuilder, expectedErr string) {
+			Expect(policy.Validate()).To(MatchError(expectedErr))
+		},
+		Entry("nil policy", (*nwo.SignaturePolicyBuilder)(nil), "nil policy"),
+		Entry("empty rule", nwo.AllOf(), "rule has no nested policies"),
+		Entry("threshold too high", nwo.OutOf(3, nwo.SignedByEach(nwo.RoleMember, "Org1MSP", "Org2MSP")...), "rule requires 3 of 2 nested policies"),
+		Entry("threshold too low", nwo.OutOf(0, nwo.SignedBy("Org1MSP", nwo.RoleMember)), "rule requires 0 of 1 nested policies"),
+		Entry("invalid role", nwo.SignedBy("Org1MSP", "auditor"), "invalid role in principal 'Org1MSP.auditor'"),
+		Entry("invalid MSP ID", nwo.SignedBy("Org1 MSP", nwo.RoleMember), "invalid MSP ID in principal 'Org1 MSP.member'"),
+		Entry("invalid nested policy", nwo.AnyOf(nwo.SignedBy("Org1MSP", nwo.RoleMember), nwo.AllOf()), "rule has no nested policies"),
+	)
+})
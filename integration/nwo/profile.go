@@ -0,0 +1,74 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+	"io/ioutil"
+	"net/http"
+
+	"github.com/hyperledger/fabric/integration/nwo/fabricconfig"
+	. "github.com/onsi/gomega"
+)
+
+// EnablePeerProfiling enables the Go pprof service of the peer. The change
+// takes effect the next time the peer starts.
+func (n *Network) EnablePeerProfiling(p *Peer) {
+	core := n.ReadPeerConfig(p)
+	if core.Peer.Profile == nil {
+		core.Peer.Profile = &fabricconfig.Service{}
+	}
+	core.Peer.Profile.Enabled = true
+	core.Peer.Profile.ListenAddress = fmt.Sprintf("127.0.0.1:%d", n.PeerPort(p, ProfilePort))
+	n.WritePeerConfig(p, core)
+}
+
+// EnableOrdererProfiling enables the Go pprof service of the orderer. The
+// change takes effect the next time the orderer starts.
+func (n *Network) EnableOrdererProfiling(o *Orderer) {
+	ordererConfig := n.ReadOrdererConfig(o)
+	if ordererConfig.General.Profile == nil {
+		ordererConfig.General.Profile = &fabricconfig.OrdererProfile{}
+	}
+	ordererConfig.General.Profile.Enabled = true
+	ordererConfig.General.Profile.Address = fmt.Sprintf("127.0.0.1:%d", n.OrdererPort(o, ProfilePort))
+	n.WriteOrdererConfig(o, ordererConfig)
+}
+
+// PeerProfileURL returns the base URL of the peer's pprof service.
+func (n *Network) PeerProfileURL(p *Peer) string {
+	return fmt.Sprintf("http://127.0.0.1:%d", n.PeerPort(p, ProfilePort))
+}
+
+// OrdererProfileURL returns the base URL of the orderer's pprof service.
+func (n *Network) OrdererProfileURL(o *Orderer) string {
+	return fmt.Sprintf("http://127.0.0.1:%d", n.OrdererPort(o, ProfilePort))
+}
+
+// FetchProfile retrieves a profile, such as "goroutine", "heap", or
+// "profile", from the pprof service at profileURL and returns the raw
+// profile. CPU profiles are sampled for one second.
+//
+// The pprof service is served over plain HTTP on the profile port rather
+// than on the operations endpoint, so it is not protected by client
+// authentication and must only be enabled on trusted networks.
+func FetchProfile(client *http.Client, profileURL, profileType string) []byte {
+	url := fmt.Sprintf("%s/debug/pprof/%s", profileURL, profileType)
+	if profileType == "profile" {
+		url += "?seconds=1"
+	}
+
+	resp, err := client.Get(url)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK), "unexpected status fetching %s", url)
+
+	profile, err := ioutil.ReadAll(resp.Body)
+	Expect(err).NotTo(HaveOccurred())
+	Expect(profile).NotTo(BeEmpty())
+	return profile
+}
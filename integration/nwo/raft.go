@@ -0,0 +1,204 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"bytes"
+	"fmt"
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
+	. "github.com/onsi/gomega"
+)
+
+// RaftLeaders returns a function that reports the orderers that consider
+// themselves the etcdraft leader of the channel. The state is read from the
+// consensus_etcdraft_is_leader gauge served on each orderer's operations
+// endpoint, so the network must use the prometheus metrics provider. Only the
+// specified orderers are consulted; they must all be running.
+func RaftLeaders(n *Network, channel string, orderers ...*Orderer) func() []*Orderer {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "raft leadership is read from prometheus metrics")
+
+	return func() []*Orderer {
+		var leaders []*Orderer
+		for _, o := range orderers {
+			authClient, _ := OrdererOperationalClients(n, o)
+			isLeader, _ := channelGauge(authClient, n.OrdererOperationsURL(o, "metrics"), "consensus_etcdraft_is_leader", channel)
+			if isLeader == 1 {
+				leaders = append(leaders, o)
+			}
+		}
+		return leaders
+	}
+}
+
+// WaitForLeader waits until exactly one of the orderers reports that it is the
+// etcdraft leader of the channel and returns that orderer.
+func WaitForLeader(n *Network, channel string, orderers ...*Orderer) *Orderer {
+	leaders := RaftLeaders(n, channel, orderers...)
+	Eventually(leaders, n.EventuallyTimeout).Should(HaveLen(1), "waiting for a single leader of %s", channel)
+	return leaders()[0]
+}
+
+// ChannelConsenters returns the etcdraft consenters of the channel as
+// recorded in the latest channel config.
+func ChannelConsenters(n *Network, peer *Peer, orderer *Orderer, channel string) []*etcdraft.Consenter {
+	config := GetConfig(n, peer, orderer, channel)
+
+	consensusType := ConsensusTypeFromConfig(config)
+	Expect(consensusType.Type).To(Equal("etcdraft"))
+
+	metadata := &etcdraft.ConfigMetadata{}
+	err := proto.Unmarshal(consensusType.Metadata, metadata)
+	Expect(err).NotTo(HaveOccurred())
+
+	return metadata.Consenters
+}
+
+// OrdererConsenter returns the etcdraft consenter for the orderer, using its
+// cluster port and TLS server certificate.
+func (n *Network) OrdererConsenter(o *Orderer) etcdraft.Consenter {
+	cert, err := ioutil.ReadFile(filepath.Join(n.OrdererLocalTLSDir(o), "server.crt"))
+	Expect(err).NotTo(HaveOccurred())
+
+	return etcdraft.Consenter{
+		Host:          "127.0.0.1",
+		Port:          uint32(n.OrdererPort(o, ClusterPort)),
+		ClientTlsCert: cert,
+		ServerTlsCert: cert,
+	}
+}
+
+// SetOrdererClusterListenAddress sets the address of the dedicated listener
+// that serves the orderer's cluster service. The listener keeps the
+// orderer's Cluster port, which is the port recorded for the orderer in the
+// etcdraft consenter set, while clients continue to use the Listen port. The
+// change takes effect the next time the orderer starts.
+func (n *Network) SetOrdererClusterListenAddress(o *Orderer, address string) {
+	ordererConfig := n.ReadOrdererConfig(o)
+	ordererConfig.General.Cluster.ListenAddress = address
+	ordererConfig.General.Cluster.ListenPort = int(n.OrdererPort(o, ClusterPort))
+	n.WriteOrdererConfig(o, ordererConfig)
+}
+
+// SetOrdererClusterCertificates sets the TLS key pairs the orderer's cluster
+// service presents to other orderers as a server and as a client. The change
+// takes effect the next time the orderer starts.
+func (n *Network) SetOrdererClusterCertificates(o *Orderer, serverCert, serverKey, clientCert, clientKey string) {
+	ordererConfig := n.ReadOrdererConfig(o)
+	ordererConfig.General.Cluster.ServerCertificate = serverCert
+	ordererConfig.General.Cluster.ServerPrivateKey = serverKey
+	ordererConfig.General.Cluster.ClientCertificate = clientCert
+	ordererConfig.General.Cluster.ClientPrivateKey = clientKey
+	n.WriteOrdererConfig(o, ordererConfig)
+}
+
+// AddConsenter adds a new consenter to the given channel.
+func AddConsenter(n *Network, peer *Peer, orderer *Orderer, channel string, consenter etcdraft.Consenter) {
+	UpdateEtcdRaftMetadata(n, peer, orderer, channel, func(metadata *etcdraft.ConfigMetadata) {
+		metadata.Consenters = append(metadata.Consenters, &consenter)
+	})
+}
+
+// RemoveConsenter removes a consenter with the given certificate in PEM format
+// from the given channel.
+func RemoveConsenter(n *Network, peer *Peer, orderer *Orderer, channel string, certificate []byte) {
+	UpdateEtcdRaftMetadata(n, peer, orderer, channel, func(metadata *etcdraft.ConfigMetadata) {
+		var newConsenters []*etcdraft.Consenter
+		for _, consenter := range metadata.Consenters {
+			if bytes.Equal(consenter.ClientTlsCert, certificate) || bytes.Equal(consenter.ServerTlsCert, certificate) {
+				continue
+			}
+			newConsenters = append(newConsenters, consenter)
+		}
+
+		metadata.Consenters = newConsenters
+	})
+}
+
+// UpdateEtcdRaftMetadata executes a config update that updates the etcdraft
+// metadata according to the given function f.
+func UpdateEtcdRaftMetadata(network *Network, peer *Peer, orderer *Orderer, channel string, f func(md *etcdraft.ConfigMetadata)) {
+	UpdateConsensusMetadata(network, peer, orderer, channel, func(originalMetadata []byte) []byte {
+		metadata := &etcdraft.ConfigMetadata{}
+		err := proto.Unmarshal(originalMetadata, metadata)
+		Expect(err).NotTo(HaveOccurred())
+
+		f(metadata)
+
+		newMetadata, err := proto.Marshal(metadata)
+		Expect(err).NotTo(HaveOccurred())
+		return newMetadata
+	})
+}
+
+// OrdererRaftWALDir returns the directory where the orderer keeps the
+// etcdraft write ahead logs of its channels.
+func (n *Network) OrdererRaftWALDir(o *Orderer) string {
+	return filepath.Join(n.OrdererDir(o), "etcdraft", "wal")
+}
+
+// OrdererRaftSnapshotDir returns the directory where the orderer keeps the
+// etcdraft snapshots of its channels.
+func (n *Network) OrdererRaftSnapshotDir(o *Orderer) string {
+	return filepath.Join(n.OrdererDir(o), "etcdraft", "snapshot")
+}
+
+// RaftState describes the etcdraft storage of a channel on an orderer.
+type RaftState struct {
+	// SnapshotCount is the number of snapshots retained by the orderer.
+	SnapshotCount int
+	// LatestSnapshotIndex is the raft index of the most recent snapshot. It
+	// is zero when there are no snapshots.
+	LatestSnapshotIndex uint64
+	// WALFiles are the names of the write ahead log segments in order.
+	WALFiles []string
+}
+
+// OrdererRaftState reads the etcdraft snapshots and write ahead log of the
+// channel from the orderer's file system. A channel the orderer has not
+// written to yet has an empty state.
+func OrdererRaftState(n *Network, o *Orderer, channel string) RaftState {
+	var state RaftState
+
+	for _, name := range raftFiles(filepath.Join(n.OrdererRaftSnapshotDir(o), channel), ".snap") {
+		// snapshots are named <term>-<index>.snap with hex encoded numbers
+		var term, index uint64
+		_, err := fmt.Sscanf(name, "%016x-%016x.snap", &term, &index)
+		Expect(err).NotTo(HaveOccurred(), "unexpected snapshot file name %s", name)
+		state.SnapshotCount++
+		if index > state.LatestSnapshotIndex {
+			state.LatestSnapshotIndex = index
+		}
+	}
+	state.WALFiles = raftFiles(filepath.Join(n.OrdererRaftWALDir(o), channel), ".wal")
+
+	return state
+}
+
+// raftFiles returns the sorted names of the files in dir with the suffix.
+func raftFiles(dir, suffix string) []string {
+	files, err := ioutil.ReadDir(dir)
+	if os.IsNotExist(err) {
+		return nil
+	}
+	Expect(err).NotTo(HaveOccurred())
+
+	var names []string
+	for _, f := range files {
+		if !f.IsDir() && strings.HasSuffix(f.Name(), suffix) {
+			names = append(names, f.Name())
+		}
+	}
+	sort.Strings(names)
+	return names
+}
@@ -0,0 +1,134 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"io/ioutil"
+	"os"
+	"path/filepath"
+	"strconv"
+
+	"github.com/golang/protobuf/proto"
+	"github.com/hyperledger/fabric-protos-go/common"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset"
+	"github.com/hyperledger/fabric-protos-go/ledger/rwset/kvrwset"
+	pb "github.com/hyperledger/fabric-protos-go/peer"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/protoutil"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// KVRead is a key read by a transaction. The version identifies the
+// transaction that last wrote the key when it was read and is nil when the
+// key did not exist.
+type KVRead struct {
+	Namespace string
+	Key       string
+	Version   *kvrwset.Version
+}
+
+// KVWrite is a key written or deleted by a transaction.
+type KVWrite struct {
+	Namespace string
+	Key       string
+	Value     []byte
+	IsDelete  bool
+}
+
+// ReadWriteSet is the public read/write set of a committed transaction along
+// with its position in the ledger and the validation code the peer assigned
+// to it.
+type ReadWriteSet struct {
+	BlockNum       uint64
+	TxNum          uint64
+	ValidationCode pb.TxValidationCode
+	Reads          []KVRead
+	Writes         []KVWrite
+}
+
+// TxReadWriteSet searches the channel's blocks on the peer, from newest to
+// oldest, for the endorser transaction and returns its decoded read/write
+// set.
+func TxReadWriteSet(n *Network, peer *Peer, channel, txID string) ReadWriteSet {
+	tempDir, err := ioutil.TempDir(n.RootDir, "txReadWriteSet")
+	Expect(err).NotTo(HaveOccurred())
+	defer os.RemoveAll(tempDir)
+
+	fetch := func(block string) *common.Block {
+		output := filepath.Join(tempDir, "block_"+block+".pb")
+		sess, err := n.PeerUserSession(peer, "User1", commands.ChannelFetch{
+			ChannelID:  channel,
+			Block:      block,
+			OutputFile: output,
+			ClientAuth: n.ClientAuthRequired,
+		})
+		Expect(err).NotTo(HaveOccurred())
+		Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+		return UnmarshalBlockFromFile(output)
+	}
+
+	block := fetch("newest")
+	for {
+		if rws, ok := blockTxReadWriteSet(block, txID); ok {
+			return rws
+		}
+		Expect(block.Header.Number).NotTo(BeZero(), "transaction %s not found on channel %s", txID, channel)
+		block = fetch(strconv.FormatUint(block.Header.Number-1, 10))
+	}
+}
+
+// blockTxReadWriteSet returns the read/write set of the transaction if the
+// block contains it.
+func blockTxReadWriteSet(block *common.Block, txID string) (ReadWriteSet, bool) {
+	flags := block.Metadata.Metadata[common.BlockMetadataIndex_TRANSACTIONS_FILTER]
+	for i, envBytes := range block.Data.Data {
+		env, err := protoutil.GetEnvelopeFromBlock(envBytes)
+		Expect(err).NotTo(HaveOccurred())
+		payload, err := protoutil.UnmarshalPayload(env.Payload)
+		Expect(err).NotTo(HaveOccurred())
+		chdr, err := protoutil.UnmarshalChannelHeader(payload.Header.ChannelHeader)
+		Expect(err).NotTo(HaveOccurred())
+		if chdr.TxId != txID {
+			continue
+		}
+		Expect(chdr.Type).To(Equal(int32(common.HeaderType_ENDORSER_TRANSACTION)), "transaction %s is not an endorser transaction", txID)
+
+		tx, err := protoutil.UnmarshalTransaction(payload.Data)
+		Expect(err).NotTo(HaveOccurred())
+		Expect(tx.Actions).NotTo(BeEmpty())
+		ccActionPayload, err := protoutil.UnmarshalChaincodeActionPayload(tx.Actions[0].Payload)
+		Expect(err).NotTo(HaveOccurred())
+		prp, err := protoutil.UnmarshalProposalResponsePayload(ccActionPayload.Action.ProposalResponsePayload)
+		Expect(err).NotTo(HaveOccurred())
+		ccAction, err := protoutil.UnmarshalChaincodeAction(prp.Extension)
+		Expect(err).NotTo(HaveOccurred())
+
+		txRWSet := &rwset.TxReadWriteSet{}
+		err = proto.Unmarshal(ccAction.Results, txRWSet)
+		Expect(err).NotTo(HaveOccurred())
+
+		rws := ReadWriteSet{
+			BlockNum:       block.Header.Number,
+			TxNum:          uint64(i),
+			ValidationCode: pb.TxValidationCode(flags[i]),
+		}
+		for _, nsRWSet := range txRWSet.NsRwset {
+			kvRWSet := &kvrwset.KVRWSet{}
+			err := proto.Unmarshal(nsRWSet.Rwset, kvRWSet)
+			Expect(err).NotTo(HaveOccurred())
+			for _, read := range kvRWSet.Reads {
+				rws.Reads = append(rws.Reads, KVRead{Namespace: nsRWSet.Namespace, Key: read.Key, Version: read.Version})
+			}
+			for _, write := range kvRWSet.Writes {
+				rws.Writes = append(rws.Writes, KVWrite{Namespace: nsRWSet.Namespace, Key: write.Key, Value: write.Value, IsDelete: write.IsDelete})
+			}
+		}
+		return rws, true
+	}
+	return ReadWriteSet{}, false
+}
@@ -219,3 +219,42 @@ func MultiNodeEtcdRaft() *Config {
 	}}
 	return config
 }
+
+// MultiOrgEtcdRaft is a configuration with three orderer organizations, each
+// owning one of the three etcdraft consenters, and two peer organizations.
+func MultiOrgEtcdRaft() *Config {
+	config := MultiNodeEtcdRaft()
+
+	config.Organizations = append(
+		[]*Organization{{
+			Name:          "OrdererOrg1",
+			MSPID:         "OrdererMSP1",
+			Domain:        "orderer1.example.com",
+			EnableNodeOUs: false,
+			Users:         0,
+			CA:            &CA{Hostname: "ca"},
+		}, {
+			Name:          "OrdererOrg2",
+			MSPID:         "OrdererMSP2",
+			Domain:        "orderer2.example.com",
+			EnableNodeOUs: false,
+			Users:         0,
+			CA:            &CA{Hostname: "ca"},
+		}, {
+			Name:          "OrdererOrg3",
+			MSPID:         "OrdererMSP3",
+			Domain:        "orderer3.example.com",
+			EnableNodeOUs: false,
+			Users:         0,
+			CA:            &CA{Hostname: "ca"},
+		}},
+		config.Organizations[1:]...,
+	)
+	config.Orderers = []*Orderer{
+		{Name: "orderer1", Organization: "OrdererOrg1"},
+		{Name: "orderer2", Organization: "OrdererOrg2"},
+		{Name: "orderer3", Organization: "OrdererOrg3"},
+	}
+
+	return config
+}
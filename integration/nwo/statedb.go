@@ -0,0 +1,174 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"encoding/json"
+	"fmt"
+	"io/ioutil"
+	"net/http"
+	"net/url"
+	"path/filepath"
+	"regexp"
+	"strings"
+	"syscall"
+
+	"github.com/hyperledger/fabric/core/ledger/kvledger/txmgmt/statedb/stateleveldb"
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	"github.com/hyperledger/fabric/integration/runner"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+	"github.com/tedsuo/ifrit"
+)
+
+// SetPeerStateCacheSize sets the size, in megabytes, of the in-memory cache
+// the peer keeps in front of a CouchDB state database. The peer rounds the
+// size up to a multiple of 32 and a size of zero disables the cache. The
+// change takes effect the next time the peer starts.
+func (n *Network) SetPeerStateCacheSize(p *Peer, sizeMB int) {
+	Expect(sizeMB).To(BeNumerically(">=", 0))
+
+	core := n.ReadPeerConfig(p)
+	core.Ledger.State.CouchDBConfig.CacheSize = sizeMB
+	n.WritePeerConfig(p, core)
+}
+
+// ReadStateDB reads the committed value of a chaincode key directly from the
+// state database of the peer, bypassing the chaincode and the peer's caches.
+// It returns nil when the key does not exist. A CouchDB state database is
+// read over HTTP while the peer runs. A LevelDB state database is locked by
+// the peer, so the peer must be stopped before its state is read.
+func ReadStateDB(n *Network, p *Peer, channel, chaincode, key string) []byte {
+	reader := newStateDBReader(n, p)
+	defer reader.Close()
+	return reader.ReadState(channel, chaincode, key)
+}
+
+// stateDBReader reads committed chaincode state from one kind of state
+// database.
+type stateDBReader interface {
+	ReadState(channel, chaincode, key string) []byte
+	Close()
+}
+
+func newStateDBReader(n *Network, p *Peer) stateDBReader {
+	core := n.ReadPeerConfig(p)
+	if core.Ledger.State.StateDatabase == "CouchDB" {
+		return &couchDBReader{address: core.Ledger.State.CouchDBConfig.CouchDBAddress}
+	}
+
+	provider, err := stateleveldb.NewVersionedDBProvider(filepath.Join(n.PeerLedgerDir(p), "stateLeveldb"))
+	Expect(err).NotTo(HaveOccurred())
+	return &levelDBReader{provider: provider}
+}
+
+type levelDBReader struct {
+	provider *stateleveldb.VersionedDBProvider
+}
+
+func (l *levelDBReader) ReadState(channel, chaincode, key string) []byte {
+	db, err := l.provider.GetDBHandle(channel, nil)
+	Expect(err).NotTo(HaveOccurred())
+	vv, err := db.GetState(chaincode, key)
+	Expect(err).NotTo(HaveOccurred())
+	if vv == nil {
+		return nil
+	}
+	return vv.Value
+}
+
+func (l *levelDBReader) Close() {
+	l.provider.Close()
+}
+
+type couchDBReader struct {
+	address string
+}
+
+var upperCase = regexp.MustCompile(`([A-Z])`)
+
+// ReadState fetches the document of the key from the database the peer
+// keeps for the chaincode namespace of the channel. JSON values are stored as
+// the document body next to the peer's bookkeeping fields, and other values
+// are stored as an attachment of the document.
+func (c *couchDBReader) ReadState(channel, chaincode, key string) []byte {
+	// the peer escapes upper case letters of the namespace; the hashed names
+	// it uses for namespaces that exceed the couchdb limit are not supported
+	dbName := channel + "_" + strings.ToLower(upperCase.ReplaceAllString(chaincode, "$$$1"))
+	Expect(len(dbName)).To(BeNumerically("<=", 238), "database name %s is too long", dbName)
+	docURL := fmt.Sprintf("http://%s/%s/%s", c.address, url.PathEscape(dbName), url.PathEscape(key))
+
+	body, found := c.get(docURL)
+	if !found {
+		return nil
+	}
+	doc := map[string]json.RawMessage{}
+	err := json.Unmarshal(body, &doc)
+	Expect(err).NotTo(HaveOccurred())
+
+	if attachments, ok := doc["_attachments"]; ok {
+		Expect(string(attachments)).To(ContainSubstring(`"valueBytes"`))
+		value, found := c.get(docURL + "/valueBytes")
+		Expect(found).To(BeTrue())
+		return value
+	}
+
+	for _, field := range []string{"_id", "_rev", "~version"} {
+		delete(doc, field)
+	}
+	value, err := json.Marshal(doc)
+	Expect(err).NotTo(HaveOccurred())
+	return value
+}
+
+func (c *couchDBReader) get(url string) (body []byte, found bool) {
+	req, err := http.NewRequest(http.MethodGet, url, nil)
+	Expect(err).NotTo(HaveOccurred())
+	req.SetBasicAuth(runner.CouchDBUsername, runner.CouchDBPassword)
+
+	resp, err := http.DefaultClient.Do(req)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	body, err = ioutil.ReadAll(resp.Body)
+	Expect(err).NotTo(HaveOccurred())
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, false
+	}
+	Expect(resp.StatusCode).To(Equal(http.StatusOK), "unexpected response from couchdb: %s", body)
+	return body, true
+}
+
+func (c *couchDBReader) Close() {}
+
+// RebuildStateDB stops the process, which must run the peer, drops the state
+// database and the other ledger databases of the peer, and invokes the runner
+// so that the peer rebuilds them from its block store when it starts. The
+// runner must start the members of the stopped process again, for example
+// PeerRunner when the process runs only the peer, or NetworkGroupRunner when
+// it runs the whole network. The returned process replaces the one that was
+// stopped. CouchDB and LevelDB state databases are both supported.
+func RebuildStateDB(n *Network, p *Peer, process ifrit.Process, runner ifrit.Runner) ifrit.Process {
+	process.Signal(syscall.SIGTERM)
+	Eventually(process.Wait(), n.EventuallyTimeout).Should(Receive())
+
+	cmd := n.peerCommand(
+		commands.NodeRebuildDBs{},
+		"",
+		"",
+		fmt.Sprintf("FABRIC_CFG_PATH=%s", n.PeerDir(p)),
+		fmt.Sprintf("CORE_LEDGER_STATE_COUCHDBCONFIG_USERNAME=admin"),
+		fmt.Sprintf("CORE_LEDGER_STATE_COUCHDBCONFIG_PASSWORD=adminpw"),
+	)
+	sess, err := n.StartSession(cmd, commands.NodeRebuildDBs{}.SessionName())
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+
+	process = ifrit.Invoke(runner)
+	Eventually(process.Ready(), n.EventuallyTimeout).Should(BeClosed())
+	return process
+}
@@ -0,0 +1,44 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"fmt"
+
+	. "github.com/onsi/gomega"
+)
+
+// SetPeerValidatorPoolSize sets the number of goroutines the peer uses to
+// validate the transactions of a block in parallel. A size of zero uses the
+// number of CPUs. The change takes effect the next time the peer starts.
+func (n *Network) SetPeerValidatorPoolSize(p *Peer, size int) {
+	Expect(size).To(BeNumerically(">=", 0))
+
+	core := n.ReadPeerConfig(p)
+	core.Peer.ValidatorPoolSize = size
+	n.WritePeerConfig(p, core)
+}
+
+// BlockValidationDuration returns a function that reports the total time, in
+// seconds, the peer has spent validating the blocks of the channel since it
+// started. The function returns -1 until the peer has validated a block of
+// the channel.
+func BlockValidationDuration(n *Network, p *Peer, channel string) func() float64 {
+	Expect(n.MetricsProvider).To(Equal("prometheus"), "metrics are read from prometheus")
+
+	authClient, _ := PeerOperationalClients(n, p)
+	metricsURL := n.PeerOperationsURL(p, "metrics")
+	samples := metricSamples("gossip_privdata_validation_duration_sum")
+	label := fmt.Sprintf(`channel="%s"`, channel)
+	return func() float64 {
+		value, ok := sumMetric(authClient, metricsURL, samples, label)
+		if !ok {
+			return -1
+		}
+		return value
+	}
+}
@@ -0,0 +1,72 @@
+/*
+Copyright IBM Corp All Rights Reserved.
+
+SPDX-License-Identifier: Apache-2.0
+*/
+
+package nwo
+
+import (
+	"encoding/json"
+	"net/http"
+	"regexp"
+
+	"github.com/hyperledger/fabric/integration/nwo/commands"
+	. "github.com/onsi/gomega"
+	"github.com/onsi/gomega/gexec"
+)
+
+// VersionInfo identifies the build of a peer or orderer.
+type VersionInfo struct {
+	Version   string `json:"Version"`
+	CommitSHA string `json:"CommitSHA"`
+}
+
+var (
+	versionLine   = regexp.MustCompile(`(?m)^ Version: (.*)$`)
+	commitSHALine = regexp.MustCompile(`(?m)^ Commit SHA: (.*)$`)
+)
+
+// ComponentVersion runs the peer or orderer binary at the path with the
+// version command and returns the build it reports. The path is typically
+// obtained from the network's Components, for example
+// n.Components.Orderer().
+func ComponentVersion(n *Network, component string) VersionInfo {
+	sess, err := n.StartSession(NewCommand(component, commands.Version{}), "version")
+	Expect(err).NotTo(HaveOccurred())
+	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(0))
+
+	output := sess.Out.Contents()
+	version := versionLine.FindSubmatch(output)
+	Expect(version).NotTo(BeNil(), "no version in output: %s", output)
+	commitSHA := commitSHALine.FindSubmatch(output)
+	Expect(commitSHA).NotTo(BeNil(), "no commit in output: %s", output)
+
+	return VersionInfo{Version: string(version[1]), CommitSHA: string(commitSHA[1])}
+}
+
+// PeerVersion returns the build reported by the version endpoint of the
+// running peer's operations service.
+func PeerVersion(n *Network, p *Peer) VersionInfo {
+	authClient, _ := PeerOperationalClients(n, p)
+	return runningVersion(authClient, n.PeerOperationsURL(p, "version"))
+}
+
+// OrdererVersion returns the build reported by the version endpoint of the
+// running orderer's operations service.
+func OrdererVersion(n *Network, o *Orderer) VersionInfo {
+	authClient, _ := OrdererOperationalClients(n, o)
+	return runningVersion(authClient, n.OrdererOperationsURL(o, "version"))
+}
+
+func runningVersion(client *http.Client, versionURL string) VersionInfo {
+	resp, err := client.Get(versionURL)
+	Expect(err).NotTo(HaveOccurred())
+	defer resp.Body.Close()
+	Expect(resp.StatusCode).To(Equal(http.StatusOK))
+
+	var versionInfo VersionInfo
+	err = json.NewDecoder(resp.Body).Decode(&versionInfo)
+	Expect(err).NotTo(HaveOccurred())
+	return versionInfo
+}
@@ -139,7 +139,7 @@ var _ bool = Describe("PrivateData", func() {
 				WaitForEvent: true,
 			}
 			expectedErrMsg := `Error: endorsement failure during invoke. response: status:500 message:"error in simulation: failed to distribute private collection`
-			invokeChaincodeExpectErr(network, network.Peer("Org1", "peer0"), command, expectedErrMsg)
+			nwo.InvokeExpectingError(network, network.Peer("Org1", "peer0"), command, expectedErrMsg)
 		})
 
 		When("collection config does not have maxPeerCount or requiredPeerCount", func() {
@@ -508,6 +508,24 @@ var _ bool = Describe("PrivateData", func() {
 						assertPvtdataPresencePerCollectionConfig1(network, testChaincode.Name, "marble1", org1Peer1)
 					})
 				})
+
+				When("a new peer in org2 that does not pull private data joins the channel", func() {
+					It("causes the new peer to reconcile the private data it missed", func() {
+						By("disabling the pulling of private data on the new peer")
+						core := network.ReadPeerConfig(org2Peer1)
+						core.Peer.Gossip.PvtData.PullRetryThreshold = 0
+						network.WritePeerConfig(org2Peer1, core)
+
+						By("adding the new peer")
+						newPeerProcess = addPeer(network, orderer, org2Peer1)
+						installChaincode(network, testChaincode, org2Peer1)
+						network.VerifyMembership(network.Peers, channelID, "marblesp")
+
+						By("waiting for the new peer to reconcile the missing private data")
+						nwo.WaitForPvtDataReconciliation(network, org2Peer1, channelID)
+						assertPvtdataPresencePerCollectionConfig1(network, testChaincode.Name, "marble1", org2Peer1)
+					})
+				})
 			}
 
 			When("chaincode is migrated from legacy to new lifecycle with same collection config", func() {
@@ -932,7 +950,7 @@ var _ bool = Describe("PrivateData", func() {
 			}
 			peer1 := network.Peer("Org1", "peer0")
 			expectedErrMsg := "tx creator does not have write access permission"
-			invokeChaincodeExpectErr(network, peer1, command, expectedErrMsg)
+			nwo.InvokeExpectingError(network, peer1, command, expectedErrMsg)
 
 			assertMarbleAPIs()
 			assertDeliverWithPrivateDataACLBehavior()
@@ -1095,13 +1113,6 @@ func invokeChaincode(n *nwo.Network, peer *nwo.Peer, command commands.ChaincodeI
 	Expect(sess.Err).To(gbytes.Say("Chaincode invoke successful."))
 }
 
-func invokeChaincodeExpectErr(n *nwo.Network, peer *nwo.Peer, command commands.ChaincodeInvoke, expectedErrMsg string) {
-	sess, err := n.PeerUserSession(peer, "User1", command)
-	Expect(err).NotTo(HaveOccurred())
-	Eventually(sess, n.EventuallyTimeout).Should(gexec.Exit(1))
-	Expect(sess.Err).To(gbytes.Say(expectedErrMsg))
-}
-
 func approveChaincodeForMyOrgExpectErr(n *nwo.Network, orderer *nwo.Orderer, chaincode nwo.Chaincode, expectedErrMsg string, peers ...*nwo.Peer) {
 	// used to ensure we only approve once per org
 	approvedOrgs := map[string]bool{}
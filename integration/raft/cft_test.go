@@ -14,7 +14,6 @@ import (
 	"fmt"
 	"io/ioutil"
 	"os"
-	"path"
 	"path/filepath"
 	"strconv"
 	"sync"
@@ -25,12 +24,15 @@ import (
 	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric-protos-go/common"
 	"github.com/hyperledger/fabric-protos-go/msp"
+	protosorderer "github.com/hyperledger/fabric-protos-go/orderer"
+	"github.com/hyperledger/fabric-protos-go/orderer/etcdraft"
 	"github.com/hyperledger/fabric/cmd/common/signer"
 	"github.com/hyperledger/fabric/common/configtx"
 	"github.com/hyperledger/fabric/common/util"
 	"github.com/hyperledger/fabric/integration/nwo"
 	"github.com/hyperledger/fabric/integration/nwo/commands"
 	"github.com/hyperledger/fabric/integration/ordererclient"
+	"github.com/hyperledger/fabric/orderer/common/cluster"
 	"github.com/hyperledger/fabric/protoutil"
 	. "github.com/onsi/ginkgo"
 	. "github.com/onsi/gomega"
@@ -89,9 +91,11 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 			network.GenerateConfigTree()
 			network.Bootstrap()
 
-			o1Runner := network.OrdererRunner(o1)
 			// Enable debug log for orderer2 so we could assert its content later
-			o2Runner := network.OrdererRunner(o2, "FABRIC_LOGGING_SPEC=orderer.consensus.etcdraft=debug:info")
+			network.SetOrdererLogSpec(o2, "orderer.consensus.etcdraft=debug:info")
+
+			o1Runner := network.OrdererRunner(o1)
+			o2Runner := network.OrdererRunner(o2)
 			o3Runner := network.OrdererRunner(o3)
 			orderers := grouper.Members{
 				{Name: o2.ID(), Runner: o2Runner},
@@ -148,6 +152,120 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 		})
 	})
 
+	When("the cluster service listens on a dedicated address", func() {
+		It("forms the cluster over the cluster listener and serves clients on the main listener", func() {
+			network = nwo.New(nwo.MultiNodeEtcdRaft(), testDir, client, StartPort(), components)
+			o1, o2, o3 := network.Orderer("orderer1"), network.Orderer("orderer2"), network.Orderer("orderer3")
+
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			By("binding the cluster listeners to all interfaces")
+			for _, o := range []*nwo.Orderer{o1, o2, o3} {
+				network.SetOrdererClusterListenAddress(o, "0.0.0.0")
+			}
+
+			By("pointing orderer1 at a copy of its cluster key pair")
+			clusterTLSDir := filepath.Join(testDir, "orderer1-cluster-tls")
+			Expect(os.MkdirAll(clusterTLSDir, 0755)).To(Succeed())
+			for _, name := range []string{"server.crt", "server.key"} {
+				pem, err := ioutil.ReadFile(filepath.Join(network.OrdererLocalTLSDir(o1), name))
+				Expect(err).NotTo(HaveOccurred())
+				Expect(ioutil.WriteFile(filepath.Join(clusterTLSDir, name), pem, 0600)).To(Succeed())
+			}
+			certPath, keyPath := filepath.Join(clusterTLSDir, "server.crt"), filepath.Join(clusterTLSDir, "server.key")
+			network.SetOrdererClusterCertificates(o1, certPath, keyPath, certPath, keyPath)
+
+			var runners []*ginkgomon.Runner
+			members := grouper.Members{}
+			for _, o := range []*nwo.Orderer{o1, o2, o3} {
+				runner := network.OrdererRunner(o)
+				runners = append(runners, runner)
+				members = append(members, grouper.Member{Name: o.ID(), Runner: runner})
+			}
+			ordererProc = ifrit.Invoke(grouper.NewParallel(syscall.SIGTERM, members))
+			Eventually(ordererProc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			for i, o := range []*nwo.Orderer{o1, o2, o3} {
+				Eventually(runners[i].Err(), network.EventuallyTimeout).Should(gbytes.Say(fmt.Sprintf(`Starting cluster listener on .*:%d`, network.OrdererPort(o, nwo.ClusterPort))))
+			}
+
+			By("electing a leader over the cluster listeners")
+			findLeader(runners)
+
+			By("broadcasting and delivering through the main listener")
+			env := CreateBroadcastEnvelope(network, o1, network.SystemChannel.Name, []byte("foo"))
+			resp, err := ordererclient.Broadcast(network, o1, env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(common.Status_SUCCESS))
+
+			for _, o := range []*nwo.Orderer{o1, o2, o3} {
+				block := FetchBlock(network, o, 1, network.SystemChannel.Name)
+				Expect(block).NotTo(BeNil())
+			}
+		})
+	})
+
+	When("etcdraft options are configured in the genesis block", func() {
+		It("takes snapshots at the configured interval from the first block", func() {
+			network = nwo.New(nwo.BasicEtcdRaft(), testDir, client, StartPort(), components)
+			network.Consensus.EtcdRaftOptions = &nwo.EtcdRaftOptions{
+				TickInterval:         "250ms",
+				ElectionTick:         20,
+				HeartbeatTick:        2,
+				MaxInflightBlocks:    10,
+				SnapshotIntervalSize: "4 KB",
+			}
+			o := network.Orderer("orderer")
+
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			ordererRunner := network.OrdererRunner(o)
+			ordererProc = ifrit.Invoke(ordererRunner)
+			Eventually(ordererProc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			findLeader([]*ginkgomon.Runner{ordererRunner})
+
+			By("verifying the options in the genesis block")
+			genesisBlock := FetchBlock(network, o, 0, network.SystemChannel.Name)
+			configEnv, err := cluster.ConfigFromBlock(genesisBlock)
+			Expect(err).NotTo(HaveOccurred())
+			consensusType := &protosorderer.ConsensusType{}
+			err = proto.Unmarshal(configEnv.Config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"].Value, consensusType)
+			Expect(err).NotTo(HaveOccurred())
+			metadata := &etcdraft.ConfigMetadata{}
+			err = proto.Unmarshal(consensusType.Metadata, metadata)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(proto.Equal(metadata.Options, &etcdraft.Options{
+				TickInterval:         "250ms",
+				ElectionTick:         20,
+				HeartbeatTick:        2,
+				MaxInflightBlocks:    10,
+				SnapshotIntervalSize: 4 * 1024,
+			})).To(BeTrue(), "unexpected options: %v", metadata.Options)
+
+			By("submitting a block smaller than the snapshot interval")
+			snapshotCount := func() int {
+				return nwo.OrdererRaftState(network, o, network.SystemChannel.Name).SnapshotCount
+			}
+			env := CreateBroadcastEnvelope(network, o, network.SystemChannel.Name, make([]byte, 1024))
+			resp, err := ordererclient.Broadcast(network, o, env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(common.Status_SUCCESS))
+			Expect(FetchBlock(network, o, 1, network.SystemChannel.Name)).NotTo(BeNil())
+			Consistently(snapshotCount, 3*time.Second).Should(Equal(0))
+
+			By("submitting blocks until the snapshot interval is exceeded")
+			for i := uint64(2); i <= 5; i++ {
+				resp, err := ordererclient.Broadcast(network, o, env)
+				Expect(err).NotTo(HaveOccurred())
+				Expect(resp.Status).To(Equal(common.Status_SUCCESS))
+				Expect(FetchBlock(network, o, i, network.SystemChannel.Name)).NotTo(BeNil())
+			}
+			Eventually(snapshotCount, network.EventuallyTimeout).Should(BeNumerically(">=", 1))
+		})
+	})
+
 	When("an orderer is behind the latest snapshot on leader", func() {
 		It("catches up using the block stored in snapshot", func() {
 			// Steps:
@@ -178,7 +296,6 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 			network.CreateChannel(channelID, o2, peer)
 
 			By("Submitting several transactions to trigger snapshot")
-			o2SnapDir := path.Join(network.RootDir, "orderers", o2.ID(), "etcdraft", "snapshot")
 
 			env := CreateBroadcastEnvelope(network, o2, channelID, make([]byte, 2000))
 			for i := 1; i <= 4; i++ { // 4 < MaxSnapshotFiles(5), so that no snapshot is pruned
@@ -192,9 +309,7 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 				// guaranteed that every block triggers a snapshot file being created,
 				// due to the mechanism to prevent excessive snapshotting.
 				Eventually(func() int {
-					files, err := ioutil.ReadDir(path.Join(o2SnapDir, channelID))
-					Expect(err).NotTo(HaveOccurred())
-					return len(files)
+					return nwo.OrdererRaftState(network, o2, channelID).SnapshotCount
 				}, network.EventuallyTimeout).Should(Equal(i)) // snapshot interval is 1 KB, every block triggers snapshot
 			}
 
@@ -211,21 +326,18 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 			ordererProc = ifrit.Invoke(ordererGroup)
 			Eventually(ordererProc.Ready(), network.EventuallyTimeout).Should(BeClosed())
 
-			o1SnapDir := path.Join(network.RootDir, "orderers", o1.ID(), "etcdraft", "snapshot")
-
 			By("Asserting that orderer1 has snapshot dir for both system and application channel")
 			Eventually(func() int {
-				files, err := ioutil.ReadDir(o1SnapDir)
+				files, err := ioutil.ReadDir(network.OrdererRaftSnapshotDir(o1))
 				Expect(err).NotTo(HaveOccurred())
 				return len(files)
 			}, network.EventuallyTimeout).Should(Equal(2))
 
 			By("Asserting that orderer1 receives and persists snapshot")
 			Eventually(func() int {
-				files, err := ioutil.ReadDir(path.Join(o1SnapDir, channelID))
-				Expect(err).NotTo(HaveOccurred())
-				return len(files)
+				return nwo.OrdererRaftState(network, o1, channelID).SnapshotCount
 			}, network.EventuallyTimeout).Should(Equal(1))
+			Expect(nwo.OrdererRaftState(network, o1, channelID).LatestSnapshotIndex).To(Equal(nwo.OrdererRaftState(network, o2, channelID).LatestSnapshotIndex))
 
 			By("Asserting cluster is still functional")
 			env = CreateBroadcastEnvelope(network, o1, channelID, make([]byte, 1000))
@@ -280,6 +392,108 @@ var _ = Describe("EndToEnd Crash Fault Tolerance", func() {
 			leader = findLeader(remainingAliveRunners)
 			By(fmt.Sprintf("Orderer %d took over as a leader", leader))
 		})
+
+		It("reports the new leader through the is_leader metric", func() {
+			network = nwo.New(nwo.MultiNodeEtcdRaft(), testDir, client, StartPort(), components)
+			network.MetricsProvider = "prometheus"
+
+			o1, o2, o3 := network.Orderer("orderer1"), network.Orderer("orderer2"), network.Orderer("orderer3")
+
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			By("Running the orderer nodes")
+			o1Proc = ifrit.Invoke(network.OrdererRunner(o1))
+			o2Proc = ifrit.Invoke(network.OrdererRunner(o2))
+			o3Proc = ifrit.Invoke(network.OrdererRunner(o3))
+
+			Eventually(o1Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			Eventually(o2Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			Eventually(o3Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("Waiting for them to elect a leader")
+			ordererProcesses := map[*nwo.Orderer]ifrit.Process{o1: o1Proc, o2: o2Proc, o3: o3Proc}
+			leader := nwo.WaitForLeader(network, network.SystemChannel.Name, o1, o2, o3)
+
+			By(fmt.Sprintf("Killing the leader (%s)", leader.ID()))
+			ordererProcesses[leader].Signal(syscall.SIGTERM)
+			Eventually(ordererProcesses[leader].Wait(), network.EventuallyTimeout).Should(Receive())
+
+			var remaining []*nwo.Orderer
+			for _, o := range []*nwo.Orderer{o1, o2, o3} {
+				if o != leader {
+					remaining = append(remaining, o)
+				}
+			}
+
+			By("Waiting for a new leader to be elected")
+			newLeader := nwo.WaitForLeader(network, network.SystemChannel.Name, remaining...)
+			Expect(newLeader).NotTo(Equal(leader))
+			By(fmt.Sprintf("Orderer %s took over as a leader", newLeader.ID()))
+		})
+	})
+
+	When("the leader cannot send to its followers", func() {
+		It("is replaced by a new leader and catches up once traffic is restored", func() {
+			network = nwo.New(nwo.MultiNodeEtcdRaft(), testDir, client, StartPort(), components)
+
+			o1, o2, o3 := network.Orderer("orderer1"), network.Orderer("orderer2"), network.Orderer("orderer3")
+			orderers := []*nwo.Orderer{o1, o2, o3}
+			network.GenerateConfigTree()
+			network.Bootstrap()
+			network.EnableOrdererTrafficProxy()
+
+			By("Running the orderer nodes")
+			o1Runner := network.OrdererRunner(o1)
+			o2Runner := network.OrdererRunner(o2)
+			o3Runner := network.OrdererRunner(o3)
+			oRunners := []*ginkgomon.Runner{o1Runner, o2Runner, o3Runner}
+
+			o1Proc = ifrit.Invoke(o1Runner)
+			o2Proc = ifrit.Invoke(o2Runner)
+			o3Proc = ifrit.Invoke(o3Runner)
+
+			Eventually(o1Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			Eventually(o2Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			Eventually(o3Proc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+
+			By("Waiting for them to elect a leader")
+			leaderIndex := findLeader(oRunners) - 1
+			leader := orderers[leaderIndex]
+
+			var followers []*nwo.Orderer
+			var followerRunners []*ginkgomon.Runner
+			for i, o := range orderers {
+				if i != leaderIndex {
+					followers = append(followers, o)
+					followerRunners = append(followerRunners, oRunners[i])
+				}
+			}
+
+			By("Blocking traffic from the leader to its followers")
+			for _, f := range followers {
+				nwo.BlockOrdererTraffic(network, leader, f)
+			}
+
+			By("Waiting for the followers to elect a new leader")
+			newLeaderIndex := findLeader(followerRunners) - 1
+			Expect(newLeaderIndex).NotTo(Equal(leaderIndex))
+
+			By("Submitting a transaction to the new leader")
+			env := CreateBroadcastEnvelope(network, orderers[newLeaderIndex], network.SystemChannel.Name, []byte("foo"))
+			resp, err := ordererclient.Broadcast(network, orderers[newLeaderIndex], env)
+			Expect(err).NotTo(HaveOccurred())
+			Expect(resp.Status).To(Equal(common.Status_SUCCESS))
+			for _, f := range followers {
+				Expect(FetchBlock(network, f, 1, network.SystemChannel.Name)).NotTo(BeNil())
+			}
+
+			By("Restoring traffic from the old leader and waiting for it to catch up")
+			for _, f := range followers {
+				nwo.UnblockOrdererTraffic(network, leader, f)
+			}
+			Expect(FetchBlock(network, leader, 1, network.SystemChannel.Name)).NotTo(BeNil())
+		})
 	})
 
 	When("Leader cannot reach quorum", func() {
@@ -11,6 +11,7 @@ import (
 	"encoding/pem"
 	"fmt"
 	"io/ioutil"
+	"net/http"
 	"os"
 	"path/filepath"
 	"syscall"
@@ -70,7 +71,7 @@ var _ = Describe("ChannelParticipation", func() {
 			ordererRunner := network.OrdererRunner(o)
 			ordererProcess := ifrit.Invoke(ordererRunner)
 			Eventually(ordererProcess.Ready(), network.EventuallyTimeout).Should(BeClosed())
-			Eventually(ordererRunner.Err(), network.EventuallyTimeout).Should(gbytes.Say("Registrar initializing without a system channel, number of application channels: 0"))
+			nwo.LogMatcher(ordererRunner).Says(network.EventuallyTimeout, "Registrar initializing without a system channel, number of application channels: 0")
 			ordererProcesses = append(ordererProcesses, ordererProcess)
 			ordererRunners = append(ordererRunners, ordererRunner)
 		}
@@ -163,6 +164,51 @@ var _ = Describe("ChannelParticipation", func() {
 			channelparticipation.List(network, orderer1, []string{"participation-trophy", "another-participation-trophy"})
 		})
 	})
+
+	Describe("three node etcdraft network with a system channel", func() {
+		BeforeEach(func() {
+			network = nwo.New(nwo.MultiNodeEtcdRaft(), testDir, client, StartPort(), components)
+			network.Consensus.ChannelParticipationEnabled = true
+			network.GenerateConfigTree()
+			network.Bootstrap()
+		})
+
+		It("lists the system and application channels but rejects joins and removals", func() {
+			orderer1 := network.Orderer("orderer1")
+			orderers := []*nwo.Orderer{orderer1, network.Orderer("orderer2"), network.Orderer("orderer3")}
+			peer := network.Peer("Org1", "peer0")
+			for _, o := range orderers {
+				ordererRunner := network.OrdererRunner(o)
+				ordererProcess := ifrit.Invoke(ordererRunner)
+				Eventually(ordererProcess.Ready(), network.EventuallyTimeout).Should(BeClosed())
+				ordererProcesses = append(ordererProcesses, ordererProcess)
+				ordererRunners = append(ordererRunners, ordererRunner)
+			}
+			findLeader(ordererRunners)
+
+			By("creating an application channel through the system channel")
+			network.CreateChannel("testchannel", orderer1, peer)
+			assertBlockReception(map[string]int{"testchannel": 0}, orderers, peer, network)
+
+			By("listing the system channel and the application channel")
+			for _, o := range orderers {
+				channelparticipation.List(network, o, []string{"testchannel"}, network.SystemChannel.Name)
+			}
+
+			By("attempting to join an application channel while the system channel exists")
+			configBlock := nwo.GetConfigBlock(network, peer, orderer1, "testchannel")
+			channelparticipation.JoinFailure(network, orderer1, "testchannel", configBlock, http.StatusMethodNotAllowed, "cannot join: system channel exists")
+
+			By("attempting to remove the application channel while the system channel exists")
+			channelparticipation.RemoveFailure(network, orderer1, "testchannel", http.StatusMethodNotAllowed, "cannot remove: system channel exists")
+
+			By("attempting to remove the system channel")
+			channelparticipation.RemoveFailure(network, orderer1, network.SystemChannel.Name, http.StatusBadRequest, "cannot remove: Not implemented yet")
+
+			By("listing both channels after the rejected requests")
+			channelparticipation.List(network, orderer1, []string{"testchannel"}, network.SystemChannel.Name)
+		})
+	})
 })
 
 func applicationChannelGenesisBlock(n *nwo.Network, orderers []*nwo.Orderer, p *nwo.Peer, channel string) *common.Block {
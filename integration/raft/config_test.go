@@ -282,7 +282,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Adding the second orderer")
-			addConsenter(network, peer, orderer, "systemchannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, orderer, "systemchannel", etcdraft.Consenter{
 				ServerTlsCert: secondOrdererCertificate,
 				ClientTlsCert: secondOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -379,7 +379,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			})
 
 			By("Adding orderer3 to the channel")
-			addConsenter(network, peer, orderer, "systemchannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, orderer, "systemchannel", etcdraft.Consenter{
 				ServerTlsCert: thirdOrdererCertificate,
 				ClientTlsCert: thirdOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -480,7 +480,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			certificateRotations := refreshOrdererPEMs(network)
 
 			swap := func(o *nwo.Orderer, certificate []byte, c etcdraft.Consenter) {
-				updateEtcdRaftMetadata(network, peer, o, network.SystemChannel.Name, func(metadata *etcdraft.ConfigMetadata) {
+				nwo.UpdateEtcdRaftMetadata(network, peer, o, network.SystemChannel.Name, func(metadata *etcdraft.ConfigMetadata) {
 					var newConsenters []*etcdraft.Consenter
 					for _, consenter := range metadata.Consenters {
 						if bytes.Equal(consenter.ClientTlsCert, certificate) || bytes.Equal(consenter.ServerTlsCert, certificate) {
@@ -663,7 +663,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 
 				By(fmt.Sprintf("Adding the future certificate of orderer node %d", i))
 				for _, channelName := range []string{"systemchannel", "testchannel"} {
-					addConsenter(network, peer, o, channelName, etcdraft.Consenter{
+					nwo.AddConsenter(network, peer, o, channelName, etcdraft.Consenter{
 						ServerTlsCert: rotation.newCert,
 						ClientTlsCert: rotation.newCert,
 						Host:          "127.0.0.1",
@@ -689,7 +689,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 
 				By("Removing the previous certificate of the old orderer")
 				for _, channelName := range []string{"systemchannel", "testchannel"} {
-					removeConsenter(network, peer, network.Orderers[(i+1)%len(network.Orderers)], channelName, rotation.oldCert)
+					nwo.RemoveConsenter(network, peer, network.Orderers[(i+1)%len(network.Orderers)], channelName, rotation.oldCert)
 				}
 
 				By("Waiting for all orderers to sync")
@@ -728,7 +728,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			orderer4Certificate, err := ioutil.ReadFile(orderer4CertificatePath)
 			Expect(err).NotTo(HaveOccurred())
 			for _, channel := range []string{"systemchannel", "testchannel"} {
-				addConsenter(network, peer, o1, channel, etcdraft.Consenter{
+				nwo.AddConsenter(network, peer, o1, channel, etcdraft.Consenter{
 					ServerTlsCert: orderer4Certificate,
 					ClientTlsCert: orderer4Certificate,
 					Host:          "127.0.0.1",
@@ -794,7 +794,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			Expect(orderer4Runner.Err()).To(gbytes.Say(belongRegex + "|" + forbiddenRegex))
 
 			By("Adding orderer4 to testchannel2")
-			addConsenter(network, peer, o1, "testchannel2", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, o1, "testchannel2", etcdraft.Consenter{
 				ServerTlsCert: orderer4Certificate,
 				ClientTlsCert: orderer4Certificate,
 				Host:          "127.0.0.1",
@@ -878,7 +878,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			ordererCertificate, err := ioutil.ReadFile(ordererCertificatePath)
 			Expect(err).NotTo(HaveOccurred())
 
-			addConsenter(network, peer, o1, "mychannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, o1, "mychannel", etcdraft.Consenter{
 				ServerTlsCert: ordererCertificate,
 				ClientTlsCert: ordererCertificate,
 				Host:          "127.0.0.1",
@@ -894,7 +894,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			ordererCertificatePath = filepath.Join(network.OrdererLocalTLSDir(o3), "server.crt")
 			ordererCertificate, err = ioutil.ReadFile(ordererCertificatePath)
 			Expect(err).NotTo(HaveOccurred())
-			addConsenter(network, peer, o1, "mychannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, o1, "mychannel", etcdraft.Consenter{
 				ServerTlsCert: ordererCertificate,
 				ClientTlsCert: ordererCertificate,
 				Host:          "127.0.0.1",
@@ -1019,7 +1019,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			server1CertBytes, err := ioutil.ReadFile(filepath.Join(network.OrdererLocalTLSDir(orderers[firstEvictedNode]), "server.crt"))
 			Expect(err).To(Not(HaveOccurred()))
 
-			removeConsenter(network, peer, network.Orderers[(firstEvictedNode+1)%3], "systemchannel", server1CertBytes)
+			nwo.RemoveConsenter(network, peer, network.Orderers[(firstEvictedNode+1)%3], "systemchannel", server1CertBytes)
 
 			var survivedOrdererRunners []*ginkgomon.Runner
 			for i := range orderers {
@@ -1051,7 +1051,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			server2CertBytes, err := ioutil.ReadFile(filepath.Join(network.OrdererLocalTLSDir(orderers[secondEvictedNode]), "server.crt"))
 			Expect(err).To(Not(HaveOccurred()))
 
-			removeConsenter(network, peer, orderers[survivor], "systemchannel", server2CertBytes)
+			nwo.RemoveConsenter(network, peer, orderers[survivor], "systemchannel", server2CertBytes)
 			findLeader([]*ginkgomon.Runner{ordererRunners[survivor]})
 
 			fmt.Fprintln(GinkgoWriter, "Ensuring the other orderer detect the eviction of the node on channel systemchannel")
@@ -1061,7 +1061,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			ensureEvicted(orderers[secondEvictedNode], peer, network, "systemchannel")
 
 			By("Re-adding first evicted orderer")
-			addConsenter(network, peer, network.Orderers[survivor], "systemchannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, network.Orderers[survivor], "systemchannel", etcdraft.Consenter{
 				Host:          "127.0.0.1",
 				Port:          uint32(network.OrdererPort(orderers[firstEvictedNode], nwo.ClusterPort)),
 				ClientTlsCert: server1CertBytes,
@@ -1114,10 +1114,10 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 				By("Removing the first orderer from the application channel")
 				server1CertBytes, err := ioutil.ReadFile(filepath.Join(network.OrdererLocalTLSDir(o1), "server.crt"))
 				Expect(err).To(Not(HaveOccurred()))
-				removeConsenter(network, peer, o2, "testchannel", server1CertBytes)
+				nwo.RemoveConsenter(network, peer, o2, "testchannel", server1CertBytes)
 
 				By("Adding the evicted orderer back to the application channel")
-				addConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
+				nwo.AddConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
 					ServerTlsCert: server1CertBytes,
 					ClientTlsCert: server1CertBytes,
 					Host:          "127.0.0.1",
@@ -1125,10 +1125,10 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 				})
 
 				By("Removing the first orderer from the application channel again")
-				removeConsenter(network, peer, o2, "testchannel", server1CertBytes)
+				nwo.RemoveConsenter(network, peer, o2, "testchannel", server1CertBytes)
 
 				By("Adding the evicted orderer back to the application channel again")
-				addConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
+				nwo.AddConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
 					ServerTlsCert: server1CertBytes,
 					ClientTlsCert: server1CertBytes,
 					Host:          "127.0.0.1",
@@ -1185,7 +1185,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			By("Removing the first orderer from an application channel")
 			o1cert, err := ioutil.ReadFile(path.Join(network.OrdererLocalTLSDir(o1), "server.crt"))
 			Expect(err).ToNot(HaveOccurred())
-			removeConsenter(network, peer, o2, "testchannel", o1cert)
+			nwo.RemoveConsenter(network, peer, o2, "testchannel", o1cert)
 
 			By("Starting the orderer again")
 			ordererRunner := network.OrdererRunner(orderers[0])
@@ -1222,7 +1222,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 			Eventually(ordererRunner.Err(), time.Minute, time.Second).Should(gbytes.Say(iDoNotBelong))
 
 			By("Adding the evicted orderer back to the application channel")
-			addConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, o2, "testchannel", etcdraft.Consenter{
 				ServerTlsCert: o1cert,
 				ClientTlsCert: o1cert,
 				Host:          "127.0.0.1",
@@ -1234,6 +1234,78 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 		})
 	})
 
+	When("consenters are removed from and added to a channel", func() {
+		It("stops the removed orderer from participating until it is added back", func() {
+			network = nwo.New(nwo.MultiNodeEtcdRaft(), testDir, client, StartPort(), components)
+			network.MetricsProvider = "prometheus"
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			o1, o2, o3 := network.Orderer("orderer1"), network.Orderer("orderer2"), network.Orderer("orderer3")
+			orderers := []*nwo.Orderer{o1, o2, o3}
+			peer = network.Peer("Org1", "peer0")
+
+			By("Launching the orderers")
+			for _, o := range orderers {
+				runner := network.OrdererRunner(o)
+				ordererRunners = append(ordererRunners, runner)
+				process := ifrit.Invoke(runner)
+				ordererProcesses = append(ordererProcesses, process)
+				Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+			}
+
+			By("Creating an application channel")
+			network.CreateChannel("testchannel", o1, peer)
+			assertBlockReception(map[string]int{"testchannel": 0}, orderers, peer, network)
+			nwo.WaitForLeader(network, "testchannel", orderers...)
+
+			By("Reading the consenters of the channel")
+			consenterPorts := func() []uint32 {
+				var ports []uint32
+				for _, c := range nwo.ChannelConsenters(network, peer, o2, "testchannel") {
+					ports = append(ports, c.Port)
+				}
+				return ports
+			}
+			clusterPort := func(o *nwo.Orderer) uint32 { return uint32(network.OrdererPort(o, nwo.ClusterPort)) }
+			Expect(consenterPorts()).To(ConsistOf(clusterPort(o1), clusterPort(o2), clusterPort(o3)))
+
+			By("Removing the first orderer from the channel")
+			o1Consenter := network.OrdererConsenter(o1)
+			nwo.RemoveConsenter(network, peer, o2, "testchannel", o1Consenter.ServerTlsCert)
+			Expect(consenterPorts()).To(ConsistOf(clusterPort(o2), clusterPort(o3)))
+			nwo.LogMatcher(ordererRunners[0]).Says(time.Minute, `Detected our own eviction from the channel in block \[1\] channel=testchannel`)
+			nwo.WaitForLeader(network, "testchannel", o2, o3)
+
+			By("Restarting the removed orderer")
+			ordererProcesses[0].Signal(syscall.SIGTERM)
+			Eventually(ordererProcesses[0].Wait(), network.EventuallyTimeout).Should(Receive())
+			ordererRunners[0] = network.OrdererRunner(o1)
+			ordererProcesses[0] = ifrit.Invoke(ordererRunners[0])
+			Eventually(ordererProcesses[0].Ready(), network.EventuallyTimeout).Should(BeClosed())
+			nwo.LogMatcher(ordererRunners[0]).Says(time.Minute, "Found 1 inactive chains")
+
+			By("Adding the first orderer back to the channel")
+			nwo.AddConsenter(network, peer, o2, "testchannel", o1Consenter)
+			Expect(consenterPorts()).To(ConsistOf(clusterPort(o1), clusterPort(o2), clusterPort(o3)))
+			assertBlockReception(map[string]int{"testchannel": 2}, orderers, peer, network)
+
+			By("Stopping the leader so that the re-added orderer's vote is needed to elect a new one")
+			leader := nwo.WaitForLeader(network, "testchannel", orderers...)
+			var remaining []*nwo.Orderer
+			for i, o := range orderers {
+				if o == leader {
+					ordererProcesses[i].Signal(syscall.SIGTERM)
+					Eventually(ordererProcesses[i].Wait(), network.EventuallyTimeout).Should(Receive())
+					ordererProcesses = append(ordererProcesses[:i], ordererProcesses[i+1:]...)
+					continue
+				}
+				remaining = append(remaining, o)
+			}
+			Expect(nwo.WaitForLeader(network, "testchannel", remaining...)).NotTo(Equal(leader))
+		})
+	})
+
 	When("an orderer node is joined", func() {
 		It("isn't influenced by outdated orderers", func() {
 			// This test checks that if a lagged is not aware of newly added nodes,
@@ -1323,7 +1395,7 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 				ordererCertificate, err := ioutil.ReadFile(ordererCertificatePath)
 				Expect(err).NotTo(HaveOccurred())
 
-				addConsenter(network, peer, orderers[0], "systemchannel", etcdraft.Consenter{
+				nwo.AddConsenter(network, peer, orderers[0], "systemchannel", etcdraft.Consenter{
 					ServerTlsCert: ordererCertificate,
 					ClientTlsCert: ordererCertificate,
 					Host:          "127.0.0.1",
@@ -1505,6 +1577,50 @@ var _ = Describe("EndToEnd reconfiguration and onboarding", func() {
 
 		nwo.UpdateOrdererConfig(network, o1, channel, config, updatedConfig, peer, o1)
 	})
+
+	It("requires a majority of orderer organizations to update the orderer config", func() {
+		network = nwo.New(nwo.MultiOrgEtcdRaft(), testDir, client, StartPort(), components)
+		o1, o2, o3 := network.Orderer("orderer1"), network.Orderer("orderer2"), network.Orderer("orderer3")
+		orderers := []*nwo.Orderer{o1, o2, o3}
+		peer := network.Peer("Org1", "peer0")
+
+		network.GenerateConfigTree()
+		network.Bootstrap()
+
+		By("Launching the orderers")
+		for _, o := range orderers {
+			runner := network.OrdererRunner(o)
+			ordererRunners = append(ordererRunners, runner)
+			process := ifrit.Invoke(runner)
+			ordererProcesses = append(ordererProcesses, process)
+		}
+
+		for _, ordererProc := range ordererProcesses {
+			Eventually(ordererProc.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		}
+
+		By("Waiting for system channel to be ready")
+		findLeader(ordererRunners)
+
+		channel := "systemchannel"
+		config := nwo.GetConfig(network, peer, o1, channel)
+		updatedConfig := proto.Clone(config).(*common.Config)
+
+		batchTimeoutConfigValue := updatedConfig.ChannelGroup.Groups["Orderer"].Values["BatchTimeout"]
+		batchTimeoutValue := &protosorderer.BatchTimeout{}
+		err := proto.Unmarshal(batchTimeoutConfigValue.Value, batchTimeoutValue)
+		Expect(err).NotTo(HaveOccurred())
+		batchTimeoutValue.Timeout = "2s"
+		batchTimeoutConfigValue.Value = protoutil.MarshalOrPanic(batchTimeoutValue)
+
+		By("Submitting a config update signed only by the first orderer organization")
+		sess := nwo.UpdateOrdererConfigSession(network, o1, channel, config, updatedConfig, peer)
+		Eventually(sess, network.EventuallyTimeout).Should(gexec.Exit(1))
+		Expect(sess.Err).To(gbytes.Say(`implicit policy evaluation failed - 1 sub-policies were satisfied, but this policy requires 2 of the 'Admins' sub-policies to be satisfied`))
+
+		By("Submitting the config update signed by a majority of orderer organizations")
+		nwo.UpdateOrdererConfig(network, o1, channel, config, updatedConfig, peer, o2)
+	})
 })
 
 func ensureEvicted(evictedOrderer *nwo.Orderer, submitter *nwo.Peer, network *nwo.Network, channel string) {
@@ -1764,42 +1880,3 @@ func consenterRemover(n *nwo.Network, peer *nwo.Peer, orderer *nwo.Orderer, chan
 
 	return config, updatedConfig
 }
-
-// addConsenter adds a new consenter to the given channel.
-func addConsenter(n *nwo.Network, peer *nwo.Peer, orderer *nwo.Orderer, channel string, consenter etcdraft.Consenter) {
-	updateEtcdRaftMetadata(n, peer, orderer, channel, func(metadata *etcdraft.ConfigMetadata) {
-		metadata.Consenters = append(metadata.Consenters, &consenter)
-	})
-}
-
-// removeConsenter removes a consenter with the given certificate in PEM format
-// from the given channel.
-func removeConsenter(n *nwo.Network, peer *nwo.Peer, orderer *nwo.Orderer, channel string, certificate []byte) {
-	updateEtcdRaftMetadata(n, peer, orderer, channel, func(metadata *etcdraft.ConfigMetadata) {
-		var newConsenters []*etcdraft.Consenter
-		for _, consenter := range metadata.Consenters {
-			if bytes.Equal(consenter.ClientTlsCert, certificate) || bytes.Equal(consenter.ServerTlsCert, certificate) {
-				continue
-			}
-			newConsenters = append(newConsenters, consenter)
-		}
-
-		metadata.Consenters = newConsenters
-	})
-}
-
-// updateEtcdRaftMetadata executes a config update that updates the etcdraft
-// metadata according to the given function f.
-func updateEtcdRaftMetadata(network *nwo.Network, peer *nwo.Peer, orderer *nwo.Orderer, channel string, f func(md *etcdraft.ConfigMetadata)) {
-	nwo.UpdateConsensusMetadata(network, peer, orderer, channel, func(originalMetadata []byte) []byte {
-		metadata := &etcdraft.ConfigMetadata{}
-		err := proto.Unmarshal(originalMetadata, metadata)
-		Expect(err).NotTo(HaveOccurred())
-
-		f(metadata)
-
-		newMetadata, err := proto.Marshal(metadata)
-		Expect(err).NotTo(HaveOccurred())
-		return newMetadata
-	})
-}
@@ -137,7 +137,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			sysStartBlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, syschannel)
 			Expect(sysStartBlockNum).ToNot(Equal(0))
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue := extractOrdererConsensusType(config)
+			consensusTypeValue := nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			By("1) Verify: new channels cannot be created")
@@ -155,7 +155,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			std1EntryBlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, channel1)
 			Expect(std1EntryBlockNum).ToNot(Equal(0))
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			By("2) Verify: Normal TX's on standard channel are blocked")
@@ -216,7 +216,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			sysStartBlockNum = nwo.CurrentConfigBlockNumber(network, peer, orderer, syschannel)
 			Expect(sysStartBlockNum).ToNot(Equal(0))
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 6: Config update on standard channel1, MAINTENANCE, again ===
@@ -231,7 +231,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(std1EntryBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			By("6) Verify: delivery request from peer is blocked")
@@ -253,7 +253,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(std2EntryBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, orderer, channel2)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			By("7) Verify: delivery request from peer is blocked")
@@ -288,6 +288,8 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			By("9) Verify: standard channel config changed")
 			std1BlockNum = nwo.CurrentConfigBlockNumber(network, peer, orderer, channel1)
 			Expect(std1BlockNum).To(Equal(std1EntryBlockNum + 1))
+			Expect(nwo.ConsensusType(network, peer, orderer, channel1)).To(Equal("etcdraft"))
+			Expect(nwo.ConsensusState(network, peer, orderer, channel1)).To(Equal(protosorderer.ConsensusType_STATE_MAINTENANCE))
 
 			By("9) Verify: delivery request from peer is blocked")
 			err = checkPeerDeliverRequest(orderer, peer, network, channel1)
@@ -360,7 +362,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			sysStartBlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, syschannel)
 			Expect(sysStartBlockNum).ToNot(Equal(0))
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue := extractOrdererConsensusType(config)
+			consensusTypeValue := nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 6: ===
@@ -374,7 +376,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			std1StartBlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, channel1)
 			Expect(std1StartBlockNum).ToNot(Equal(0))
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 7: ===
@@ -413,7 +415,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			sysBlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, syschannel)
 			Expect(sysBlockNum).To(Equal(sysStartBlockNum + 1))
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "etcdraft", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 12: ===
@@ -428,13 +430,13 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			std1BlockNum := nwo.CurrentConfigBlockNumber(network, peer, orderer, channel1)
 			Expect(std1BlockNum).To(Equal(std1StartBlockNum + 1))
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "etcdraft", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 13: ===
 			By("13) Config update on system channel, changing value other than ConsensusType.Type is permitted")
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "etcdraft", protosorderer.ConsensusType_STATE_MAINTENANCE)
 			updatedConfig = proto.Clone(config).(*common.Config)
 			updateConfigWithBatchTimeout(updatedConfig)
@@ -447,7 +449,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			//=== Step 14: ===
 			By("14) Config update on standard channel, changing value other than ConsensusType.Type is permitted")
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "etcdraft", protosorderer.ConsensusType_STATE_MAINTENANCE)
 			updatedConfig = proto.Clone(config).(*common.Config)
 			updateConfigWithBatchTimeout(updatedConfig)
@@ -536,7 +538,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(sysStartBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, o1, syschannel)
-			consensusTypeValue := extractOrdererConsensusType(config)
+			consensusTypeValue := nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 2: Config update on standard channel, MAINTENANCE ===
@@ -551,7 +553,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(chan1StartBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, o1, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "kafka", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 3: config update on system channel, State=MAINTENANCE, type=etcdraft ===
@@ -676,7 +678,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("Adding the fourth orderer to the system channel")
-			addConsenter(network, peer, o1, "systemchannel", protosraft.Consenter{
+			nwo.AddConsenter(network, peer, o1, "systemchannel", protosraft.Consenter{
 				ServerTlsCert: fourthOrdererCertificate,
 				ClientTlsCert: fourthOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -705,7 +707,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Eventually(o4Runner.Err(), time.Minute, time.Second).Should(gbytes.Say("This node was migrated from Kafka to Raft, skipping activation of Kafka chain"))
 
 			By("Adding the fourth orderer to the application channel")
-			addConsenter(network, peer, o1, channel1, protosraft.Consenter{
+			nwo.AddConsenter(network, peer, o1, channel1, protosraft.Consenter{
 				ServerTlsCert: fourthOrdererCertificate,
 				ClientTlsCert: fourthOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -776,7 +778,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(sysStartBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, orderer, syschannel)
-			consensusTypeValue := extractOrdererConsensusType(config)
+			consensusTypeValue := nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "solo", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 2: Config update on standard channel, MAINTENANCE ===
@@ -791,7 +793,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(chan1StartBlockNum).ToNot(Equal(0))
 
 			config = nwo.GetConfig(network, peer, orderer, channel1)
-			consensusTypeValue = extractOrdererConsensusType(config)
+			consensusTypeValue = nwo.ConsensusTypeFromConfig(config)
 			validateConsensusTypeValue(consensusTypeValue, "solo", protosorderer.ConsensusType_STATE_MAINTENANCE)
 
 			//=== Step 3: config update on system channel, State=MAINTENANCE, type=etcdraft ===
@@ -903,7 +905,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Expect(err).NotTo(HaveOccurred())
 
 			By("14) Adding the second orderer to system channel")
-			addConsenter(network, peer, orderer, syschannel, etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, orderer, syschannel, etcdraft.Consenter{
 				ServerTlsCert: secondOrdererCertificate,
 				ClientTlsCert: secondOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -926,7 +928,7 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			Eventually(o2Runner.Err(), network.EventuallyTimeout, time.Second).Should(gbytes.Say("Raft leader changed: 0 -> "))
 
 			By("18) Adding orderer2 to channel2")
-			addConsenter(network, peer, orderer, channel2, etcdraft.Consenter{
+			nwo.AddConsenter(network, peer, orderer, channel2, etcdraft.Consenter{
 				ServerTlsCert: secondOrdererCertificate,
 				ClientTlsCert: secondOrdererCertificate,
 				Host:          "127.0.0.1",
@@ -942,6 +944,87 @@ var _ = Describe("Kafka2RaftMigration", func() {
 			assertBlockCreation(network, orderer2, nil, channel2, 3)
 		})
 	})
+
+	// This test migrates a running kafka network to etcdraft with the nwo
+	// migration helpers and verifies that chaincode transactions commit under
+	// the new consensus.
+	Describe("Kafka to Raft migration of a running network", func() {
+		BeforeEach(func() {
+			network = nwo.New(nwo.BasicKafka(), testDir, client, StartPort(), components)
+			network.GenerateConfigTree()
+			network.Bootstrap()
+
+			process = ifrit.Invoke(network.NetworkGroupRunner())
+			Eventually(process.Ready(), network.EventuallyTimeout).Should(BeClosed())
+		})
+
+		It("migrates all channels and commits transactions with etcdraft", func() {
+			orderer := network.Orderer("orderer")
+			peer := network.Peer("Org1", "peer0")
+			syschannel := network.SystemChannel.Name
+
+			network.CreateAndJoinChannel(orderer, "testchannel")
+			Expect(nwo.ConsensusType(network, peer, orderer, syschannel)).To(Equal("kafka"))
+			Expect(nwo.ConsensusType(network, peer, orderer, "testchannel")).To(Equal("kafka"))
+
+			By("migrating the system channel and the application channel to etcdraft")
+			process = nwo.MigrateToRaft(network, process, peer, orderer, nwo.RaftMetadata(network, orderer), syschannel, "testchannel")
+
+			for _, channel := range []string{syschannel, "testchannel"} {
+				Expect(nwo.ConsensusType(network, peer, orderer, channel)).To(Equal("etcdraft"))
+				Expect(nwo.ConsensusState(network, peer, orderer, channel)).To(Equal(protosorderer.ConsensusType_STATE_NORMAL))
+			}
+
+			By("deploying and invoking chaincode under etcdraft")
+			nwo.DeployChaincode(network, "testchannel", orderer, nwo.Chaincode{
+				Name:            "mycc",
+				Version:         "0.0",
+				Path:            "github.com/hyperledger/fabric/integration/chaincode/simple/cmd",
+				Lang:            "golang",
+				PackageFile:     filepath.Join(testDir, "simplecc.tar.gz"),
+				Ctor:            `{"Args":["init","a","100","b","200"]}`,
+				SignaturePolicy: `OR ('Org1ExampleCom.member','Org2ExampleCom.member')`,
+				Sequence:        "1",
+				InitRequired:    true,
+				Label:           "my_simple_chaincode",
+			})
+			nwo.TimedInvoke(network, orderer, peer, commands.ChaincodeInvoke{
+				ChannelID:     "testchannel",
+				Name:          "mycc",
+				Ctor:          `{"Args":["invoke","a","b","10"]}`,
+				PeerAddresses: []string{network.PeerAddress(peer, nwo.ListenPort)},
+				ClientAuth:    network.ClientAuthRequired,
+			})
+		})
+
+		It("rejects normal transactions while a channel is in maintenance mode", func() {
+			orderer := network.Orderer("orderer")
+			peer := network.Peer("Org1", "peer0")
+			signer := nwo.SignerForUser(network, "Org1", "User1")
+
+			network.CreateAndJoinChannel(orderer, "testchannel")
+			normalTx := func() *common.Envelope {
+				env, err := protoutil.CreateSignedEnvelope(common.HeaderType_ENDORSER_TRANSACTION, "testchannel", signer, &common.ConfigValue{}, 0, 0)
+				Expect(err).NotTo(HaveOccurred())
+				return env
+			}
+
+			By("entering maintenance mode")
+			nwo.SetOrdererMaintenanceMode(network, peer, orderer, "testchannel", true)
+			Expect(nwo.ConsensusState(network, peer, orderer, "testchannel")).To(Equal(protosorderer.ConsensusType_STATE_MAINTENANCE))
+
+			By("submitting a normal transaction")
+			resp := nwo.BroadcastExpectingStatus(network, orderer, "testchannel", normalTx(), common.Status_SERVICE_UNAVAILABLE)
+			Expect(resp.Info).To(ContainSubstring("normal transactions are rejected: maintenance mode"))
+
+			By("leaving maintenance mode with a config transaction")
+			nwo.SetOrdererMaintenanceMode(network, peer, orderer, "testchannel", false)
+			Expect(nwo.ConsensusState(network, peer, orderer, "testchannel")).To(Equal(protosorderer.ConsensusType_STATE_NORMAL))
+
+			By("submitting a normal transaction")
+			nwo.BroadcastExpectingStatus(network, orderer, "testchannel", normalTx(), common.Status_SUCCESS)
+		})
+	})
 })
 
 func validateConsensusTypeValue(value *protosorderer.ConsensusType, cType string, state protosorderer.ConsensusType_State) {
@@ -949,14 +1032,6 @@ func validateConsensusTypeValue(value *protosorderer.ConsensusType, cType string
 	Expect(value.State).To(Equal(state))
 }
 
-func extractOrdererConsensusType(config *common.Config) *protosorderer.ConsensusType {
-	var consensusTypeValue protosorderer.ConsensusType
-	consensusTypeConfigValue := config.ChannelGroup.Groups["Orderer"].Values["ConsensusType"]
-	err := proto.Unmarshal(consensusTypeConfigValue.Value, &consensusTypeValue)
-	Expect(err).NotTo(HaveOccurred())
-	return &consensusTypeValue
-}
-
 func updateConfigWithConsensusType(
 	consensusType string,
 	consensusMetadata []byte,
@@ -1125,7 +1200,7 @@ func prepareTransition(
 ) (current, updated *common.Config) {
 	current = nwo.GetConfig(network, peer, orderer, channel)
 	updated = proto.Clone(current).(*common.Config)
-	consensusTypeValue := extractOrdererConsensusType(current)
+	consensusTypeValue := nwo.ConsensusTypeFromConfig(current)
 	validateConsensusTypeValue(consensusTypeValue, fromConsensusType, fromMigState)
 	updateConfigWithConsensusType(toConsensusType, toConsensusMetadata, toMigState, updated, consensusTypeValue)
 	return current, updated
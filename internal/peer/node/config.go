@@ -63,6 +63,9 @@ func ledgerConfig() *ledger.Config {
 		SnapshotsConfig: &ledger.SnapshotsConfig{
 			RootDir: snapshotsRootDir,
 		},
+		BlockStoreConfig: &ledger.BlockStoreConfig{
+			MaxBlockfileSize: viper.GetInt("ledger.blockchain.maxBlockfileSize"),
+		},
 	}
 
 	if conf.StateDBConfig.StateDatabase == ledger.CouchDB {
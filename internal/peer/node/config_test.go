@@ -45,6 +45,9 @@ func TestLedgerConfig(t *testing.T) {
 				SnapshotsConfig: &ledger.SnapshotsConfig{
 					RootDir: "/peerfs/ledgersData/snapshots",
 				},
+				BlockStoreConfig: &ledger.BlockStoreConfig{
+					MaxBlockfileSize: 0,
+				},
 			},
 		},
 		{
@@ -91,6 +94,9 @@ func TestLedgerConfig(t *testing.T) {
 				SnapshotsConfig: &ledger.SnapshotsConfig{
 					RootDir: "/peerfs/ledgersData/snapshots",
 				},
+				BlockStoreConfig: &ledger.BlockStoreConfig{
+					MaxBlockfileSize: 0,
+				},
 			},
 		},
 		{
@@ -114,6 +120,7 @@ func TestLedgerConfig(t *testing.T) {
 				"ledger.pvtdataStore.purgeInterval":                  1000,
 				"ledger.history.enableHistoryDatabase":               true,
 				"ledger.snapshots.rootDir":                           "/peerfs/snapshots",
+				"ledger.blockchain.maxBlockfileSize":                 67108864,
 			},
 			expected: &ledger.Config{
 				RootFSPath: "/peerfs/ledgersData",
@@ -145,6 +152,9 @@ func TestLedgerConfig(t *testing.T) {
 				SnapshotsConfig: &ledger.SnapshotsConfig{
 					RootDir: "/peerfs/snapshots",
 				},
+				BlockStoreConfig: &ledger.BlockStoreConfig{
+					MaxBlockfileSize: 67108864,
+				},
 			},
 		},
 	}
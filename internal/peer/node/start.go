@@ -557,7 +557,9 @@ func serve(args []string) error {
 				"CORE_CHAINCODE_LOGGING_SHIM=" + chaincodeConfig.ShimLogLevel,
 				"CORE_CHAINCODE_LOGGING_FORMAT=" + chaincodeConfig.LogFormat,
 			},
-			MSPID: mspID,
+			MSPID:        mspID,
+			StartTimeout: chaincodeConfig.StartupTimeout,
+			StopTimeout:  coreConfig.VMDockerStopTimeout,
 		}
 		if err := opsSystem.RegisterChecker("docker", dockerVM); err != nil {
 			logger.Panicf("failed to register docker health check: %s", err)
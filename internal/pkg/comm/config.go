@@ -64,6 +64,12 @@ type ServerConfig struct {
 	HealthCheckEnabled bool
 	// ServerStatsHandler should be set if metrics on connections are to be reported.
 	ServerStatsHandler *ServerStatsHandler
+	// MaxRecvMsgSize is the maximum message size in bytes the server can
+	// receive. When zero, MaxRecvMsgSize is used.
+	MaxRecvMsgSize int
+	// MaxSendMsgSize is the maximum message size in bytes the server can
+	// send. When zero, MaxSendMsgSize is used.
+	MaxSendMsgSize int
 }
 
 // ClientConfig defines the parameters for configuring a GRPCClient instance
@@ -125,8 +125,16 @@ func NewGRPCServerFromListener(listener net.Listener, serverConfig ServerConfig)
 		}
 	}
 	// set max send and recv msg sizes
-	serverOpts = append(serverOpts, grpc.MaxSendMsgSize(MaxSendMsgSize))
-	serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(MaxRecvMsgSize))
+	maxSendMsgSize := MaxSendMsgSize
+	if serverConfig.MaxSendMsgSize != 0 {
+		maxSendMsgSize = serverConfig.MaxSendMsgSize
+	}
+	maxRecvMsgSize := MaxRecvMsgSize
+	if serverConfig.MaxRecvMsgSize != 0 {
+		maxRecvMsgSize = serverConfig.MaxRecvMsgSize
+	}
+	serverOpts = append(serverOpts, grpc.MaxSendMsgSize(maxSendMsgSize))
+	serverOpts = append(serverOpts, grpc.MaxRecvMsgSize(maxRecvMsgSize))
 	// set the keepalive options
 	serverOpts = append(serverOpts, ServerKeepaliveOptions(serverConfig.KaOpts)...)
 	// set connection timeout
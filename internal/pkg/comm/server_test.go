@@ -21,6 +21,7 @@ import (
 	"testing"
 	"time"
 
+	"github.com/golang/protobuf/proto"
 	"github.com/hyperledger/fabric/common/crypto/tlsgen"
 	"github.com/hyperledger/fabric/internal/pkg/comm"
 	"github.com/hyperledger/fabric/internal/pkg/comm/testpb"
@@ -1264,3 +1265,64 @@ func TestServerInterceptors(t *testing.T) {
 	require.Equal(t, status.Convert(err).Message(), msg, "Expected error from second ssi")
 	require.Equal(t, uint32(2), atomic.LoadUint32(&ssiCount), "Expected both ssi handlers to be invoked")
 }
+
+func TestServerMaxMsgSize(t *testing.T) {
+	tests := []struct {
+		name        string
+		config      comm.ServerConfig
+		payloadSize int
+		expectedErr string
+	}{
+		{
+			name:        "defaults",
+			payloadSize: 1024,
+		},
+		{
+			name:        "recv limit exceeded",
+			config:      comm.ServerConfig{MaxRecvMsgSize: 100},
+			payloadSize: 1024,
+			expectedErr: "grpc: received message larger than max",
+		},
+		{
+			name:        "send limit exceeded",
+			config:      comm.ServerConfig{MaxSendMsgSize: 100},
+			payloadSize: 1024,
+			expectedErr: "grpc: trying to send message larger than max",
+		},
+		{
+			name:        "within limits",
+			config:      comm.ServerConfig{MaxRecvMsgSize: 2048, MaxSendMsgSize: 2048},
+			payloadSize: 1024,
+		},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			lis, err := net.Listen("tcp", "127.0.0.1:0")
+			require.NoError(t, err, "listen failed")
+
+			srv, err := comm.NewGRPCServerFromListener(lis, tt.config)
+			require.NoError(t, err, "failed to create gRPC server")
+			testpb.RegisterEchoServiceServer(srv.Server(), &echoServer{})
+			defer srv.Stop()
+			go srv.Start()
+
+			ctx, cancel := context.WithTimeout(context.Background(), testTimeout)
+			defer cancel()
+			conn, err := grpc.DialContext(ctx, lis.Addr().String(), grpc.WithBlock(), grpc.WithInsecure())
+			require.NoError(t, err)
+			defer conn.Close()
+
+			echo := &testpb.Echo{Payload: make([]byte, tt.payloadSize)}
+			resp, err := testpb.NewEchoServiceClient(conn).EchoCall(ctx, echo)
+			if tt.expectedErr != "" {
+				require.Error(t, err)
+				require.Equal(t, codes.ResourceExhausted, status.Code(err))
+				require.Contains(t, err.Error(), tt.expectedErr)
+				return
+			}
+			require.NoError(t, err)
+			require.True(t, proto.Equal(echo, resp))
+		})
+	}
+}
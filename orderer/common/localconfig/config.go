@@ -39,6 +39,8 @@ type General struct {
 	Cluster           Cluster
 	Keepalive         Keepalive
 	ConnectionTimeout time.Duration
+	MaxRecvMsgSize    int
+	MaxSendMsgSize    int
 	GenesisMethod     string // For compatibility only, will be replaced by BootstrapMethod
 	GenesisFile       string // For compatibility only, will be replaced by BootstrapFile
 	BootstrapMethod   string
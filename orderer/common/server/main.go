@@ -53,6 +53,7 @@ import (
 	"github.com/hyperledger/fabric/protoutil"
 	"go.uber.org/zap/zapcore"
 	"google.golang.org/grpc"
+	_ "google.golang.org/grpc/encoding/gzip" // decompress deliver requests from peers that enable compression
 	"gopkg.in/alecthomas/kingpin.v2"
 )
 
@@ -572,6 +573,8 @@ func initializeServerConfig(conf *localconfig.TopLevel, metricsProvider metrics.
 		Logger:             commLogger,
 		ServerStatsHandler: comm.NewServerStatsHandler(metricsProvider),
 		ConnectionTimeout:  conf.General.ConnectionTimeout,
+		MaxRecvMsgSize:     conf.General.MaxRecvMsgSize,
+		MaxSendMsgSize:     conf.General.MaxSendMsgSize,
 		StreamInterceptors: []grpc.StreamServerInterceptor{
 			grpcmetrics.StreamServerInterceptor(grpcmetrics.NewStreamMetrics(metricsProvider)),
 			grpclogging.StreamServerInterceptor(flogging.MustGetLogger("comm.grpc.server").Zap()),
@@ -157,6 +157,13 @@ func TestInitializeServerConfig(t *testing.T) {
 	require.Equal(t, time.Duration(0), sc.KaOpts.ServerInterval)
 	require.Equal(t, time.Duration(0), sc.KaOpts.ServerTimeout)
 	require.Equal(t, 7*time.Second, sc.ConnectionTimeout)
+	require.Equal(t, 0, sc.MaxRecvMsgSize)
+	require.Equal(t, 0, sc.MaxSendMsgSize)
+	conf.General.MaxRecvMsgSize = 1024
+	conf.General.MaxSendMsgSize = 2048
+	sc = initializeServerConfig(conf, nil)
+	require.Equal(t, 1024, sc.MaxRecvMsgSize)
+	require.Equal(t, 2048, sc.MaxSendMsgSize)
 	testDuration := 10 * time.Second
 	conf.General.Keepalive = localconfig.Keepalive{
 		ServerMinInterval: testDuration,